@@ -23,7 +23,16 @@ type AddrManager struct {
 
 	cQuit chan struct{}
 
-	addrIndex map[string]*peer.Peer // address key to KnownAddress for all addrs.
+	addrIndex map[string]*peer.Peer  // address key to KnownAddress for all addrs.
+	banned    map[string]*BannedPeer // peer id to ban of misbehaving peers
+}
+
+// BannedPeer - peer which is refused until Until
+type BannedPeer struct {
+	PeerID     string
+	RawAddress string
+	Until      time.Time
+	Reason     string
 }
 
 type serializedKnownAddress struct {
@@ -32,10 +41,18 @@ type serializedKnownAddress struct {
 	PublicKey string
 }
 
+type serializedBannedPeer struct {
+	PeerID string
+	Addr   string
+	Until  int64
+	Reason string
+}
+
 type serializedAddrManager struct {
 	Version   int
 	Key       [32]byte
 	Addresses []*serializedKnownAddress
+	Banned    []*serializedBannedPeer
 }
 
 func New(dataDir string) *AddrManager {
@@ -51,8 +68,10 @@ func New(dataDir string) *AddrManager {
 // savePeers saves all the known addresses to a file so they can be read back
 // in at next run.
 func (addrManager *AddrManager) savePeers() error {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
 
-	if len(addrManager.addrIndex) == 0 {
+	if len(addrManager.addrIndex) == 0 && len(addrManager.banned) == 0 {
 		return nil
 	}
 
@@ -73,6 +92,16 @@ func (addrManager *AddrManager) savePeers() error {
 		i++
 	}
 
+	sam.Banned = make([]*serializedBannedPeer, 0, len(addrManager.banned))
+	for _, v := range addrManager.banned {
+		sam.Banned = append(sam.Banned, &serializedBannedPeer{
+			PeerID: v.PeerID,
+			Addr:   v.RawAddress,
+			Until:  v.Until.Unix(),
+			Reason: v.Reason,
+		})
+	}
+
 	w, err := os.Create(addrManager.peersFile)
 	if err != nil {
 		Logger.log.Errorf("Error opening file %s: %+v", addrManager.peersFile, err)
@@ -118,6 +147,7 @@ func (addrManager *AddrManager) reset() {
 	//defer addrManager.mtx.Unlock()
 
 	addrManager.addrIndex = make(map[string]*peer.Peer)
+	addrManager.banned = make(map[string]*BannedPeer)
 }
 
 func (addrManager *AddrManager) deserializePeers(filePath string) error {
@@ -155,6 +185,19 @@ func (addrManager *AddrManager) deserializePeers(filePath string) error {
 		addrManager.addrIndex[peer.RawAddress] = peer
 
 	}
+	now := time.Now()
+	for _, v := range sam.Banned {
+		until := time.Unix(v.Until, 0)
+		if !until.After(now) {
+			continue
+		}
+		addrManager.banned[v.PeerID] = &BannedPeer{
+			PeerID:     v.PeerID,
+			RawAddress: v.Addr,
+			Until:      until,
+			Reason:     v.Reason,
+		}
+	}
 	return nil
 }
 
@@ -236,3 +279,84 @@ func (addrManager *AddrManager) AddressCache() []*peer.Peer {
 	}
 	return allAddr
 }
+
+// Ban refuses peer with given id and address until the given time, the address
+// is removed from known addresses so that it is not dialed anymore
+func (addrManager *AddrManager) Ban(peerID string, rawAddress string, until time.Time, reason string) {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
+
+	addrManager.banned[peerID] = &BannedPeer{
+		PeerID:     peerID,
+		RawAddress: rawAddress,
+		Until:      until,
+		Reason:     reason,
+	}
+	for addr, knownPeer := range addrManager.addrIndex {
+		if addr == rawAddress || knownPeer.PeerID.Pretty() == peerID {
+			delete(addrManager.addrIndex, addr)
+		}
+	}
+}
+
+// Unban lifts ban of peer with given id or address, it returns false when no
+// ban is found
+func (addrManager *AddrManager) Unban(peerIDOrAddress string) bool {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
+
+	found := false
+	for peerID, bannedPeer := range addrManager.banned {
+		if peerID == peerIDOrAddress || bannedPeer.RawAddress == peerIDOrAddress {
+			delete(addrManager.banned, peerID)
+			found = true
+		}
+	}
+	return found
+}
+
+// ClearBanned lifts all bans
+func (addrManager *AddrManager) ClearBanned() {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
+
+	addrManager.banned = make(map[string]*BannedPeer)
+}
+
+// IsBanned returns true if peer id or address is banned and the ban is not
+// expired yet
+func (addrManager *AddrManager) IsBanned(peerID string, rawAddress string) bool {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
+
+	addrManager.expireBanned()
+	for _, bannedPeer := range addrManager.banned {
+		if (peerID != "" && bannedPeer.PeerID == peerID) || (rawAddress != "" && bannedPeer.RawAddress == rawAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+// BannedPeers returns a copy of bans which are not expired
+func (addrManager *AddrManager) BannedPeers() []BannedPeer {
+	addrManager.mtx.Lock()
+	defer addrManager.mtx.Unlock()
+
+	addrManager.expireBanned()
+	bannedPeers := make([]BannedPeer, 0, len(addrManager.banned))
+	for _, bannedPeer := range addrManager.banned {
+		bannedPeers = append(bannedPeers, *bannedPeer)
+	}
+	return bannedPeers
+}
+
+// expireBanned drops expired bans, the lock must be held
+func (addrManager *AddrManager) expireBanned() {
+	now := time.Now()
+	for peerID, bannedPeer := range addrManager.banned {
+		if !bannedPeer.Until.After(now) {
+			delete(addrManager.banned, peerID)
+		}
+	}
+}
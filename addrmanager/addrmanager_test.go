@@ -0,0 +1,47 @@
+package addrmanager
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+func init() {
+	Logger.Init(common.NewBackend(ioutil.Discard).Logger("test"))
+}
+
+func TestBannedPeersSaveLoad(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "addrmanager")
+	if err != nil {
+		t.Fatalf("TempDir %+v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	until := time.Now().Add(time.Hour)
+	addrManager := New(dataDir)
+	addrManager.Ban("peer1", "/ip4/127.0.0.1/tcp/9333", until, "invalid block")
+	addrManager.Ban("peer2", "/ip4/127.0.0.1/tcp/9334", time.Now().Add(-time.Second), "expired")
+	if err := addrManager.savePeers(); err != nil {
+		t.Fatalf("savePeers() error = %+v", err)
+	}
+
+	loaded := New(dataDir)
+	loaded.loadPeers()
+	bannedPeers := loaded.BannedPeers()
+	if len(bannedPeers) != 1 {
+		t.Fatalf("BannedPeers() = %+v, want the ban which is not expired", bannedPeers)
+	}
+	bannedPeer := bannedPeers[0]
+	if bannedPeer.PeerID != "peer1" || bannedPeer.RawAddress != "/ip4/127.0.0.1/tcp/9333" || bannedPeer.Reason != "invalid block" || bannedPeer.Until.Unix() != until.Unix() {
+		t.Fatalf("loaded ban %+v does not match the saved one", bannedPeer)
+	}
+	if !loaded.IsBanned("peer1", "") || !loaded.IsBanned("", "/ip4/127.0.0.1/tcp/9333") {
+		t.Fatalf("loaded ban should refuse peer by id and by address")
+	}
+	if loaded.IsBanned("peer2", "/ip4/127.0.0.1/tcp/9334") {
+		t.Fatalf("expired ban should not be loaded")
+	}
+}
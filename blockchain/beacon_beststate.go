@@ -230,3 +230,64 @@ func (self *BestStateBeacon) GetPubkeyRole(pubkey string) (string, byte) {
 
 	return "", 0
 }
+
+/*
+Clone - copy of the best state which can be updated with a new block without
+touching the current one, see BestStateShard.Clone
+*/
+func (self *BestStateBeacon) Clone() *BestStateBeacon {
+	bestState := *self
+	if self.BestShardHash != nil {
+		bestState.BestShardHash = make(map[byte]common.Hash, len(self.BestShardHash))
+		for shardID, hash := range self.BestShardHash {
+			bestState.BestShardHash[shardID] = hash
+		}
+	}
+	if self.BestShardHeight != nil {
+		bestState.BestShardHeight = make(map[byte]uint64, len(self.BestShardHeight))
+		for shardID, height := range self.BestShardHeight {
+			bestState.BestShardHeight[shardID] = height
+		}
+	}
+	if self.AllShardState != nil {
+		bestState.AllShardState = make(map[byte][]ShardState, len(self.AllShardState))
+		for shardID, shardStates := range self.AllShardState {
+			bestState.AllShardState[shardID] = append([]ShardState{}, shardStates...)
+		}
+	}
+	bestState.BeaconCommittee = cloneStrings(self.BeaconCommittee)
+	bestState.BeaconPendingValidator = cloneStrings(self.BeaconPendingValidator)
+	bestState.CandidateShardWaitingForCurrentRandom = cloneStrings(self.CandidateShardWaitingForCurrentRandom)
+	bestState.CandidateBeaconWaitingForCurrentRandom = cloneStrings(self.CandidateBeaconWaitingForCurrentRandom)
+	bestState.CandidateShardWaitingForNextRandom = cloneStrings(self.CandidateShardWaitingForNextRandom)
+	bestState.CandidateBeaconWaitingForNextRandom = cloneStrings(self.CandidateBeaconWaitingForNextRandom)
+	bestState.ShardCommittee = cloneValidators(self.ShardCommittee)
+	bestState.ShardPendingValidator = cloneValidators(self.ShardPendingValidator)
+	if self.Params != nil {
+		bestState.Params = make(map[string]string, len(self.Params))
+		for key, value := range self.Params {
+			bestState.Params[key] = value
+		}
+	}
+	if self.ShardHandle != nil {
+		bestState.ShardHandle = make(map[byte]bool, len(self.ShardHandle))
+		for shardID, isCurrent := range self.ShardHandle {
+			bestState.ShardHandle[shardID] = isCurrent
+		}
+	}
+	if self.StabilityInstructions != nil {
+		bestState.StabilityInstructions = append([][]string{}, self.StabilityInstructions...)
+	}
+	return &bestState
+}
+
+func cloneValidators(validators map[byte][]string) map[byte][]string {
+	if validators == nil {
+		return nil
+	}
+	res := make(map[byte][]string, len(validators))
+	for shardID, pubkeys := range validators {
+		res[shardID] = cloneStrings(pubkeys)
+	}
+	return res
+}
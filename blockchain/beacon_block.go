@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 const (
@@ -13,7 +14,7 @@ const (
 	RANDOM_TIME   = 100
 	COMMITEES     = 3
 	OFFSET        = 1
-	VERSION       = 1
+	VERSION       = BlockVersion
 	RANDOM_NUMBER = 3
 )
 
@@ -160,8 +161,21 @@ func (self *BeaconHeader) toString() string {
 	return res
 }
 
+/*
+Hash - hash of beacon header
+Headers from FullHeaderHashVersion hash the codec encoding of the whole
+header, older headers (genesis, stored chains) keep the legacy string hash
+*/
 func (self *BeaconHeader) Hash() common.Hash {
-	return common.DoubleHashH([]byte(self.toString()))
+	if self.Version < FullHeaderHashVersion {
+		return common.DoubleHashH([]byte(self.toString()))
+	}
+	data, err := codec.Marshal(self)
+	if err != nil {
+		// header only holds plain fields, encoding can not fail
+		panic(err)
+	}
+	return common.DoubleHashH(data)
 }
 
 // func (self *BeaconHeader) UnmarshalJSON(data []byte) error {
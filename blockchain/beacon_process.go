@@ -14,6 +14,7 @@ import (
 	"github.com/ninjadotorg/constant/cashec"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 /*
@@ -145,42 +146,77 @@ func (self *BlockChain) InsertBeaconBlock(block *BeaconBlock) error {
 
 	Logger.log.Infof("Update BestState with Beacon Block %+v \n", *block.Hash())
 	//========Update best state with new block
-	if err := self.BestState.Beacon.Update(block); err != nil {
+	// on a copy, current best state stays as is until the block is stored
+	beaconBestState := self.BestState.Beacon.Clone()
+	if err := beaconBestState.Update(block); err != nil {
 		return err
 	}
 
 	Logger.log.Infof("Verify Post Processing Beacon Block %+v \n", *block.Hash())
 	//========Post verififcation: verify new beaconstate with corresponding block
-	if err := self.BestState.Beacon.VerifyPostProcessingBeaconBlock(block); err != nil {
+	if err := beaconBestState.VerifyPostProcessingBeaconBlock(block); err != nil {
 		return err
 	}
 
-	//========Store new Beaconblock and new Beacon bestState in cache
+	//========Store new Beaconblock and new Beacon bestState
+	// all writes of this block are committed at once
+	if err := self.ProcessStoreBeaconBlock(block, beaconBestState); err != nil {
+		return err
+	}
+	//=========Remove shard block in beacon pool
+	Logger.log.Infof("Remove block from pool %+v \n", *block.Hash())
+	self.config.ShardToBeaconPool.RemovePendingBlock(self.BestState.Beacon.BestShardHeight)
+	self.config.NodeBeaconPool.RemoveBlocksBelow(block.Header.Height)
+
+	Logger.log.Infof("Finish Insert new block %d, with hash %x", block.Header.Height, *block.Hash())
+	return nil
+}
+
+/*
+	Store beacon block, best state, indexes and accepted shard blocks
+	All writes go through one database batch so that the block
+	and all of its side effects are committed all-or-nothing
+	bestState is the best state already updated with block, it replaces the
+	current beacon best state only once the batch is written
+*/
+func (self *BlockChain) ProcessStoreBeaconBlock(block *BeaconBlock, bestState *BestStateBeacon) error {
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
 	Logger.log.Infof("Store Beacon BestState %+v \n", *block.Hash())
-	if err := self.config.DataBase.StoreBeaconBestState(self.BestState.Beacon); err != nil {
+	if err := batch.StoreBeaconBestState(bestState); err != nil {
 		return err
 	}
 	Logger.log.Infof("Store Beacon Block %+v \n", *block.Hash())
-	if err := self.config.DataBase.StoreBeaconBlock(block); err != nil {
+	if err := batch.StoreBeaconBlock(block); err != nil {
 		return err
 	}
 	blockHash := block.Hash()
-	if err := self.config.DataBase.StoreBeaconBlockIndex(blockHash, block.Header.Height); err != nil {
+	if err := batch.StoreBeaconBlockIndex(blockHash, block.Header.Height); err != nil {
 		return err
 	}
 	for shardID, shardStates := range block.Body.ShardState {
 		for _, shardState := range shardStates {
-			self.config.DataBase.StoreAcceptedShardToBeacon(shardID, block.Header.Height, &shardState.Hash)
+			batch.StoreAcceptedShardToBeacon(shardID, block.Header.Height, &shardState.Hash)
 		}
 	}
-	if err := self.config.DataBase.StoreBeaconCommitteeByHeight(block.Header.Height, self.BestState.Beacon.ShardCommittee); err != nil {
+	if err := batch.StoreBeaconCommitteeByHeight(block.Header.Height, bestState.ShardCommittee); err != nil {
 		return err
 	}
-	//=========Remove shard block in beacon pool
-	Logger.log.Infof("Remove block from pool %+v \n", *block.Hash())
-	self.config.ShardToBeaconPool.RemovePendingBlock(self.BestState.Beacon.BestShardHeight)
-
-	Logger.log.Infof("Finish Insert new block %d, with hash %x", block.Header.Height, *block.Hash())
+	// Undo journal of this block, used by RollbackBeaconBlock
+	if err := batch.StoreUndo(blockHash, batch.Undo()); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	// blocks below the prune and rollback windows, not in the undo journal
+	if err := self.pruneBeaconBlock(batch, block.Header.Height); err != nil {
+		return err
+	}
+	if err := self.dropBeaconUndo(batch, block.Header.Height); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	self.BestState.Beacon = bestState
 	return nil
 }
 
@@ -282,7 +318,9 @@ func (self *BlockChain) AcceptBeaconBlock(blockHash *common.Hash) error {
 		return err
 	}
 	Logger.log.Infof("Accept block %d, with hash %+v", beaconBlock.Header.Height, blockHash)
-	err = self.BestState.Beacon.Update(&beaconBlock)
+	// best state is swapped in once the block is stored
+	beaconBestState := self.BestState.Beacon.Clone()
+	err = beaconBestState.Update(&beaconBlock)
 	if err != nil {
 		return err
 	}
@@ -295,9 +333,11 @@ func (self *BlockChain) AcceptBeaconBlock(blockHash *common.Hash) error {
 	// 	Logger.log.Error("Current best state and stored block %+v are not compatible", blockHash)
 	// 	return NewBlockChainError(BeaconError, errors.New("Current best state and stored block are not compatible"))
 	// }
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
 	//===================Store Block============================
 	Logger.log.Infof("Store Beacon block %+v", blockHash)
-	if err := self.config.DataBase.StoreBeaconBlock(beaconBlock); err != nil {
+	if err := batch.StoreBeaconBlock(beaconBlock); err != nil {
 		Logger.log.Error("Error store beacon block", blockHash, "in beacon chain")
 		return err
 	}
@@ -306,10 +346,23 @@ func (self *BlockChain) AcceptBeaconBlock(blockHash *common.Hash) error {
 	Logger.log.Infof("Store BeaconBestState block %+v", blockHash)
 	//Process stored block with current best state
 
-	if err := self.config.DataBase.StoreBeaconBestState(self.BestState.Beacon); err != nil {
+	if err := batch.StoreBeaconBestState(beaconBestState); err != nil {
 		Logger.log.Error("Error Store best state for block", blockHash, "in beacon chain")
 		return NewBlockChainError(UnExpectedError, err)
 	}
+	if err := batch.StoreUndo(blockHash, batch.Undo()); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := self.pruneBeaconBlock(batch, beaconBlock.Header.Height); err != nil {
+		return err
+	}
+	if err := self.dropBeaconUndo(batch, beaconBlock.Header.Height); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	self.BestState.Beacon = beaconBestState
 	Logger.log.Infof("Accepted block %+v", blockHash)
 	return nil
 }
@@ -346,7 +399,9 @@ func (self *BlockChain) VerifyPreProcessingBeaconBlock(block *BeaconBlock) error
 		return NewBlockChainError(DBError, err)
 	}
 	parentBlockInterface := NewBeaconBlock()
-	json.Unmarshal(parentBlock, &parentBlockInterface)
+	if err := codec.Unmarshal(parentBlock, &parentBlockInterface); err != nil {
+		return NewBlockChainError(BlockCodecError, err)
+	}
 	// Verify block height with parent block
 	if parentBlockInterface.Header.Height+1 != block.Header.Height {
 		return NewBlockChainError(BlockHeightError, errors.New("Block height of new block should be :"+strconv.Itoa(int(block.Header.Height+1))))
@@ -0,0 +1,109 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ninjadotorg/constant/database"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// failingWriteDB is a database whose batches can not be written
+type failingWriteDB struct {
+	database.DatabaseInterface
+}
+
+func (db failingWriteDB) NewBatch() database.Batch {
+	return failingWriteBatch{db.DatabaseInterface.NewBatch()}
+}
+
+type failingWriteBatch struct {
+	database.Batch
+}
+
+func (failingWriteBatch) Write() error {
+	return errors.New("write failed")
+}
+
+func TestInsertBeaconBlockKeepsBestStateOnWriteError(t *testing.T) {
+	chain := newTestChain(t)
+	insertTestBeaconBlock(t, chain)
+	block := newTestBeaconBlock(t, chain)
+	bestState := chain.BestState.Beacon
+	before, _ := json.Marshal(bestState)
+
+	db := chain.config.DataBase
+	chain.config.DataBase = failingWriteDB{db}
+	if err := chain.InsertBeaconBlock(block); err == nil {
+		t.Fatalf("InsertBeaconBlock should fail when the batch can not be written")
+	}
+	after, _ := json.Marshal(chain.BestState.Beacon)
+	if chain.BestState.Beacon != bestState || !bytes.Equal(before, after) {
+		t.Fatalf("best state changed by a block which is not stored\nbefore %s\nafter %s", before, after)
+	}
+
+	// the same block is inserted once the database is back
+	chain.config.DataBase = db
+	if err := chain.InsertBeaconBlock(block); err != nil {
+		t.Fatalf("InsertBeaconBlock %+v", err)
+	}
+	if chain.BestState.Beacon.BestBlockHash != *block.Hash() {
+		t.Fatalf("best block should be the inserted block")
+	}
+}
+
+func TestProcessStoreShardBlockKeepsBestStateOnWriteError(t *testing.T) {
+	chain := newTestChain(t)
+	block := newTestShardBlock(t, chain, 0, []metadata.Transaction{newTestTx(t, chain)})
+	bestState := chain.BestState.Shard[0]
+	before, _ := json.Marshal(bestState)
+
+	newBestState := bestState.Clone()
+	if err := newBestState.Update(block, nil); err != nil {
+		t.Fatalf("Update %+v", err)
+	}
+	db := chain.config.DataBase
+	chain.config.DataBase = failingWriteDB{db}
+	if err := chain.ProcessStoreShardBlock(block, newBestState); err == nil {
+		t.Fatalf("ProcessStoreShardBlock should fail when the batch can not be written")
+	}
+	after, _ := json.Marshal(chain.BestState.Shard[0])
+	if chain.BestState.Shard[0] != bestState || !bytes.Equal(before, after) {
+		t.Fatalf("best state changed by a block which is not stored\nbefore %s\nafter %s", before, after)
+	}
+
+	chain.config.DataBase = db
+	if err := chain.ProcessStoreShardBlock(block, newBestState); err != nil {
+		t.Fatalf("ProcessStoreShardBlock %+v", err)
+	}
+	if chain.BestState.Shard[0] != newBestState {
+		t.Fatalf("best state should be swapped in once the block is stored")
+	}
+}
+
+func TestCloneBestState(t *testing.T) {
+	chain := newTestChain(t)
+	beaconBestState := chain.BestState.Beacon
+	beaconClone := beaconBestState.Clone()
+	beaconClone.BeaconCommittee[0] = "changed"
+	beaconClone.ShardCommittee[0][0] = "changed"
+	beaconClone.BestShardHeight[0] = 100
+	beaconClone.Params["key"] = "changed"
+	if beaconBestState.BeaconCommittee[0] == "changed" || beaconBestState.ShardCommittee[0][0] == "changed" ||
+		beaconBestState.BestShardHeight[0] == 100 || beaconBestState.Params["key"] == "changed" {
+		t.Fatalf("changes of a beacon best state clone should not be seen by the original")
+	}
+
+	shardBestState := chain.BestState.Shard[0]
+	shardClone := shardBestState.Clone()
+	shardClone.ShardCommittee[0] = "changed"
+	if shardClone.BestCrossShard == nil {
+		shardClone.BestCrossShard = make(map[byte]uint64)
+	}
+	shardClone.BestCrossShard[1] = 100
+	if shardBestState.ShardCommittee[0] == "changed" || shardBestState.BestCrossShard[1] == 100 {
+		t.Fatalf("changes of a shard best state clone should not be seen by the original")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/common/base58"
+	"github.com/ninjadotorg/constant/common/codec"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/ninjadotorg/constant/database/lvdb"
 	"github.com/ninjadotorg/constant/metadata"
@@ -42,6 +43,7 @@ type BlockChain struct {
 	//=====cache
 	beaconBlock        map[string][]byte
 	highestBeaconBlock string
+	txVerifyCache      *txVerifyCache
 
 	//channel
 	cQuitSync  chan struct{}
@@ -55,9 +57,9 @@ type BlockChain struct {
 		Beacon BeaconChainState
 	}
 	BeaconStateCh  chan *PeerBeaconChainState
-	newBeaconBlkCh chan *BeaconBlock
+	newBeaconBlkCh chan *PeerBeaconBlock
 	ShardStateCh   map[byte](chan *PeerShardChainState)
-	newShardBlkCh  map[byte](*chan *ShardBlock)
+	newShardBlkCh  map[byte](*chan *PeerShardBlock)
 }
 type BestState struct {
 	Beacon *BestStateBeacon
@@ -99,17 +101,30 @@ type Config struct {
 	//snapshot reward
 	customTokenRewardSnapshot map[string]uint64
 
+	// PruneDepth is the number of most recent blocks of each chain which
+	// keep their body, older blocks only keep their header. 0 disables pruning.
+	PruneDepth uint64
+	// UndoDepth is the number of most recent blocks of each chain which keep
+	// their undo journal and can be rolled back, DefaultUndoDepth if 0.
+	UndoDepth uint64
+
 	ShardToBeaconPool ShardToBeaconPool
 	CrossShardPool    CrossShardPool
 	NodeBeaconPool    NodeBeaconPool
 	NodeShardPool     NodeShardPool
-	Server            interface {
+	OrphanBlockPool   OrphanBlockPool
+	// TxPool is set by SetTxPool as mempool is created after chain
+	TxPool TxPool
+	Server interface {
 		PushMessageGetBeaconState() error
 		PushMessageGetShardState(byte) error
 		PushMessageGetBlockBeacon(from uint64, to uint64, peerID libp2p.ID) error
 		PushMessageGetBlockShard(shardID byte, from uint64, to uint64, peerID libp2p.ID) error
 		PushMessageGetShardToBeacon(shardID byte, blkHash common.Hash) error
 		PushMessageGetShardToBeacons(shardID byte, from uint64, to uint64) error
+		PushMessageTx(tx metadata.Transaction) error
+		// OnBlockRejected adds ban score to peer which sent an invalid block
+		OnBlockRejected(peerID libp2p.ID, reason string)
 	}
 	UserKeySet *cashec.KeySet
 }
@@ -127,6 +142,10 @@ func (self *BlockChain) Init(config *Config) error {
 	}
 
 	self.config = *config
+	if self.config.UndoDepth == 0 {
+		self.config.UndoDepth = DefaultUndoDepth
+	}
+	self.txVerifyCache = newTxVerifyCache(txVerifyCacheSize)
 
 	// Initialize the chain state from the passed database.  When the db
 	// does not yet contain any chain state, both it and the chain state
@@ -134,6 +153,12 @@ func (self *BlockChain) Init(config *Config) error {
 	if err := self.initChainState(); err != nil {
 		return err
 	}
+	if err := self.pruneOldBlocks(); err != nil {
+		Logger.log.Error(err)
+	}
+	if err := self.dropOldUndo(); err != nil {
+		Logger.log.Error(err)
+	}
 
 	// for chainIndex, bestState := range self.BestState {
 	// 	Logger.log.Infof("BlockChain state for chain #%d (Height %d, Best block hash %+v, Total tx %d, Salary fund %d, Gov Param %+v)",
@@ -141,9 +166,13 @@ func (self *BlockChain) Init(config *Config) error {
 	// }
 	self.cQuitSync = make(chan struct{})
 	self.ShardStateCh = make(map[byte](chan *PeerShardChainState))
-	self.newShardBlkCh = make(map[byte](*chan *ShardBlock))
+	self.newShardBlkCh = make(map[byte](*chan *PeerShardBlock))
 	self.syncStatus.Shard = make(map[byte](chan struct{}))
 	self.knownChainState.Shards = make(map[byte]ShardChainState)
+	// without a server (offline tools) there is no peer to sync with
+	if self.config.Server == nil {
+		return nil
+	}
 	self.SyncBeacon()
 	for _, shardID := range self.config.RelayShards {
 		self.SyncShard(shardID)
@@ -154,6 +183,12 @@ func (self *BlockChain) SetShardToBeaconPool(db database.DatabaseInterface) {
 	self.config.ShardToBeaconPool.SetDatabase(db)
 }
 
+// SetTxPool - orphan txs of mempool are processed again when a shard block
+// is inserted
+func (self *BlockChain) SetTxPool(txPool TxPool) {
+	self.config.TxPool = txPool
+}
+
 // Before call store and get block from cache or db, call chain.lock()
 func (self *BlockChain) StoreMaybeAcceptBeaconBeststate(beaconBestState BestStateBeacon) (string, error) {
 	res, err := json.Marshal(beaconBestState)
@@ -351,7 +386,7 @@ func (self *BlockChain) initShardState(shardID byte) error {
 	// 	initBlock.Header.PrevBlockHash = common.Hash{}
 	// }
 
-	self.BestState.Shard[shardID] = &BestStateShard{
+	shardBestState := &BestStateShard{
 		ShardCommittee:        []string{},
 		ShardPendingValidator: []string{},
 		BestShardBlock:        &ShardBlock{},
@@ -359,17 +394,19 @@ func (self *BlockChain) initShardState(shardID byte) error {
 
 	_, newShardCandidate := GetStakingCandidate(*self.config.ChainParams.GenesisBeaconBlock)
 
-	self.BestState.Shard[shardID].ShardCommittee = append(self.BestState.Shard[shardID].ShardCommittee, newShardCandidate[int(shardID)*self.config.ChainParams.ShardCommitteeSize:(int(shardID)*self.config.ChainParams.ShardCommitteeSize)+self.config.ChainParams.ShardCommitteeSize]...)
+	shardBestState.ShardCommittee = append(shardBestState.ShardCommittee, newShardCandidate[int(shardID)*self.config.ChainParams.ShardCommitteeSize:(int(shardID)*self.config.ChainParams.ShardCommitteeSize)+self.config.ChainParams.ShardCommitteeSize]...)
 
 	genesisBeaconBlk, err := self.GetBeaconBlockByHeight(1)
 	if err != nil {
 		return NewBlockChainError(UnExpectedError, err)
 	}
-	err = self.BestState.Shard[shardID].Update(&initBlock, []*BeaconBlock{genesisBeaconBlk})
+	err = shardBestState.Update(&initBlock, []*BeaconBlock{genesisBeaconBlk})
 	if err != nil {
 		return err
 	}
-	self.ProcessStoreShardBlock(&initBlock)
+	if err := self.ProcessStoreShardBlock(&initBlock, shardBestState); err != nil {
+		return err
+	}
 
 	// fmt.Println()
 	// fmt.Println(*initBlock.Hash())
@@ -385,18 +422,23 @@ func (self *BlockChain) initBeaconState() error {
 	self.BestState.Beacon.Update(initBlock)
 	// Insert new block into beacon chain
 
-	if err := self.StoreBeaconBestState(); err != nil {
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
+	if err := self.StoreBeaconBestState(batch); err != nil {
 		Logger.log.Error("Error Store best state for block", self.BestState.Beacon.BestBlockHash, "in beacon chain")
 		return NewBlockChainError(UnExpectedError, err)
 	}
-	if err := self.config.DataBase.StoreBeaconBlock(self.BestState.Beacon.BestBlock); err != nil {
+	if err := batch.StoreBeaconBlock(self.BestState.Beacon.BestBlock); err != nil {
 		Logger.log.Error("Error store beacon block", self.BestState.Beacon.BestBlockHash, "in beacon chain")
 		return err
 	}
 	blockHash := initBlock.Hash()
-	if err := self.config.DataBase.StoreBeaconBlockIndex(blockHash, initBlock.Header.Height); err != nil {
+	if err := batch.StoreBeaconBlockIndex(blockHash, initBlock.Header.Height); err != nil {
 		return err
 	}
+	if err := batch.Write(); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
 	//=======================Init cache data==========================
 	self.BestState.beacon = make(map[string][]byte)
 	return nil
@@ -435,14 +477,17 @@ func (self *BlockChain) GetBeaconBlockByHeight(height uint64) (*BeaconBlock, err
 Fetch DatabaseInterface and get block data by block hash
 */
 func (self *BlockChain) GetBeaconBlockByHash(hash *common.Hash) (*BeaconBlock, error) {
+	if err := self.checkBlockPruned(hash); err != nil {
+		return nil, err
+	}
 	blockBytes, err := self.config.DataBase.FetchBeaconBlock(hash)
 	if err != nil {
 		return nil, err
 	}
 	block := BeaconBlock{}
-	err = json.Unmarshal(blockBytes, &block)
+	err = codec.Unmarshal(blockBytes, &block)
 	if err != nil {
-		return nil, err
+		return nil, NewBlockChainError(BlockCodecError, err)
 	}
 	return &block, nil
 }
@@ -470,7 +515,9 @@ func (self *BlockChain) GetShardBlockByHeight(height uint64, shardID byte) (*Sha
 		return nil, err
 	}
 	block, err := self.GetShardBlockByHash(hashBlock)
-
+	if err != nil {
+		return nil, err
+	}
 	return block, nil
 }
 
@@ -478,15 +525,18 @@ func (self *BlockChain) GetShardBlockByHeight(height uint64, shardID byte) (*Sha
 Fetch DatabaseInterface and get block data by block hash
 */
 func (self *BlockChain) GetShardBlockByHash(hash *common.Hash) (*ShardBlock, error) {
+	if err := self.checkBlockPruned(hash); err != nil {
+		return nil, err
+	}
 	blockBytes, err := self.config.DataBase.FetchBlock(hash)
 	if err != nil {
 		return nil, err
 	}
 
 	block := ShardBlock{}
-	err = json.Unmarshal(blockBytes, &block)
+	err = codec.Unmarshal(blockBytes, &block)
 	if err != nil {
-		return nil, err
+		return nil, NewBlockChainError(BlockCodecError, err)
 	}
 	return &block, nil
 }
@@ -494,15 +544,15 @@ func (self *BlockChain) GetShardBlockByHash(hash *common.Hash) (*ShardBlock, err
 /*
 Store best state of block(best block, num of tx, ...) into Database
 */
-func (self *BlockChain) StoreBeaconBestState() error {
-	return self.config.DataBase.StoreBeaconBestState(self.BestState.Beacon)
+func (self *BlockChain) StoreBeaconBestState(db database.DatabaseInterface) error {
+	return db.StoreBeaconBestState(self.BestState.Beacon)
 }
 
 /*
 Store best state of block(best block, num of tx, ...) into Database
 */
-func (self *BlockChain) StoreShardBestState(shardID byte) error {
-	return self.config.DataBase.StoreBestState(self.BestState.Shard[shardID], shardID)
+func (self *BlockChain) StoreShardBestState(db database.DatabaseInterface, shardID byte) error {
+	return db.StoreBestState(self.BestState.Shard[shardID], shardID)
 }
 
 /*
@@ -521,8 +571,8 @@ func (self *BlockChain) GetShardBestState(shardID byte) (*BestStateShard, error)
 /*
 Store block into Database
 */
-func (self *BlockChain) StoreShardBlock(block *ShardBlock) error {
-	return self.config.DataBase.StoreShardBlock(block, block.Header.ShardID)
+func (self *BlockChain) StoreShardBlock(db database.DatabaseInterface, block *ShardBlock) error {
+	return db.StoreShardBlock(block, block.Header.ShardID)
 }
 
 /*
@@ -530,7 +580,8 @@ func (self *BlockChain) StoreShardBlock(block *ShardBlock) error {
 */
 func (self *BlockChain) StoreShardBlockHeader(block *ShardBlock) error {
 	//Logger.log.Infof("Store Block Header, block header %+v, block hash %+v, chain id %+v",block.Header, block.blockHash, block.Header.shardID)
-	return self.config.DataBase.StoreShardBlockHeader(block.Header, block.Hash(), block.Header.ShardID)
+	// stored as a block without body, so that it is read back as any other block
+	return self.config.DataBase.StoreShardBlockHeader(&ShardBlock{Header: block.Header}, block.Hash(), block.Header.ShardID)
 }
 
 /*
@@ -549,21 +600,21 @@ Save index(height) of block by block hash
 and
 Save block hash by index(height) of block
 */
-func (self *BlockChain) StoreShardBlockIndex(block *ShardBlock) error {
-	return self.config.DataBase.StoreShardBlockIndex(block.Hash(), block.Header.Height, block.Header.ShardID)
+func (self *BlockChain) StoreShardBlockIndex(db database.DatabaseInterface, block *ShardBlock) error {
+	return db.StoreShardBlockIndex(block.Hash(), block.Header.Height, block.Header.ShardID)
 }
 
-func (self *BlockChain) StoreTransactionIndex(txHash *common.Hash, blockHash *common.Hash, index int) error {
-	return self.config.DataBase.StoreTransactionIndex(txHash, blockHash, index)
+func (self *BlockChain) StoreTransactionIndex(db database.DatabaseInterface, txHash *common.Hash, blockHash *common.Hash, index int) error {
+	return db.StoreTransactionIndex(txHash, blockHash, index)
 }
 
 /*
 Uses an existing database to update the set of used tx by saving list nullifier of privacy,
 this is a list tx-out which are used by a new tx
 */
-func (self *BlockChain) StoreSerialNumbersFromTxViewPoint(view TxViewPoint) error {
+func (self *BlockChain) StoreSerialNumbersFromTxViewPoint(db database.DatabaseInterface, view TxViewPoint) error {
 	for _, item1 := range view.listSerialNumbers {
-		err := self.config.DataBase.StoreSerialNumbers(view.tokenID, item1, view.shardID)
+		err := db.StoreSerialNumbers(view.tokenID, item1, view.shardID)
 		if err != nil {
 			return err
 		}
@@ -575,9 +626,9 @@ func (self *BlockChain) StoreSerialNumbersFromTxViewPoint(view TxViewPoint) erro
 Uses an existing database to update the set of used tx by saving list SNDerivator of privacy,
 this is a list tx-out which are used by a new tx
 */
-func (self *BlockChain) StoreSNDerivatorsFromTxViewPoint(view TxViewPoint) error {
+func (self *BlockChain) StoreSNDerivatorsFromTxViewPoint(db database.DatabaseInterface, view TxViewPoint) error {
 	for _, item1 := range view.listSnD {
-		err := self.config.DataBase.StoreSNDerivators(view.tokenID, item1, view.shardID)
+		err := db.StoreSNDerivators(view.tokenID, item1, view.shardID)
 
 		if err != nil {
 			return err
@@ -590,7 +641,7 @@ func (self *BlockChain) StoreSNDerivatorsFromTxViewPoint(view TxViewPoint) error
 Uses an existing database to update the set of not used tx by saving list commitments of privacy,
 this is a list tx-in which are used by a new tx
 */
-func (self *BlockChain) StoreCommitmentsFromTxViewPoint(view TxViewPoint) error {
+func (self *BlockChain) StoreCommitmentsFromTxViewPoint(db database.DatabaseInterface, view TxViewPoint) error {
 
 	// commitment
 	keys := make([]string, 0, len(view.mapCommitments))
@@ -607,7 +658,7 @@ func (self *BlockChain) StoreCommitmentsFromTxViewPoint(view TxViewPoint) error
 			return err
 		}
 		for _, com := range item1 {
-			err = self.config.DataBase.StoreCommitments(view.tokenID, pubkeyBytes, com, view.shardID)
+			err = db.StoreCommitments(view.tokenID, pubkeyBytes, com, view.shardID)
 			if err != nil {
 				return err
 			}
@@ -632,7 +683,7 @@ func (self *BlockChain) StoreCommitmentsFromTxViewPoint(view TxViewPoint) error
 		for _, com := range item1 {
 			lastByte := pubkeyBytes[len(pubkeyBytes)-1]
 			shardID := common.GetShardIDFromLastByte(lastByte)
-			err = self.config.DataBase.StoreOutputCoins(view.tokenID, pubkeyBytes, com.Bytes(), shardID)
+			err = db.StoreOutputCoins(view.tokenID, pubkeyBytes, com.Bytes(), shardID)
 			if err != nil {
 				return err
 			}
@@ -1111,11 +1162,11 @@ func (self *BlockChain) updateCrowdsalePaymentData(tx metadata.Transaction, sale
 // with light mode - node only fetch outputcoins of account in local wallet -> smaller data
 // with not light mode - node fetch all outputcoins of all accounts in network -> big data
 // (note: still storage full data of commitments, serialnumbersm snderivator to check double spend)
-func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) error {
+func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(db database.DatabaseInterface, block *ShardBlock) error {
 	// Fetch data from block into tx View point
 	view := NewTxViewPoint(block.Header.ShardID)
 	// TODO: 0xsirrush check lightmode turn off
-	err := view.fetchTxViewPointFromBlock(self.config.DataBase, block, nil)
+	err := view.fetchTxViewPointFromBlock(db, block, nil)
 	if err != nil {
 		return err
 	}
@@ -1126,7 +1177,7 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 		case transaction.CustomTokenInit:
 			{
 				Logger.log.Info("Store custom token when it is issued", customTokenTx.TxTokenData.PropertyID, customTokenTx.TxTokenData.PropertySymbol, customTokenTx.TxTokenData.PropertyName)
-				err = self.config.DataBase.StoreCustomToken(&customTokenTx.TxTokenData.PropertyID, customTokenTx.Hash()[:])
+				err = db.StoreCustomToken(&customTokenTx.TxTokenData.PropertyID, customTokenTx.Hash()[:])
 				if err != nil {
 					return err
 				}
@@ -1138,12 +1189,12 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 		}
 		// save tx which relate to custom token
 		// Reject Double spend UTXO before enter this state
-		err = self.StoreCustomTokenPaymentAddresstHistory(customTokenTx)
+		err = self.StoreCustomTokenPaymentAddresstHistory(db, customTokenTx)
 		if err != nil {
 			// Skip double spend
 			return err
 		}
-		err = self.config.DataBase.StoreCustomTokenTx(&customTokenTx.TxTokenData.PropertyID, block.Header.ShardID, block.Header.Height, indexTx, customTokenTx.Hash()[:])
+		err = db.StoreCustomTokenTx(&customTokenTx.TxTokenData.PropertyID, block.Header.ShardID, block.Header.Height, indexTx, customTokenTx.Hash()[:])
 		if err != nil {
 			return err
 		}
@@ -1151,7 +1202,7 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 		// replace 1000 with proper value for snapshot
 		if block.Header.Height%1000 == 0 {
 			// list of unreward-utxo
-			self.config.customTokenRewardSnapshot, err = self.config.DataBase.GetCustomTokenPaymentAddressesBalance(&customTokenTx.TxTokenData.PropertyID)
+			self.config.customTokenRewardSnapshot, err = db.GetCustomTokenPaymentAddressesBalance(&customTokenTx.TxTokenData.PropertyID)
 			if err != nil {
 				return err
 			}
@@ -1168,7 +1219,7 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 		case transaction.CustomTokenInit:
 			{
 				Logger.log.Info("Store custom token when it is issued", privacyCustomTokenTx.TxTokenPrivacyData.PropertyID, privacyCustomTokenTx.TxTokenPrivacyData.PropertySymbol, privacyCustomTokenTx.TxTokenPrivacyData.PropertyName)
-				err = self.config.DataBase.StorePrivacyCustomToken(&privacyCustomTokenTx.TxTokenPrivacyData.PropertyID, privacyCustomTokenTx.Hash()[:])
+				err = db.StorePrivacyCustomToken(&privacyCustomTokenTx.TxTokenPrivacyData.PropertyID, privacyCustomTokenTx.Hash()[:])
 				if err != nil {
 					return err
 				}
@@ -1178,22 +1229,22 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 				Logger.log.Info("Transfer custom token %+v", privacyCustomTokenTx)
 			}
 		}
-		err = self.config.DataBase.StorePrivacyCustomTokenTx(&privacyCustomTokenTx.TxTokenPrivacyData.PropertyID, block.Header.ShardID, block.Header.Height, indexTx, privacyCustomTokenTx.Hash()[:])
+		err = db.StorePrivacyCustomTokenTx(&privacyCustomTokenTx.TxTokenPrivacyData.PropertyID, block.Header.ShardID, block.Header.Height, indexTx, privacyCustomTokenTx.Hash()[:])
 		if err != nil {
 			return err
 		}
 
-		err = self.StoreSerialNumbersFromTxViewPoint(*privacyCustomTokenSubView)
+		err = self.StoreSerialNumbersFromTxViewPoint(db, *privacyCustomTokenSubView)
 		if err != nil {
 			return err
 		}
 
-		err = self.StoreCommitmentsFromTxViewPoint(*privacyCustomTokenSubView)
+		err = self.StoreCommitmentsFromTxViewPoint(db, *privacyCustomTokenSubView)
 		if err != nil {
 			return err
 		}
 
-		err = self.StoreSNDerivatorsFromTxViewPoint(*privacyCustomTokenSubView)
+		err = self.StoreSNDerivatorsFromTxViewPoint(db, *privacyCustomTokenSubView)
 		if err != nil {
 			return err
 		}
@@ -1202,17 +1253,17 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 	// Update the list nullifiers and commitment, snd set using the state of the used tx view point. This
 	// entails adding the new
 	// ones created by the block.
-	err = self.StoreSerialNumbersFromTxViewPoint(*view)
+	err = self.StoreSerialNumbersFromTxViewPoint(db, *view)
 	if err != nil {
 		return err
 	}
 
-	err = self.StoreCommitmentsFromTxViewPoint(*view)
+	err = self.StoreCommitmentsFromTxViewPoint(db, *view)
 	if err != nil {
 		return err
 	}
 
-	err = self.StoreSNDerivatorsFromTxViewPoint(*view)
+	err = self.StoreSNDerivatorsFromTxViewPoint(db, *view)
 	if err != nil {
 		return err
 	}
@@ -1224,7 +1275,7 @@ func (self *BlockChain) CreateAndSaveTxViewPointFromBlock(block *ShardBlock) err
 // 	KeyWallet: token-paymentAddress  -[-]-  {tokenId}  -[-]-  {paymentAddress}  -[-]-  {txHash}  -[-]-  {voutIndex}
 //   H: value-spent/unspent-rewarded/unreward
 // */
-func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(customTokenTx *transaction.TxCustomToken) error {
+func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(db database.DatabaseInterface, customTokenTx *transaction.TxCustomToken) error {
 	Splitter := lvdb.Splitter
 	TokenPaymentAddressPrefix := lvdb.TokenPaymentAddressPrefix
 	unspent := lvdb.Unspent
@@ -1245,11 +1296,11 @@ func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(customTokenTx *tr
 		paymentAddressKey = append(paymentAddressKey, utxoHash[:]...)
 		paymentAddressKey = append(paymentAddressKey, Splitter...)
 		paymentAddressKey = append(paymentAddressKey, byte(voutIndex))
-		_, err := self.config.DataBase.HasValue(paymentAddressKey)
+		_, err := db.HasValue(paymentAddressKey)
 		if err != nil {
 			return err
 		}
-		value, err := self.config.DataBase.Get(paymentAddressKey)
+		value, err := db.Get(paymentAddressKey)
 		if err != nil {
 			return err
 		}
@@ -1260,7 +1311,7 @@ func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(customTokenTx *tr
 		}
 		// new value: {value}-spent-unreward/reward
 		newValues := values[0] + string(Splitter) + string(spent) + string(Splitter) + values[2]
-		if err := self.config.DataBase.Put(paymentAddressKey, []byte(newValues)); err != nil {
+		if err := db.Put(paymentAddressKey, []byte(newValues)); err != nil {
 			return err
 		}
 	}
@@ -1276,7 +1327,7 @@ func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(customTokenTx *tr
 		paymentAddressKey = append(paymentAddressKey, utxoHash[:]...)
 		paymentAddressKey = append(paymentAddressKey, Splitter...)
 		paymentAddressKey = append(paymentAddressKey, byte(voutIndex))
-		ok, err := self.config.DataBase.HasValue(paymentAddressKey)
+		ok, err := db.HasValue(paymentAddressKey)
 		// Vout already exist
 		if ok {
 			return errors.New("UTXO already exist")
@@ -1286,7 +1337,7 @@ func (self *BlockChain) StoreCustomTokenPaymentAddresstHistory(customTokenTx *tr
 		}
 		// init value: {value}-unspent-unreward
 		paymentAddressValue := strconv.Itoa(int(value)) + string(Splitter) + string(unspent) + string(Splitter) + string(unreward)
-		if err := self.config.DataBase.Put(paymentAddressKey, []byte(paymentAddressValue)); err != nil {
+		if err := db.Put(paymentAddressKey, []byte(paymentAddressValue)); err != nil {
 			return err
 		}
 	}
@@ -1494,7 +1545,10 @@ func (self *BlockChain) GetTransactionByHash(txHash *common.Hash) (byte, *common
 	}
 	block, err1 := self.GetShardBlockByHash(blockHash)
 	if err1 != nil {
-		Logger.log.Errorf("ERROR", err1, "NO Transaction in block with hash &+v", blockHash, "and index", index, "contains", block.Body.Transactions[index])
+		Logger.log.Errorf("ERROR %+v NO Transaction in block with hash %+v and index %d", err1, blockHash, index)
+		if IsBlockPrunedError(err1) {
+			return byte(255), nil, -1, nil, err1
+		}
 		return byte(255), nil, -1, nil, NewBlockChainError(UnExpectedError, err1)
 	}
 	//Logger.log.Infof("Transaction in block with hash &+v", blockHash, "and index", index, "contains", block.Transactions[index])
@@ -0,0 +1,234 @@
+package blockchain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ninjadotorg/constant/common/codec"
+)
+
+/*
+Chain file - portable dump of beacon and shard blocks
+The file starts with chainFileMagic and the format version, followed by one
+record per block: kind (1 byte), payload length (4 bytes big endian) and the
+block encoded with common/codec.
+Shard blocks accepted by a beacon block are written before it, so that
+replaying the records in order always finds what a block depends on.
+*/
+const (
+	chainFileMagic   = "CSTCHAIN"
+	chainFileVersion = uint32(2)
+
+	chainFileBeaconBlock = byte(0)
+	chainFileShardBlock  = byte(1)
+
+	// maxChainFileRecord bounds the memory allocated for a record read from a file
+	maxChainFileRecord = 32 * 1024 * 1024
+)
+
+/*
+ExportChain - write beacon blocks from fromHeight to toHeight (included) and
+every shard block they accept to w.
+toHeight = 0 exports up to the best beacon block.
+Returns the number of beacon and shard blocks written.
+*/
+func (self *BlockChain) ExportChain(w io.Writer, fromHeight uint64, toHeight uint64) (int, int, error) {
+	if toHeight == 0 {
+		toHeight = self.BestState.Beacon.BeaconHeight
+	}
+	if fromHeight == 0 || fromHeight > toHeight || toHeight > self.BestState.Beacon.BeaconHeight {
+		return 0, 0, NewBlockChainError(ChainFileError, fmt.Errorf("invalid beacon height range %d-%d, best height is %d", fromHeight, toHeight, self.BestState.Beacon.BeaconHeight))
+	}
+
+	writer := bufio.NewWriter(w)
+	header := make([]byte, len(chainFileMagic)+4)
+	copy(header, chainFileMagic)
+	binary.BigEndian.PutUint32(header[len(chainFileMagic):], chainFileVersion)
+	if _, err := writer.Write(header); err != nil {
+		return 0, 0, NewBlockChainError(ChainFileError, err)
+	}
+
+	beaconCount, shardCount := 0, 0
+	for height := fromHeight; height <= toHeight; height++ {
+		if self.interruptRequested() {
+			return beaconCount, shardCount, NewBlockChainError(ChainFileError, errors.New("export interrupted"))
+		}
+		beaconBlock, err := self.GetBeaconBlockByHeight(height)
+		if err != nil {
+			return beaconCount, shardCount, err
+		}
+
+		shardIDs := []int{}
+		for shardID := range beaconBlock.Body.ShardState {
+			shardIDs = append(shardIDs, int(shardID))
+		}
+		sort.Ints(shardIDs)
+		for _, shardID := range shardIDs {
+			for _, shardState := range beaconBlock.Body.ShardState[byte(shardID)] {
+				shardBlock, err := self.GetShardBlockByHash(&shardState.Hash)
+				if err != nil {
+					return beaconCount, shardCount, NewBlockChainError(ChainFileError, fmt.Errorf("shard %d block %d accepted by beacon block %d: %+v", shardID, shardState.Height, height, err))
+				}
+				if err := writeChainFileRecord(writer, chainFileShardBlock, shardBlock); err != nil {
+					return beaconCount, shardCount, err
+				}
+				shardCount++
+			}
+		}
+
+		if err := writeChainFileRecord(writer, chainFileBeaconBlock, beaconBlock); err != nil {
+			return beaconCount, shardCount, err
+		}
+		beaconCount++
+	}
+	if err := writer.Flush(); err != nil {
+		return beaconCount, shardCount, NewBlockChainError(ChainFileError, err)
+	}
+	return beaconCount, shardCount, nil
+}
+
+/*
+ImportChain - replay a file written by ExportChain through InsertBeaconBlock
+and InsertShardBlock, so every block goes through the normal validation.
+Blocks already in the database are skipped, a file can be imported again or
+overlap with the local chain.
+Returns the number of beacon and shard blocks inserted.
+*/
+func (self *BlockChain) ImportChain(r io.Reader) (int, int, error) {
+	reader := bufio.NewReader(r)
+	header := make([]byte, len(chainFileMagic)+4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, 0, NewBlockChainError(ChainFileError, err)
+	}
+	if string(header[:len(chainFileMagic)]) != chainFileMagic {
+		return 0, 0, NewBlockChainError(ChainFileError, errors.New("not a chain file"))
+	}
+	if version := binary.BigEndian.Uint32(header[len(chainFileMagic):]); version != chainFileVersion {
+		return 0, 0, NewBlockChainError(ChainFileError, fmt.Errorf("unsupported chain file version %d", version))
+	}
+
+	beaconCount, shardCount := 0, 0
+	for {
+		if self.interruptRequested() {
+			return beaconCount, shardCount, NewBlockChainError(ChainFileError, errors.New("import interrupted"))
+		}
+		kind, payload, err := readChainFileRecord(reader)
+		if err == io.EOF {
+			return beaconCount, shardCount, nil
+		}
+		if err != nil {
+			return beaconCount, shardCount, err
+		}
+
+		switch kind {
+		case chainFileShardBlock:
+			block := &ShardBlock{}
+			if err := codec.Unmarshal(payload, block); err != nil {
+				return beaconCount, shardCount, NewBlockChainError(BlockCodecError, err)
+			}
+			inserted, err := self.importShardBlock(block)
+			if err != nil {
+				return beaconCount, shardCount, err
+			}
+			if inserted {
+				shardCount++
+			}
+		case chainFileBeaconBlock:
+			block := &BeaconBlock{}
+			if err := codec.Unmarshal(payload, block); err != nil {
+				return beaconCount, shardCount, NewBlockChainError(BlockCodecError, err)
+			}
+			inserted, err := self.importBeaconBlock(block)
+			if err != nil {
+				return beaconCount, shardCount, err
+			}
+			if inserted {
+				beaconCount++
+			}
+		default:
+			return beaconCount, shardCount, NewBlockChainError(ChainFileError, fmt.Errorf("unknown record kind %d", kind))
+		}
+	}
+}
+
+// importShardBlock inserts block if needed and hands it to the pools, as the
+// beacon block accepting it is verified against the shard to beacon pool
+func (self *BlockChain) importShardBlock(block *ShardBlock) (bool, error) {
+	shardID := block.Header.ShardID
+	exists, err := self.config.DataBase.HasBlock(block.Hash())
+	if err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	if !exists {
+		if err := self.InsertShardBlock(block); err != nil {
+			return false, err
+		}
+		for _, crossShardBlock := range block.CreateAllCrossShardBlock() {
+			if err := self.config.CrossShardPool.AddCrossShardBlock(*crossShardBlock); err != nil {
+				return false, NewBlockChainError(CrossShardBlockError, err)
+			}
+		}
+	}
+	if block.Header.Height > self.BestState.Beacon.BestShardHeight[shardID] {
+		shardToBeaconBlock := block.CreateShardToBeaconBlock()
+		if err := self.config.ShardToBeaconPool.AddShardBeaconBlock(*shardToBeaconBlock, self.BestState.Beacon.ShardCommittee[shardID]); err != nil {
+			return false, NewBlockChainError(ShardStateError, err)
+		}
+	}
+	return !exists, nil
+}
+
+func (self *BlockChain) importBeaconBlock(block *BeaconBlock) (bool, error) {
+	exists, err := self.config.DataBase.HasBeaconBlock(block.Hash())
+	if err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	if exists {
+		return false, nil
+	}
+	if err := self.InsertBeaconBlock(block); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeChainFileRecord(w io.Writer, kind byte, block interface{}) error {
+	payload, err := codec.Marshal(block)
+	if err != nil {
+		return NewBlockChainError(BlockCodecError, err)
+	}
+	header := make([]byte, 5)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return NewBlockChainError(ChainFileError, err)
+	}
+	if _, err := w.Write(payload); err != nil {
+		return NewBlockChainError(ChainFileError, err)
+	}
+	return nil
+}
+
+// readChainFileRecord returns io.EOF at the end of the file
+func readChainFileRecord(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF {
+			return 0, nil, err
+		}
+		return 0, nil, NewBlockChainError(ChainFileError, err)
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxChainFileRecord {
+		return 0, nil, NewBlockChainError(ChainFileError, fmt.Errorf("record of %d bytes is too large", length))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, NewBlockChainError(ChainFileError, err)
+	}
+	return header[0], payload, nil
+}
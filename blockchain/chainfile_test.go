@@ -0,0 +1,222 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ninjadotorg/constant/cashec"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/database"
+	_ "github.com/ninjadotorg/constant/database/lvdb"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/transaction"
+	"github.com/ninjadotorg/constant/wallet"
+)
+
+func init() {
+	Logger.Init(common.NewBackend(ioutil.Discard).Logger("test"))
+	transaction.Logger.Init(common.NewBackend(ioutil.Discard).Logger("test"))
+}
+
+type testShardToBeaconPool struct{}
+
+func (testShardToBeaconPool) RemovePendingBlock(map[byte]uint64) error               { return nil }
+func (testShardToBeaconPool) GetFinalBlock() map[byte][]ShardToBeaconBlock           { return nil }
+func (testShardToBeaconPool) AddShardBeaconBlock(ShardToBeaconBlock, []string) error { return nil }
+func (testShardToBeaconPool) ValidateShardToBeaconBlock(ShardToBeaconBlock) error    { return nil }
+func (testShardToBeaconPool) GetPendingBlockHashes() map[byte][]common.Hash          { return nil }
+func (testShardToBeaconPool) SetDatabase(database.DatabaseInterface)                 {}
+
+type testNodeBeaconPool struct{}
+
+func (testNodeBeaconPool) PushBlock(BeaconBlock) error             { return nil }
+func (testNodeBeaconPool) GetBlocks(uint64) ([]BeaconBlock, error) { return nil, nil }
+func (testNodeBeaconPool) RemoveBlocks(uint64) error               { return nil }
+func (testNodeBeaconPool) RemoveBlocksBelow(uint64)                {}
+
+// newTestChain returns a chain at its genesis blocks on a memdb database
+func newTestChain(t *testing.T) *BlockChain {
+	db, err := database.Open("memdb")
+	if err != nil {
+		t.Fatalf("database.Open memdb %+v", err)
+	}
+	chain := &BlockChain{}
+	err = chain.Init(&Config{
+		ChainParams:       &ChainTestParam,
+		DataBase:          db,
+		ShardToBeaconPool: testShardToBeaconPool{},
+		NodeBeaconPool:    testNodeBeaconPool{},
+	})
+	if err != nil {
+		t.Fatalf("chain.Init %+v", err)
+	}
+	return chain
+}
+
+// insertTestBeaconBlock produces and inserts the next beacon block
+func insertTestBeaconBlock(t *testing.T, chain *BlockChain) *BeaconBlock {
+	block := newTestBeaconBlock(t, chain)
+	if err := chain.InsertBeaconBlock(block); err != nil {
+		t.Fatalf("InsertBeaconBlock %d %+v", block.Header.Height, err)
+	}
+	return block
+}
+
+// newTestBeaconBlock produces the next beacon block, signed by the committee
+// member whose turn it is
+func newTestBeaconBlock(t *testing.T, chain *BlockChain) *BeaconBlock {
+	bestState := chain.BestState.Beacon
+	producer := bestState.BeaconCommittee[(bestState.BeaconProposerIdx+1)%len(bestState.BeaconCommittee)]
+	var keySet *cashec.KeySet
+	for _, privateKey := range preSelectBeaconNodeTestnet {
+		keyWallet, err := wallet.Base58CheckDeserialize(privateKey)
+		if err != nil {
+			t.Fatalf("wallet.Base58CheckDeserialize %+v", err)
+		}
+		keyWallet.KeySet.ImportFromPrivateKey(&keyWallet.KeySet.PrivateKey)
+		if keyWallet.KeySet.GetPublicKeyB58() == producer {
+			keySet = &keyWallet.KeySet
+		}
+	}
+	if keySet == nil {
+		t.Fatalf("no test key for producer %s", producer)
+	}
+	generator := &BlkTmplGenerator{
+		chain:             chain,
+		shardToBeaconPool: chain.config.ShardToBeaconPool,
+	}
+	block, err := generator.NewBlockBeacon(&keySet.PaymentAddress, &keySet.PrivateKey)
+	if err != nil {
+		t.Fatalf("NewBlockBeacon %+v", err)
+	}
+	// blocks produced within a second must still have increasing timestamps
+	parent, err := chain.GetBeaconBlockByHeight(bestState.BeaconHeight)
+	if err != nil {
+		t.Fatalf("GetBeaconBlockByHeight %+v", err)
+	}
+	if block.Header.Timestamp <= parent.Header.Timestamp {
+		block.Header.Timestamp = parent.Header.Timestamp + 1
+		blockHash := block.Header.Hash()
+		if block.ProducerSig, err = keySet.SignDataB58(blockHash.GetBytes()); err != nil {
+			t.Fatalf("SignDataB58 %+v", err)
+		}
+	}
+	block.ValidatorsIdx = make([][]int, len(bestState.BeaconCommittee))
+	return block
+}
+
+func TestExportImportChain(t *testing.T) {
+	source := newTestChain(t)
+	for i := 0; i < 3; i++ {
+		insertTestBeaconBlock(t, source)
+	}
+	if source.BestState.Beacon.BeaconHeight != 4 {
+		t.Fatalf("source chain should be at height 4, got %d", source.BestState.Beacon.BeaconHeight)
+	}
+
+	var file bytes.Buffer
+	beaconCount, shardCount, err := source.ExportChain(&file, 1, 0)
+	if err != nil {
+		t.Fatalf("ExportChain %+v", err)
+	}
+	if beaconCount != 4 || shardCount != 0 {
+		t.Fatalf("ExportChain should write 4 beacon and 0 shard blocks, got %d %d", beaconCount, shardCount)
+	}
+
+	target := newTestChain(t)
+	beaconCount, shardCount, err = target.ImportChain(bytes.NewReader(file.Bytes()))
+	if err != nil {
+		t.Fatalf("ImportChain %+v", err)
+	}
+	// genesis block is already in the target database
+	if beaconCount != 3 || shardCount != 0 {
+		t.Fatalf("ImportChain should insert 3 beacon and 0 shard blocks, got %d %d", beaconCount, shardCount)
+	}
+	// best blocks are compared by hash, the codec does not keep empty and
+	// nil body fields apart
+	sourceBeacon, targetBeacon := *source.BestState.Beacon, *target.BestState.Beacon
+	sourceBeacon.BestBlock, targetBeacon.BestBlock = nil, nil
+	sourceState, _ := json.Marshal(sourceBeacon)
+	targetState, _ := json.Marshal(targetBeacon)
+	if !bytes.Equal(sourceState, targetState) {
+		t.Fatalf("best states differ after import\nsource %s\ntarget %s", sourceState, targetState)
+	}
+	for shardID, bestState := range source.BestState.Shard {
+		if target.BestState.Shard[shardID].BestShardBlockHash != bestState.BestShardBlockHash {
+			t.Fatalf("shard %d best block differs after import", shardID)
+		}
+	}
+
+	// importing the same file again inserts nothing
+	beaconCount, shardCount, err = target.ImportChain(bytes.NewReader(file.Bytes()))
+	if err != nil || beaconCount != 0 || shardCount != 0 {
+		t.Fatalf("second ImportChain should insert nothing, got %d %d %+v", beaconCount, shardCount, err)
+	}
+}
+
+func TestImportChainRejectsBadFile(t *testing.T) {
+	chain := newTestChain(t)
+	if _, _, err := chain.ImportChain(bytes.NewReader([]byte("NOTCHAIN\x00\x00\x00\x02"))); err == nil {
+		t.Fatalf("ImportChain should reject a file without chain file magic")
+	}
+	header := []byte(chainFileMagic + "\x00\x00\x00\x01")
+	if _, _, err := chain.ImportChain(bytes.NewReader(header)); err == nil {
+		t.Fatalf("ImportChain should reject an unsupported version")
+	}
+}
+
+// newTestShardBlock builds the next block of a shard on its best block with
+// txs, its header passes VerifyPreProcessingShardBlock
+func newTestShardBlock(t *testing.T, chain *BlockChain, shardID byte, txs []metadata.Transaction) *ShardBlock {
+	parent, err := chain.GetShardBlockByHash(&chain.BestState.Shard[shardID].BestShardBlockHash)
+	if err != nil {
+		t.Fatalf("GetShardBlockByHash %+v", err)
+	}
+	crossOutputCoinRoot, err := CreateMerkleCrossOutputCoin(nil)
+	if err != nil {
+		t.Fatalf("CreateMerkleCrossOutputCoin %+v", err)
+	}
+	block := &ShardBlock{
+		Header: ShardHeader{
+			ShardID:             shardID,
+			Version:             VERSION,
+			Height:              parent.Header.Height + 1,
+			Timestamp:           parent.Header.Timestamp + 1,
+			PrevBlockHash:       chain.BestState.Shard[shardID].BestShardBlockHash,
+			CrossOutputCoinRoot: *crossOutputCoinRoot,
+			BeaconHeight:        chain.BestState.Beacon.BeaconHeight,
+			BeaconHash:          chain.BestState.Beacon.BestBlockHash,
+		},
+		Body: ShardBody{
+			Transactions: txs,
+		},
+	}
+	txMerkle := Merkle{}.BuildMerkleTreeStore(block.Body.Transactions)
+	block.Header.TxRoot = *txMerkle[len(txMerkle)-1]
+	block.Header.ShardTxRoot = *block.Body.CalcMerkleRootShard()
+	block.Header.CrossShards = CreateCrossShardByteArray(block.Body.Transactions)
+	return block
+}
+
+// newTestTx returns a salary tx which also spends an input coin, so that a
+// block with it stores a serial number, a commitment, a snderivator and an
+// output coin, signature and proof are not valid
+func newTestTx(t *testing.T, chain *BlockChain) metadata.Transaction {
+	keyWallet, err := wallet.Base58CheckDeserialize(preSelectBeaconNodeTestnet[0])
+	if err != nil {
+		t.Fatalf("wallet.Base58CheckDeserialize %+v", err)
+	}
+	keyWallet.KeySet.ImportFromPrivateKey(&keyWallet.KeySet.PrivateKey)
+	tx := &transaction.Tx{}
+	if err := tx.InitTxSalary(100, &keyWallet.KeySet.PaymentAddress, &keyWallet.KeySet.PrivateKey, chain.config.DataBase, nil); err != nil {
+		t.Fatalf("InitTxSalary %+v", err)
+	}
+	inputCoin := new(privacy.InputCoin).Init()
+	inputCoin.CoinDetails.SerialNumber = new(privacy.EllipticPoint)
+	inputCoin.CoinDetails.SerialNumber.Randomize()
+	tx.Proof.InputCoins = []*privacy.InputCoin{inputCoin}
+	return tx
+}
@@ -0,0 +1,36 @@
+package blockchain
+
+import (
+	"encoding/json"
+
+	"github.com/ninjadotorg/constant/database"
+)
+
+func init() {
+	// blocks were stored in json before schema version 2 of lvdb, its
+	// migration decodes them with decodeJSONBlock
+	database.SetJSONBlockDecoder(decodeJSONBlock)
+}
+
+// decodeJSONBlock decodes a block written before the binary codec, light
+// mode nodes stored only the header of shard blocks
+func decodeJSONBlock(data []byte, isBeacon bool) (interface{}, error) {
+	if isBeacon {
+		block := &BeaconBlock{}
+		if err := json.Unmarshal(data, block); err != nil {
+			return nil, err
+		}
+		return block, nil
+	}
+	block := &ShardBlock{}
+	if err := json.Unmarshal(data, block); err != nil {
+		return nil, err
+	}
+	if block.Header.Height == 0 {
+		header := ShardHeader{}
+		if err := json.Unmarshal(data, &header); err == nil && header.Height != 0 {
+			block = &ShardBlock{Header: header}
+		}
+	}
+	return block, nil
+}
@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
+)
+
+// a chain larger than one migration chunk, part of it already re-encoded by
+// an interrupted run
+func TestMigrateBlocksToCodec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "codec-migration")
+	if err != nil {
+		t.Fatalf("ioutil.TempDir %+v", err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := database.Open("leveldb", dir)
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	const blocks, encodedBlocks = 2500, 300
+	keys := make([][]byte, 0, blocks)
+	for height := 1; height <= blocks; height++ {
+		block := &ShardBlock{Header: ShardHeader{Height: uint64(height)}}
+		value, err := json.Marshal(block)
+		if height <= encodedBlocks {
+			value, err = codec.Marshal(block)
+		}
+		if err != nil {
+			t.Fatalf("Marshal %+v", err)
+		}
+		hash := common.HashH(value)
+		key := append([]byte("b-"), hash[:]...)
+		if err := db.Put(key, value); err != nil {
+			t.Fatalf("Put %+v", err)
+		}
+		keys = append(keys, key)
+	}
+	// blocks were stored in json before schema version 2
+	if err := db.Put([]byte("schema-version"), []byte{0, 0, 0, 1}); err != nil {
+		t.Fatalf("Put %+v", err)
+	}
+	db.Close()
+
+	var results []database.MigrationResult
+	progress := func(result database.MigrationResult) {
+		results = append(results, result)
+	}
+	db, err = database.Open("leveldb", dir, &database.MigrationOptions{DryRun: true, Progress: progress})
+	if err != nil {
+		t.Fatalf("database.Open dry run %+v", err)
+	}
+	if len(results) != 1 || results[0].Changes != blocks-encodedBlocks {
+		t.Fatalf("dry run should report %d blocks to encode, got %+v", blocks-encodedBlocks, results)
+	}
+	for _, key := range keys[encodedBlocks:] {
+		if value, err := db.Get(key); err != nil || codec.IsEncoded(value) {
+			t.Fatalf("dry run should not change blocks, %+v", err)
+		}
+	}
+	db.Close()
+
+	results = nil
+	db, err = database.Open("leveldb", dir, &database.MigrationOptions{Progress: progress})
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	defer db.Close()
+	if len(results) != 1 || results[0].Changes != blocks-encodedBlocks {
+		t.Fatalf("migration should encode %d blocks, got %+v", blocks-encodedBlocks, results)
+	}
+	if version, _ := database.SchemaVersion(db); version != 2 {
+		t.Fatalf("database should be migrated to version 2, got %d", version)
+	}
+	for i, key := range keys {
+		value, err := db.Get(key)
+		if err != nil {
+			t.Fatalf("Get %+v", err)
+		}
+		block := &ShardBlock{}
+		if !codec.IsEncoded(value) || codec.Unmarshal(value, block) != nil || block.Header.Height != uint64(i+1) {
+			t.Fatalf("block %d is not encoded", i+1)
+		}
+	}
+}
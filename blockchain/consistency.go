@@ -0,0 +1,511 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
+	"github.com/ninjadotorg/constant/database/lvdb"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+// categories of data checked by CheckConsistency
+const (
+	BlocksCategory          = "blocks"
+	BlockIndexCategory      = "block index"
+	TxIndexCategory         = "tx index"
+	SerialNumbersCategory   = "serial numbers"
+	CommitmentsCategory     = "commitments"
+	SNDerivatorsCategory    = "snderivators"
+	CustomTokenUTXOCategory = "custom token utxo"
+)
+
+// ConsistencyCategoryReport is the result of the checks of one category of data
+type ConsistencyCategoryReport struct {
+	Name     string
+	Checked  int
+	Errors   []string
+	Repaired int
+	// Skipped tells why (part of) the category could not be checked
+	Skipped []string
+}
+
+// ConsistencyReport is returned by CheckConsistency, one entry per category
+type ConsistencyReport struct {
+	Categories []*ConsistencyCategoryReport
+}
+
+func newConsistencyReport() *ConsistencyReport {
+	report := &ConsistencyReport{}
+	for _, name := range []string{BlocksCategory, BlockIndexCategory, TxIndexCategory, SerialNumbersCategory, CommitmentsCategory, SNDerivatorsCategory, CustomTokenUTXOCategory} {
+		report.Categories = append(report.Categories, &ConsistencyCategoryReport{Name: name})
+	}
+	return report
+}
+
+func (self *ConsistencyReport) Category(name string) *ConsistencyCategoryReport {
+	for _, category := range self.Categories {
+		if category.Name == name {
+			return category
+		}
+	}
+	return nil
+}
+
+// IsConsistent returns true when no category has errors left to fix
+func (self *ConsistencyReport) IsConsistent() bool {
+	for _, category := range self.Categories {
+		if len(category.Errors) > category.Repaired {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *ConsistencyReport) String() string {
+	var result strings.Builder
+	for _, category := range self.Categories {
+		fmt.Fprintf(&result, "%s: %d checked, %d errors, %d repaired\n", category.Name, category.Checked, len(category.Errors), category.Repaired)
+		for _, err := range category.Errors {
+			fmt.Fprintf(&result, "\terror: %s\n", err)
+		}
+		for _, reason := range category.Skipped {
+			fmt.Fprintf(&result, "\tskipped: %s\n", reason)
+		}
+	}
+	return result.String()
+}
+
+func (self *ConsistencyCategoryReport) errorf(format string, args ...interface{}) {
+	self.Errors = append(self.Errors, fmt.Sprintf(format, args...))
+}
+
+/*
+CheckConsistency - walk the beacon chain and every shard chain from their best
+block down to genesis and check the store against what replaying the blocks
+produces: block index, tx index, serial numbers, commitments and their indexes,
+snderivators and custom token utxo.
+Blocks are replayed into an in-memory database with the same code used to
+store them, so expected data is computed and not read back from the store.
+With repair, damaged block and tx index entries are rewritten from the blocks,
+other damage is only reported. A block which can not be replayed is reported
+and the rest of its shard is not replayed.
+*/
+func (self *BlockChain) CheckConsistency(repair bool) (*ConsistencyReport, error) {
+	self.chainLock.Lock()
+	defer self.chainLock.Unlock()
+
+	report := newConsistencyReport()
+	replayDB, err := database.Open("memdb")
+	if err != nil {
+		return nil, NewBlockChainError(DBError, err)
+	}
+	defer replayDB.Close()
+	// replaying blocks must not touch the reward snapshot of the live chain
+	rewardSnapshot := self.config.customTokenRewardSnapshot
+	defer func() {
+		self.config.customTokenRewardSnapshot = rewardSnapshot
+	}()
+
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
+
+	beaconHashes := self.checkBeaconChain(report, batch, repair)
+
+	// shards with pruned or missing blocks can not be replayed
+	shardHashes := make(map[byte][]common.Hash)
+	prunedShards := make(map[byte]bool)
+	for shardID := range self.BestState.Shard {
+		hashes, pruned := self.checkShardChain(report, batch, repair, shardID)
+		if pruned {
+			prunedShards[shardID] = true
+		} else {
+			shardHashes[shardID] = hashes
+		}
+	}
+
+	unreplayedShards := make(map[byte]string)
+	tokenIDs := make(map[byte]map[common.Hash]bool)
+	customTokens := make(map[common.Hash]map[string][]byte)
+	for _, hash := range self.shardReplayOrder(beaconHashes, shardHashes) {
+		block, err := self.fetchShardBlockForCheck(&hash)
+		if err != nil {
+			return nil, err
+		}
+		shardID := block.Header.ShardID
+		// once a block fails, the rest of its shard is not replayed as the
+		// expected data would be wrong, tx indexes are still checked
+		_, replayFailed := unreplayedShards[shardID]
+		if !replayFailed {
+			if err := self.CreateAndSaveTxViewPointFromBlock(replayDB, block); err != nil {
+				report.Category(BlocksCategory).errorf("shard %d block %d %s can not be replayed: %+v", shardID, block.Header.Height, hash.String(), err)
+				unreplayedShards[shardID] = fmt.Sprintf("shard %d block %d can not be replayed", shardID, block.Header.Height)
+				replayFailed = true
+			}
+		}
+		if tokenIDs[shardID] == nil {
+			tokenIDs[shardID] = map[common.Hash]bool{common.ConstantID: true}
+		}
+		for index, tx := range block.Body.Transactions {
+			self.checkTxIndex(report, batch, repair, block, index)
+			if replayFailed {
+				continue
+			}
+			switch tx.GetType() {
+			case common.TxCustomTokenPrivacyType:
+				tokenIDs[shardID][tx.(*transaction.TxCustomTokenPrivacy).TxTokenPrivacyData.PropertyID] = true
+			case common.TxCustomTokenType:
+				tokenData := tx.(*transaction.TxCustomToken).TxTokenData
+				if customTokens[tokenData.PropertyID] == nil {
+					customTokens[tokenData.PropertyID] = make(map[string][]byte)
+				}
+				for _, vout := range tokenData.Vouts {
+					customTokens[tokenData.PropertyID][string(vout.PaymentAddress.Pk)] = vout.PaymentAddress.Pk
+				}
+			}
+		}
+	}
+
+	shardIDs := []int{}
+	for shardID := range self.BestState.Shard {
+		shardIDs = append(shardIDs, int(shardID))
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		shardID := byte(id)
+		if prunedShards[shardID] {
+			for _, name := range []string{TxIndexCategory, SerialNumbersCategory, CommitmentsCategory, SNDerivatorsCategory} {
+				report.Category(name).Skipped = append(report.Category(name).Skipped, fmt.Sprintf("shard %d has pruned or missing blocks", shardID))
+			}
+			continue
+		}
+		if reason, failed := unreplayedShards[shardID]; failed {
+			for _, name := range []string{SerialNumbersCategory, CommitmentsCategory, SNDerivatorsCategory} {
+				report.Category(name).Skipped = append(report.Category(name).Skipped, reason)
+			}
+			continue
+		}
+		for tokenID := range tokenIDs[shardID] {
+			tokenID := tokenID
+			if err := self.checkSerialNumbers(report, replayDB, &tokenID, shardID); err != nil {
+				return nil, err
+			}
+			if err := self.checkCommitments(report, replayDB, &tokenID, shardID); err != nil {
+				return nil, err
+			}
+			if err := self.checkSNDerivators(report, replayDB, &tokenID, shardID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(prunedShards) > 0 || len(unreplayedShards) > 0 {
+		// custom token utxo are spent across shards
+		report.Category(CustomTokenUTXOCategory).Skipped = append(report.Category(CustomTokenUTXOCategory).Skipped, "some shards have pruned, missing or invalid blocks")
+	} else {
+		for tokenID, pubkeys := range customTokens {
+			tokenID := tokenID
+			for _, pubkey := range pubkeys {
+				if err := self.checkCustomTokenUTXO(report, replayDB, &tokenID, pubkey); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if repair && batch.Len() > 0 {
+		if err := batch.Write(); err != nil {
+			return nil, NewBlockChainError(DBError, err)
+		}
+		// txs were verified against the damaged store
+		self.txVerifyCache.reset()
+	}
+	return report, nil
+}
+
+// checkBeaconChain returns the hashes of the beacon chain from the lowest block found
+func (self *BlockChain) checkBeaconChain(report *ConsistencyReport, batch database.Batch, repair bool) []common.Hash {
+	blocks := report.Category(BlocksCategory)
+	index := report.Category(BlockIndexCategory)
+
+	bestHeight := self.BestState.Beacon.BeaconHeight
+	hashes := make([]common.Hash, bestHeight+1)
+	hash := self.BestState.Beacon.BestBlockHash
+	for height := bestHeight; height >= 1; height-- {
+		blocks.Checked++
+		blockBytes, err := self.config.DataBase.FetchBeaconBlock(&hash)
+		if err != nil {
+			blocks.errorf("beacon block %d %s: %+v", height, hash.String(), err)
+			return hashes[height+1:]
+		}
+		block := BeaconBlock{}
+		if err := codec.Unmarshal(blockBytes, &block); err != nil {
+			blocks.errorf("beacon block %d %s: %+v", height, hash.String(), err)
+			return hashes[height+1:]
+		}
+		if block.Header.Height != height {
+			blocks.errorf("beacon block %s has height %d, expected %d", hash.String(), block.Header.Height, height)
+		}
+		hashes[height] = hash
+
+		index.Checked++
+		indexHash, err := self.config.DataBase.GetBeaconBlockHashByIndex(height)
+		indexHeight, err2 := self.config.DataBase.GetIndexOfBeaconBlock(&hash)
+		if err != nil || err2 != nil || !indexHash.IsEqual(&hash) || indexHeight != height {
+			index.errorf("beacon block %d %s is not indexed", height, hash.String())
+			if repair {
+				if err := batch.StoreBeaconBlockIndex(&hash, height); err == nil {
+					index.Repaired++
+				}
+			}
+		}
+		hash = block.Header.PrevBlockHash
+	}
+	return hashes[1:]
+}
+
+// checkShardChain returns the hashes of the shard chain indexed by height - 1 and
+// whether some of its blocks are pruned or missing
+func (self *BlockChain) checkShardChain(report *ConsistencyReport, batch database.Batch, repair bool, shardID byte) ([]common.Hash, bool) {
+	blocks := report.Category(BlocksCategory)
+	index := report.Category(BlockIndexCategory)
+
+	bestHeight := self.BestState.Shard[shardID].ShardHeight
+	hashes := make([]common.Hash, bestHeight+1)
+	hash := self.BestState.Shard[shardID].BestShardBlockHash
+	pruned := false
+	for height := bestHeight; height >= 1; height-- {
+		blocks.Checked++
+		block, err := self.fetchShardBlockForCheck(&hash)
+		if err != nil {
+			blocks.errorf("shard %d block %d %s: %+v", shardID, height, hash.String(), err)
+			return hashes[height+1:], true
+		}
+		if block.Header.Height != height || block.Header.ShardID != shardID {
+			blocks.errorf("shard %d block %s has shard %d height %d, expected height %d", shardID, hash.String(), block.Header.ShardID, block.Header.Height, height)
+		}
+		if isPruned, err := self.config.DataBase.IsBlockPruned(&hash); err != nil || isPruned {
+			pruned = true
+		}
+		hashes[height] = hash
+
+		index.Checked++
+		indexHash, err := self.config.DataBase.GetBlockByIndex(height, shardID)
+		indexHeight, indexShardID, err2 := self.config.DataBase.GetIndexOfBlock(&hash)
+		if err != nil || err2 != nil || !indexHash.IsEqual(&hash) || indexHeight != height || indexShardID != shardID {
+			index.errorf("shard %d block %d %s is not indexed", shardID, height, hash.String())
+			if repair {
+				if err := batch.StoreShardBlockIndex(&hash, height, shardID); err == nil {
+					index.Repaired++
+				}
+			}
+		}
+		hash = block.Header.PrevBlockHash
+	}
+	return hashes[1:], pruned
+}
+
+func (self *BlockChain) fetchShardBlockForCheck(hash *common.Hash) (*ShardBlock, error) {
+	blockBytes, err := self.config.DataBase.FetchBlock(hash)
+	if err != nil {
+		return nil, NewBlockChainError(DBError, err)
+	}
+	block := ShardBlock{}
+	if err := codec.Unmarshal(blockBytes, &block); err != nil {
+		return nil, NewBlockChainError(BlockCodecError, err)
+	}
+	return &block, nil
+}
+
+/*
+shardReplayOrder - shard block hashes in the order they are accepted by the
+beacon chain, blocks not accepted yet come last. Custom token utxo can be
+spent in another shard, replaying shard by shard could spend one before it
+is created.
+*/
+func (self *BlockChain) shardReplayOrder(beaconHashes []common.Hash, shardHashes map[byte][]common.Hash) []common.Hash {
+	order := []common.Hash{}
+	next := make(map[byte]int)
+	for _, beaconHash := range beaconHashes {
+		blockBytes, err := self.config.DataBase.FetchBeaconBlock(&beaconHash)
+		if err != nil {
+			continue
+		}
+		block := BeaconBlock{}
+		if err := codec.Unmarshal(blockBytes, &block); err != nil {
+			continue
+		}
+		shardIDs := []int{}
+		for shardID := range block.Body.ShardState {
+			shardIDs = append(shardIDs, int(shardID))
+		}
+		sort.Ints(shardIDs)
+		for _, id := range shardIDs {
+			shardID := byte(id)
+			shardStates := block.Body.ShardState[shardID]
+			if len(shardStates) == 0 {
+				continue
+			}
+			to := shardStates[len(shardStates)-1].Height
+			for next[shardID] < len(shardHashes[shardID]) && uint64(next[shardID]+1) <= to {
+				order = append(order, shardHashes[shardID][next[shardID]])
+				next[shardID]++
+			}
+		}
+	}
+	shardIDs := []int{}
+	for shardID := range shardHashes {
+		shardIDs = append(shardIDs, int(shardID))
+	}
+	sort.Ints(shardIDs)
+	for _, id := range shardIDs {
+		shardID := byte(id)
+		order = append(order, shardHashes[shardID][next[shardID]:]...)
+	}
+	return order
+}
+
+func (self *BlockChain) checkTxIndex(report *ConsistencyReport, batch database.Batch, repair bool, block *ShardBlock, index int) {
+	category := report.Category(TxIndexCategory)
+	category.Checked++
+	txHash := block.Body.Transactions[index].Hash()
+	blockHash, indexInBlock, dbErr := self.config.DataBase.GetTransactionIndexById(txHash)
+	if dbErr == nil && blockHash.IsEqual(block.Hash()) && indexInBlock == index {
+		return
+	}
+	category.errorf("tx %s of shard %d block %d is not indexed", txHash.String(), block.Header.ShardID, block.Header.Height)
+	if repair {
+		if err := batch.StoreTransactionIndex(txHash, block.Hash(), index); err == nil {
+			category.Repaired++
+		}
+	}
+}
+
+func (self *BlockChain) checkSerialNumbers(report *ConsistencyReport, replayDB database.DatabaseInterface, tokenID *common.Hash, shardID byte) error {
+	category := report.Category(SerialNumbersCategory)
+	expected, err := replayDB.FetchSerialNumbers(tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	stored, err := self.config.DataBase.FetchSerialNumbers(tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	for _, serialNumber := range expected {
+		category.Checked++
+		if ok, err := self.config.DataBase.HasSerialNumber(tokenID, serialNumber, shardID); err != nil || !ok {
+			category.errorf("serial number %x of token %s is missing in shard %d", serialNumber, tokenID.String(), shardID)
+		}
+	}
+	if len(stored) != len(expected) {
+		category.errorf("shard %d has %d serial numbers of token %s, expected %d", shardID, len(stored), tokenID.String(), len(expected))
+	}
+	return nil
+}
+
+func (self *BlockChain) checkCommitments(report *ConsistencyReport, replayDB database.DatabaseInterface, tokenID *common.Hash, shardID byte) error {
+	category := report.Category(CommitmentsCategory)
+	expectedLength, err := commitmentLength(replayDB, tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	storedLength, err := commitmentLength(self.config.DataBase, tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if storedLength != expectedLength {
+		category.errorf("shard %d has %d commitments of token %s, expected %d", shardID, storedLength, tokenID.String(), expectedLength)
+	}
+	for i := uint64(0); i < expectedLength; i++ {
+		category.Checked++
+		commitment, err := replayDB.GetCommitmentByIndex(tokenID, i, shardID)
+		if err != nil {
+			return NewBlockChainError(DBError, err)
+		}
+		stored, err := self.config.DataBase.GetCommitmentByIndex(tokenID, i, shardID)
+		if err != nil || !bytes.Equal(stored, commitment) {
+			category.errorf("commitment %d of token %s in shard %d is %x, expected %x", i, tokenID.String(), shardID, stored, commitment)
+			continue
+		}
+		index, err := self.config.DataBase.GetCommitmentIndex(tokenID, commitment, shardID)
+		if err != nil || index.Cmp(new(big.Int).SetUint64(i)) != 0 {
+			category.errorf("commitment %x of token %s in shard %d is not indexed at %d", commitment, tokenID.String(), shardID, i)
+		}
+	}
+	return nil
+}
+
+// commitmentLength returns 0 when no commitment of tokenID is stored, the
+// store reports any read error as not found
+func commitmentLength(db database.DatabaseInterface, tokenID *common.Hash, shardID byte) (uint64, error) {
+	if _, err := db.GetCommitmentByIndex(tokenID, 0, shardID); err != nil {
+		return 0, nil
+	}
+	length, err := db.GetCommitmentLength(tokenID, shardID)
+	if err != nil {
+		return 0, err
+	}
+	return length.Uint64(), nil
+}
+
+func (self *BlockChain) checkSNDerivators(report *ConsistencyReport, replayDB database.DatabaseInterface, tokenID *common.Hash, shardID byte) error {
+	category := report.Category(SNDerivatorsCategory)
+	expected, err := replayDB.FetchSNDerivator(tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	stored, err := self.config.DataBase.FetchSNDerivator(tokenID, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	for _, snd := range expected {
+		category.Checked++
+		if ok, err := self.config.DataBase.HasSNDerivator(tokenID, snd, shardID); err != nil || !ok {
+			category.errorf("snderivator %s of token %s is missing in shard %d", snd.String(), tokenID.String(), shardID)
+		}
+	}
+	if len(stored) != len(expected) {
+		category.errorf("shard %d has %d snderivators of token %s, expected %d", shardID, len(stored), tokenID.String(), len(expected))
+	}
+	return nil
+}
+
+/*
+checkCustomTokenUTXO - compare amount and spent state of every utxo of pubkey,
+the reward state is updated when dividends are paid, not by blocks.
+*/
+func (self *BlockChain) checkCustomTokenUTXO(report *ConsistencyReport, replayDB database.DatabaseInterface, tokenID *common.Hash, pubkey []byte) error {
+	category := report.Category(CustomTokenUTXOCategory)
+	expected, err := replayDB.GetCustomTokenPaymentAddressUTXO(tokenID, pubkey)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	stored, err := self.config.DataBase.GetCustomTokenPaymentAddressUTXO(tokenID, pubkey)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	splitter := string(lvdb.Splitter)
+	for key, value := range expected {
+		category.Checked++
+		storedValue, ok := stored[key]
+		if !ok {
+			category.errorf("utxo %x of token %s is missing", key, tokenID.String())
+			continue
+		}
+		expectedFields := strings.Split(value, splitter)
+		storedFields := strings.Split(storedValue, splitter)
+		if len(storedFields) < 2 || storedFields[0] != expectedFields[0] || storedFields[1] != expectedFields[1] {
+			category.errorf("utxo %x of token %s is %s, expected %s", key, tokenID.String(), storedValue, value)
+		}
+	}
+	for key := range stored {
+		if _, ok := expected[key]; !ok {
+			category.errorf("utxo %x of token %s is not created by any block", key, tokenID.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package blockchain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+func checkConsistency(t *testing.T, chain *BlockChain, repair bool) *ConsistencyReport {
+	report, err := chain.CheckConsistency(repair)
+	if err != nil {
+		t.Fatalf("CheckConsistency %+v", err)
+	}
+	return report
+}
+
+func TestCheckConsistency(t *testing.T) {
+	chain := newTestChain(t)
+	insertTestBeaconBlock(t, chain)
+	insertTestBeaconBlock(t, chain)
+
+	report := checkConsistency(t, chain, false)
+	if !report.IsConsistent() {
+		t.Fatalf("fresh chain should be consistent\n%s", report.String())
+	}
+	expected := int(chain.BestState.Beacon.BeaconHeight)
+	for _, bestState := range chain.BestState.Shard {
+		expected += int(bestState.ShardHeight)
+	}
+	if checked := report.Category(BlocksCategory).Checked; checked != expected {
+		t.Fatalf("every block should be checked, got %d expected %d", checked, expected)
+	}
+	if report.Category(TxIndexCategory).Checked == 0 {
+		t.Fatalf("txs of genesis shard blocks should be checked")
+	}
+}
+
+func TestCheckConsistencyRepair(t *testing.T) {
+	chain := newTestChain(t)
+	insertTestBeaconBlock(t, chain)
+	db := chain.config.DataBase
+
+	wrongHash := common.HashH([]byte("wrong"))
+	if err := db.StoreBeaconBlockIndex(&wrongHash, 2); err != nil {
+		t.Fatalf("StoreBeaconBlockIndex %+v", err)
+	}
+	shardBlock, err := chain.GetShardBlockByHash(&chain.BestState.Shard[0].BestShardBlockHash)
+	if err != nil {
+		t.Fatalf("GetShardBlockByHash %+v", err)
+	}
+	txHash := shardBlock.Body.Transactions[0].Hash()
+	if err := db.StoreTransactionIndex(txHash, &wrongHash, 7); err != nil {
+		t.Fatalf("StoreTransactionIndex %+v", err)
+	}
+
+	report := checkConsistency(t, chain, false)
+	if report.IsConsistent() {
+		t.Fatalf("damaged indexes should be reported")
+	}
+	if len(report.Category(BlockIndexCategory).Errors) != 1 || len(report.Category(TxIndexCategory).Errors) != 1 {
+		t.Fatalf("one block index and one tx index error expected\n%s", report.String())
+	}
+	if report.Category(BlockIndexCategory).Repaired != 0 {
+		t.Fatalf("check without repair should not repair")
+	}
+
+	report = checkConsistency(t, chain, true)
+	if !report.IsConsistent() || report.Category(BlockIndexCategory).Repaired != 1 || report.Category(TxIndexCategory).Repaired != 1 {
+		t.Fatalf("repair should fix both indexes\n%s", report.String())
+	}
+	report = checkConsistency(t, chain, false)
+	if !report.IsConsistent() || len(report.Category(BlockIndexCategory).Errors) != 0 || len(report.Category(TxIndexCategory).Errors) != 0 {
+		t.Fatalf("repaired chain should be consistent\n%s", report.String())
+	}
+}
+
+func TestCheckConsistencyCommitments(t *testing.T) {
+	chain := newTestChain(t)
+	if err := chain.config.DataBase.StoreCommitments(&common.ConstantID, []byte{1}, []byte{2}, 0); err != nil {
+		t.Fatalf("StoreCommitments %+v", err)
+	}
+	report := checkConsistency(t, chain, true)
+	if len(report.Category(CommitmentsCategory).Errors) == 0 {
+		t.Fatalf("commitment not created by any block should be reported\n%s", report.String())
+	}
+	if report.IsConsistent() {
+		t.Fatalf("commitments can not be repaired")
+	}
+}
+
+// a block which fails to replay is reported and the check goes on
+func TestCheckConsistencyReplayError(t *testing.T) {
+	chain := newTestChain(t)
+	db := chain.config.DataBase
+	bestState := chain.BestState.Shard[0]
+	// a transfer of a custom token utxo which does not exist
+	tx := &transaction.TxCustomToken{
+		Tx: transaction.Tx{Type: common.TxCustomTokenType},
+		TxTokenData: transaction.TxTokenData{
+			Type:       transaction.CustomTokenTransfer,
+			PropertyID: common.HashH([]byte("token")),
+			Vins: []transaction.TxTokenVin{{
+				TxCustomTokenID: common.HashH([]byte("utxo")),
+				PaymentAddress:  privacy.PaymentAddress{Pk: []byte{1}},
+			}},
+			Vouts: []transaction.TxTokenVout{{
+				PaymentAddress: privacy.PaymentAddress{Pk: []byte{2}},
+				Value:          1,
+			}},
+		},
+	}
+	block := &ShardBlock{
+		Header: ShardHeader{
+			ShardID:       0,
+			Version:       VERSION,
+			Height:        bestState.ShardHeight + 1,
+			PrevBlockHash: bestState.BestShardBlockHash,
+		},
+		Body: ShardBody{
+			Transactions: []metadata.Transaction{tx},
+		},
+	}
+	if err := db.StoreShardBlock(block, 0); err != nil {
+		t.Fatalf("StoreShardBlock %+v", err)
+	}
+	if err := db.StoreShardBlockIndex(block.Hash(), block.Header.Height, 0); err != nil {
+		t.Fatalf("StoreShardBlockIndex %+v", err)
+	}
+	if err := db.StoreTransactionIndex(tx.Hash(), block.Hash(), 0); err != nil {
+		t.Fatalf("StoreTransactionIndex %+v", err)
+	}
+	bestState.ShardHeight = block.Header.Height
+	bestState.BestShardBlockHash = *block.Hash()
+
+	report := checkConsistency(t, chain, false)
+	errs := report.Category(BlocksCategory).Errors
+	if len(errs) != 1 || !strings.Contains(errs[0], "can not be replayed") {
+		t.Fatalf("replay error should be reported for the block\n%s", report.String())
+	}
+	if len(report.Category(SerialNumbersCategory).Skipped) != 1 || len(report.Category(CustomTokenUTXOCategory).Skipped) != 1 {
+		t.Fatalf("checks depending on the replay of the shard should be skipped\n%s", report.String())
+	}
+	if len(report.Category(TxIndexCategory).Errors) != 0 || report.Category(TxIndexCategory).Checked == 0 {
+		t.Fatalf("tx index should still be checked\n%s", report.String())
+	}
+}
@@ -33,7 +33,17 @@ const (
 
 const (
 	// BlockVersion is the current latest supported block version.
-	BlockVersion = 1
+	BlockVersion = 2
+	// FullHeaderHashVersion is the first block version whose header hash
+	// commits to every header field, older headers keep the legacy hash
+	FullHeaderHashVersion = 2
 
 	defaultGetStateWaitTime = 5
+
+	// number of verified txs remembered by BlockChain.ValidateTxByItself
+	txVerifyCacheSize = 50000
+
+	// DefaultUndoDepth is the number of most recent blocks of each chain
+	// which keep their undo journal when Config.UndoDepth is 0
+	DefaultUndoDepth = 100
 )
@@ -37,6 +37,11 @@ const (
 	TransactionError
 	InstructionError
 	SwapError
+	RollbackError
+	BlockPrunedError
+	ChainFileError
+	BlockCodecError
+	MerkleProofError
 )
 
 var ErrCodeMessage = map[int]struct {
@@ -68,6 +73,11 @@ var ErrCodeMessage = map[int]struct {
 	TransactionError:              {-22, "Transaction invalid"},
 	InstructionError:              {-23, "Instruction Error"},
 	SwapError:                     {-24, "Swap Error"},
+	RollbackError:                 {-25, "Rollback Error"},
+	BlockPrunedError:              {-26, "Block body is pruned on this node"},
+	ChainFileError:                {-27, "Chain File Error"},
+	BlockCodecError:               {-28, "Encode or decode block is failed"},
+	MerkleProofError:              {-29, "Merkle Proof Error"},
 }
 
 type BlockChainError struct {
@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+// mutateField sets field to a value different from the one it has
+func mutateField(t *testing.T, field reflect.Value, name string) {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(field.String() + "x")
+	case reflect.Int, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint8, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	case reflect.Array:
+		// common.Hash
+		field.Index(0).SetUint(field.Index(0).Uint() + 1)
+	case reflect.Slice:
+		field.SetBytes(append(append([]byte{}, field.Bytes()...), 1))
+	default:
+		t.Fatalf("field %s of kind %s is not mutated by the test", name, field.Kind())
+	}
+}
+
+func testShardHeader() ShardHeader {
+	return ShardHeader{
+		Producer:      "producer",
+		ShardID:       1,
+		Version:       FullHeaderHashVersion,
+		PrevBlockHash: common.HashH([]byte("prev")),
+		Height:        10,
+		Epoch:         1,
+		Timestamp:     1000,
+		TxRoot:        common.HashH([]byte("tx")),
+		CrossShards:   []byte{0, 2},
+		BeaconHeight:  5,
+		BeaconHash:    common.HashH([]byte("beacon")),
+	}
+}
+
+func testBeaconHeader() BeaconHeader {
+	return BeaconHeader{
+		Producer:        "producer",
+		Version:         FullHeaderHashVersion,
+		Height:          10,
+		Epoch:           1,
+		Timestamp:       1000,
+		PrevBlockHash:   common.HashH([]byte("prev")),
+		ShardStateHash:  common.HashH([]byte("shard state")),
+		InstructionHash: common.HashH([]byte("instruction")),
+	}
+}
+
+func TestShardHeaderHashCoversEveryField(t *testing.T) {
+	header := testShardHeader()
+	hash := header.Hash()
+	headerType := reflect.TypeOf(header)
+	for i := 0; i < headerType.NumField(); i++ {
+		if headerType.Field(i).Name == "Version" {
+			continue
+		}
+		mutated := testShardHeader()
+		mutateField(t, reflect.ValueOf(&mutated).Elem().Field(i), headerType.Field(i).Name)
+		if mutated.Hash() == hash {
+			t.Errorf("changing %s should change the header hash", headerType.Field(i).Name)
+		}
+	}
+}
+
+func TestBeaconHeaderHashCoversEveryField(t *testing.T) {
+	header := testBeaconHeader()
+	hash := header.Hash()
+	headerType := reflect.TypeOf(header)
+	for i := 0; i < headerType.NumField(); i++ {
+		if headerType.Field(i).Name == "Version" {
+			continue
+		}
+		mutated := testBeaconHeader()
+		mutateField(t, reflect.ValueOf(&mutated).Elem().Field(i), headerType.Field(i).Name)
+		if mutated.Hash() == hash {
+			t.Errorf("changing %s should change the header hash", headerType.Field(i).Name)
+		}
+	}
+}
+
+// headers before FullHeaderHashVersion keep the hash stored chains were built
+// with
+func TestLegacyHeaderHash(t *testing.T) {
+	shardHeader := testShardHeader()
+	shardHeader.Version = FullHeaderHashVersion - 1
+	record := strconv.FormatInt(shardHeader.Timestamp, 10) + shardHeader.Producer + string(shardHeader.ShardID) + strconv.Itoa(shardHeader.Version)
+	if shardHeader.Hash() != common.DoubleHashH([]byte(record)) {
+		t.Fatalf("legacy shard header hash changed")
+	}
+	legacyHash := shardHeader.Hash()
+	shardHeader.TxRoot = common.HashH([]byte("other tx"))
+	if shardHeader.Hash() != legacyHash {
+		t.Fatalf("legacy shard header hash should not cover TxRoot")
+	}
+
+	beaconHeader := testBeaconHeader()
+	beaconHeader.Version = FullHeaderHashVersion - 1
+	record = fmt.Sprintf("%v%v%v", beaconHeader.Version, beaconHeader.Height, beaconHeader.Timestamp) +
+		beaconHeader.PrevBlockHash.String() +
+		beaconHeader.ShardStateHash.String() +
+		beaconHeader.InstructionHash.String() +
+		beaconHeader.Producer
+	if beaconHeader.Hash() != common.DoubleHashH([]byte(record)) {
+		t.Fatalf("legacy beacon header hash changed")
+	}
+	legacyHash = beaconHeader.Hash()
+	beaconHeader.ValidatorsRoot = common.HashH([]byte("validators"))
+	if beaconHeader.Hash() != legacyHash {
+		t.Fatalf("legacy beacon header hash should not cover ValidatorsRoot")
+	}
+}
+
+func TestVerifyPreProcessingShardBlockCrossShards(t *testing.T) {
+	chain := newTestChain(t)
+	genesisBlock := chain.BestState.Shard[0].BestShardBlock
+	block := newTestShardBlock(t, chain, 0, genesisBlock.Body.Transactions)
+	if err := chain.VerifyPreProcessingShardBlock(block, 0); err != nil {
+		t.Fatalf("VerifyPreProcessingShardBlock %+v", err)
+	}
+
+	block.Header.CrossShards = append(block.Header.CrossShards, 3)
+	err := chain.VerifyPreProcessingShardBlock(block, 0)
+	if err == nil || !strings.Contains(err.Error(), "Can't Verify CrossShards") {
+		t.Fatalf("tampered CrossShards should be rejected, got %+v", err)
+	}
+}
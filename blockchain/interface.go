@@ -24,18 +24,28 @@ type ShardToBeaconPool interface {
 type CrossShardPool interface {
 	AddCrossShardBlock(CrossShardBlock) error
 	GetBlock(map[byte]uint64) map[byte][]CrossShardBlock
+	RemoveBlock(map[byte]uint64) error
 }
 
 type NodeShardPool interface {
 	PushBlock(ShardBlock) error
 	GetBlocks(byte, uint64) ([]ShardBlock, error)
 	RemoveBlocks(byte, uint64) error
+	RemoveBlocksBelow(byte, uint64)
 }
 
 type NodeBeaconPool interface {
 	PushBlock(BeaconBlock) error
 	GetBlocks(uint64) ([]BeaconBlock, error)
 	RemoveBlocks(uint64) error
+	RemoveBlocksBelow(uint64)
+}
+
+type OrphanBlockPool interface {
+	AddShardBlock(ShardBlock) error
+	AddCrossShardBlock(CrossShardBlock) error
+	GetShardBlocks(shardID byte, parentHash common.Hash, beaconHeight uint64) []ShardBlock
+	GetCrossShardBlocks(bestShardHeight map[byte]uint64) []CrossShardBlock
 }
 
 type TxPool interface {
@@ -44,15 +54,22 @@ type TxPool interface {
 	LastUpdated() time.Time
 
 	// MiningDescs returns a slice of mining descriptors for all the
-	// transactions in the source pool.
-	MiningDescs() []*metadata.TxDesc
+	// transactions of shard in the source pool, the producer takes them in
+	// order so higher priority txs must come first.
+	MiningDescs(shardID byte) []*metadata.TxDesc
 
 	// HaveTransaction returns whether or not the passed transaction hash
 	// exists in the source pool.
 	HaveTransaction(hash *common.Hash) bool
 
-	// RemoveTx remove tx from tx resource
-	RemoveTx(tx metadata.Transaction) error
+	// RemoveTx remove tx from tx resource, isInBlock is true when the tx
+	// is mined
+	RemoveTx(tx metadata.Transaction, isInBlock bool) error
+
+	// ProcessOrphans tries again txs of shard which spend commitments
+	// unknown when they are received, called after a block of shard is
+	// inserted, returns the accepted txs
+	ProcessOrphans(shardID byte) []metadata.Transaction
 
 	//CheckTransactionFee
 	// CheckTransactionFee(tx metadata.Transaction) (uint64, error)
@@ -4,17 +4,35 @@ import (
 	libp2p "github.com/libp2p/go-libp2p-peer"
 )
 
-func (self *BlockChain) OnBlockShardReceived(block *ShardBlock) {
+func (self *BlockChain) OnBlockShardReceived(block *ShardBlock, peerID libp2p.ID) {
 	if self.newShardBlkCh[block.Header.ShardID] != nil {
-		*self.newShardBlkCh[block.Header.ShardID] <- block
+		*self.newShardBlkCh[block.Header.ShardID] <- &PeerShardBlock{
+			block, peerID,
+		}
 	}
 }
-func (self *BlockChain) OnBlockBeaconReceived(block *BeaconBlock) {
+func (self *BlockChain) OnBlockBeaconReceived(block *BeaconBlock, peerID libp2p.ID) {
 	if self.syncStatus.Beacon {
-		self.newBeaconBlkCh <- block
+		self.newBeaconBlkCh <- &PeerBeaconBlock{
+			block, peerID,
+		}
 	}
 }
 
+/*
+rejectBlock - blame peer which sent a block failing validation, errors caused
+by this node, as database errors, are not blamed
+*/
+func (self *BlockChain) rejectBlock(peerID libp2p.ID, err error) {
+	if peerID == "" || self.config.Server == nil {
+		return
+	}
+	if chainErr, ok := err.(*BlockChainError); ok && (chainErr.Code == ErrCodeMessage[DBError].code || chainErr.Code == ErrCodeMessage[UnExpectedError].code) {
+		return
+	}
+	self.config.Server.OnBlockRejected(peerID, err.Error())
+}
+
 func (self *BlockChain) GetBeaconState() (*BeaconChainState, error) {
 	state := &BeaconChainState{
 		Height:          self.BestState.Beacon.BeaconHeight,
@@ -68,6 +86,12 @@ func (self *BlockChain) OnShardToBeaconBlockReceived(block ShardToBeaconBlock) {
 
 func (self *BlockChain) OnCrossShardBlockReceived(block CrossShardBlock) {
 	//TODO: check node mode -> node role before add block to pool
+	if self.isOrphanCrossShardBlock(&block) {
+		if err := self.config.OrphanBlockPool.AddCrossShardBlock(block); err != nil {
+			Logger.log.Error(err)
+		}
+		return
+	}
 	err := self.config.CrossShardPool.AddCrossShardBlock(block)
 	if err != nil {
 		Logger.log.Error(err)
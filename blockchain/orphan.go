@@ -0,0 +1,92 @@
+package blockchain
+
+/*
+ProcessShardBlock - insert block if its parent block and the beacon block it
+refers to are inserted, otherwise keep it in orphan pool until they are.
+Orphans waiting for the new block are inserted after it
+*/
+func (self *BlockChain) ProcessShardBlock(block *ShardBlock) error {
+	shardID := block.Header.ShardID
+	if self.isOrphanShardBlock(block) {
+		Logger.log.Infof("SHARD %+v | Keep orphan block height %+v at hash %+v", shardID, block.Header.Height, *block.Hash())
+		return self.config.OrphanBlockPool.AddShardBlock(*block)
+	}
+	if err := self.InsertShardBlock(block); err != nil {
+		return err
+	}
+	self.processOrphans(shardID)
+	return nil
+}
+
+/*
+ProcessBeaconBlock - insert block, then shard blocks and cross shard blocks
+which wait for it
+*/
+func (self *BlockChain) ProcessBeaconBlock(block *BeaconBlock) error {
+	if err := self.InsertBeaconBlock(block); err != nil {
+		return err
+	}
+	for _, crossShardBlock := range self.config.OrphanBlockPool.GetCrossShardBlocks(self.BestState.Beacon.BestShardHeight) {
+		if err := self.config.CrossShardPool.AddCrossShardBlock(crossShardBlock); err != nil {
+			Logger.log.Error(err)
+		}
+	}
+	for shardID := range self.BestState.Shard {
+		self.processOrphans(shardID)
+	}
+	return nil
+}
+
+// isOrphanShardBlock - beacon block or parent block of block is not inserted
+func (self *BlockChain) isOrphanShardBlock(block *ShardBlock) bool {
+	if block.Header.BeaconHeight > self.BestState.Beacon.BeaconHeight {
+		return true
+	}
+	prevBlockHash := block.Header.PrevBlockHash
+	isParentKnown, err := self.config.DataBase.HasBlock(&prevBlockHash)
+	return err == nil && !isParentKnown
+}
+
+// isOrphanCrossShardBlock - shard block of block is not accepted by beacon
+func (self *BlockChain) isOrphanCrossShardBlock(block *CrossShardBlock) bool {
+	return block.Header.Height > self.BestState.Beacon.BestShardHeight[block.Header.ShardID]
+}
+
+/*
+processOrphans - insert orphan blocks of shard which are children of its best
+block and whose beacon block is inserted, then let mempool try again orphan
+txs which may spend coins of the new blocks
+*/
+func (self *BlockChain) processOrphans(shardID byte) {
+	for {
+		bestState, ok := self.BestState.Shard[shardID]
+		if !ok || bestState == nil {
+			return
+		}
+		blocks := self.config.OrphanBlockPool.GetShardBlocks(shardID, bestState.BestShardBlockHash, self.BestState.Beacon.BeaconHeight)
+		isInserted := false
+		// children of the same parent compete, the first valid one wins
+		for index := range blocks {
+			if err := self.InsertShardBlock(&blocks[index]); err != nil {
+				Logger.log.Error(err)
+				continue
+			}
+			isInserted = true
+			break
+		}
+		if !isInserted {
+			break
+		}
+	}
+	if self.config.TxPool != nil {
+		// orphans were not relayed when received
+		for _, tx := range self.config.TxPool.ProcessOrphans(shardID) {
+			if self.config.Server == nil {
+				break
+			}
+			if err := self.config.Server.PushMessageTx(tx); err != nil {
+				Logger.log.Error(err)
+			}
+		}
+	}
+}
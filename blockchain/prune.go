@@ -0,0 +1,162 @@
+package blockchain
+
+import (
+	"errors"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/database"
+)
+
+// IsBlockPrunedError returns true when err is returned for a block whose body is pruned
+func IsBlockPrunedError(err error) bool {
+	blockChainErr, ok := err.(*BlockChainError)
+	return ok && blockChainErr.Code == ErrCodeMessage[BlockPrunedError].code
+}
+
+func (self *BlockChain) checkBlockPruned(hash *common.Hash) error {
+	pruned, err := self.config.DataBase.IsBlockPruned(hash)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if pruned {
+		return NewBlockChainError(BlockPrunedError, errors.New(hash.String()))
+	}
+	return nil
+}
+
+/*
+pruneShardBlock - drop the body of the shard block PruneDepth blocks below height,
+header, tx index, serial numbers and commitments are kept, its undo journal
+is deleted.
+Genesis block is never pruned.
+Writes go to db, the batch of the block at height when called on insert.
+*/
+func (self *BlockChain) pruneShardBlock(db database.DatabaseInterface, shardID byte, height uint64) error {
+	if self.config.PruneDepth == 0 || height <= self.config.PruneDepth+1 {
+		return nil
+	}
+	_, err := self.pruneShardBlockByHeight(db, shardID, height-self.config.PruneDepth)
+	return err
+}
+
+// pruneShardBlockByHeight returns false when the block was already pruned
+func (self *BlockChain) pruneShardBlockByHeight(db database.DatabaseInterface, shardID byte, height uint64) (bool, error) {
+	hash, err := db.GetBlockByIndex(height, shardID)
+	if err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	block, err := self.GetShardBlockByHash(hash)
+	if err != nil {
+		if IsBlockPrunedError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	header := ShardBlock{
+		AggregatedSig: block.AggregatedSig,
+		R:             block.R,
+		ValidatorsIdx: block.ValidatorsIdx,
+		ProducerSig:   block.ProducerSig,
+		Header:        block.Header,
+	}
+	if err := db.PruneBlockBody(hash, &header); err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	// a pruned block can not be rolled back
+	if err := db.DeleteUndo(hash); err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	return true, nil
+}
+
+/*
+pruneBeaconBlock - same as pruneShardBlock for beacon chain
+*/
+func (self *BlockChain) pruneBeaconBlock(db database.DatabaseInterface, height uint64) error {
+	if self.config.PruneDepth == 0 || height <= self.config.PruneDepth+1 {
+		return nil
+	}
+	_, err := self.pruneBeaconBlockByHeight(db, height-self.config.PruneDepth)
+	return err
+}
+
+func (self *BlockChain) pruneBeaconBlockByHeight(db database.DatabaseInterface, height uint64) (bool, error) {
+	hash, err := db.GetBeaconBlockHashByIndex(height)
+	if err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	block, err := self.GetBeaconBlockByHash(hash)
+	if err != nil {
+		if IsBlockPrunedError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	header := BeaconBlock{
+		AggregatedSig: block.AggregatedSig,
+		R:             block.R,
+		ValidatorsIdx: block.ValidatorsIdx,
+		ProducerSig:   block.ProducerSig,
+		Header:        block.Header,
+	}
+	if err := db.PruneBlockBody(hash, &header); err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	// a pruned block can not be rolled back
+	if err := db.DeleteUndo(hash); err != nil {
+		return false, NewBlockChainError(DBError, err)
+	}
+	return true, nil
+}
+
+/*
+pruneOldBlocks - catch up pruning of blocks stored before pruning was enabled
+or while the node was stopped. Blocks are pruned from the top down to the first
+block already pruned, as everything below it is pruned too.
+*/
+func (self *BlockChain) pruneOldBlocks() error {
+	if self.config.PruneDepth == 0 {
+		return nil
+	}
+	if self.BestState.Beacon != nil && self.BestState.Beacon.BeaconHeight > self.config.PruneDepth+1 {
+		for height := self.BestState.Beacon.BeaconHeight - self.config.PruneDepth; height > 1; height-- {
+			if self.interruptRequested() {
+				return nil
+			}
+			pruned, err := self.pruneBeaconBlockByHeight(self.config.DataBase, height)
+			if err != nil {
+				return err
+			}
+			if !pruned {
+				break
+			}
+		}
+	}
+	for shardID, bestState := range self.BestState.Shard {
+		if bestState.ShardHeight <= self.config.PruneDepth+1 {
+			continue
+		}
+		for height := bestState.ShardHeight - self.config.PruneDepth; height > 1; height-- {
+			if self.interruptRequested() {
+				return nil
+			}
+			pruned, err := self.pruneShardBlockByHeight(self.config.DataBase, shardID, height)
+			if err != nil {
+				return err
+			}
+			if !pruned {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (self *BlockChain) interruptRequested() bool {
+	select {
+	case <-self.config.Interrupt:
+		return true
+	default:
+	}
+	return false
+}
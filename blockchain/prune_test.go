@@ -0,0 +1,90 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// storeTestShardBlock stores the next block of shard 0 the way InsertShardBlock does
+func storeTestShardBlock(t *testing.T, chain *BlockChain) (*ShardBlock, error) {
+	block := newTestShardBlock(t, chain, 0, []metadata.Transaction{newTestTx(t, chain)})
+	bestState := chain.BestState.Shard[0].Clone()
+	if err := bestState.Update(block, nil); err != nil {
+		t.Fatalf("Update %+v", err)
+	}
+	return block, chain.ProcessStoreShardBlock(block, bestState)
+}
+
+func checkPruned(t *testing.T, chain *BlockChain, hash *common.Hash, pruned bool, hasUndo bool) {
+	isPruned, err := chain.config.DataBase.IsBlockPruned(hash)
+	if err != nil {
+		t.Fatalf("IsBlockPruned %+v", err)
+	}
+	if isPruned != pruned {
+		t.Fatalf("block %+v pruned %v, want %v", hash, isPruned, pruned)
+	}
+	_, err = chain.config.DataBase.FetchUndo(hash)
+	if (err == nil) != hasUndo {
+		t.Fatalf("block %+v has undo journal %v, want %v", hash, err == nil, hasUndo)
+	}
+}
+
+func TestPruneBeaconBlockWithBlockBatch(t *testing.T) {
+	chain := newTestChain(t)
+	chain.config.PruneDepth = 1
+	chain.config.UndoDepth = 2
+	blocks := []*BeaconBlock{nil, nil}
+	for i := 0; i < 2; i++ {
+		blocks = append(blocks, insertTestBeaconBlock(t, chain))
+	}
+	// height 2 is pruned by height 3
+	checkPruned(t, chain, blocks[2].Hash(), true, false)
+	checkPruned(t, chain, blocks[3].Hash(), false, true)
+
+	block := newTestBeaconBlock(t, chain)
+	db := chain.config.DataBase
+	chain.config.DataBase = failingWriteDB{db}
+	if err := chain.InsertBeaconBlock(block); err == nil {
+		t.Fatalf("InsertBeaconBlock should fail when the batch can not be written")
+	}
+	chain.config.DataBase = db
+	checkPruned(t, chain, blocks[3].Hash(), false, true)
+
+	if err := chain.InsertBeaconBlock(block); err != nil {
+		t.Fatalf("InsertBeaconBlock %+v", err)
+	}
+	checkPruned(t, chain, blocks[3].Hash(), true, false)
+	checkPruned(t, chain, block.Hash(), false, true)
+}
+
+func TestDropShardUndoWithBlockBatch(t *testing.T) {
+	chain := newTestChain(t)
+	chain.config.UndoDepth = 2
+	blocks := []*ShardBlock{nil, chain.BestState.Shard[0].BestShardBlock}
+	for i := 0; i < 2; i++ {
+		block, err := storeTestShardBlock(t, chain)
+		if err != nil {
+			t.Fatalf("ProcessStoreShardBlock %+v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	// journal of height 1 is dropped by height 3
+	checkPruned(t, chain, blocks[1].Hash(), false, false)
+	checkPruned(t, chain, blocks[2].Hash(), false, true)
+
+	db := chain.config.DataBase
+	chain.config.DataBase = failingWriteDB{db}
+	if _, err := storeTestShardBlock(t, chain); err == nil {
+		t.Fatalf("ProcessStoreShardBlock should fail when the batch can not be written")
+	}
+	chain.config.DataBase = db
+	checkPruned(t, chain, blocks[2].Hash(), false, true)
+
+	if _, err := storeTestShardBlock(t, chain); err != nil {
+		t.Fatalf("ProcessStoreShardBlock %+v", err)
+	}
+	checkPruned(t, chain, blocks[2].Hash(), false, false)
+	checkPruned(t, chain, blocks[3].Hash(), false, true)
+}
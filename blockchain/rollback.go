@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/database"
+)
+
+/*
+RollbackShardBlock - revert the best block of a shard
+Every write made when the block was stored is reverted with its undo journal:
+serial numbers, commitments, snderivators, output coins, custom token utxo,
+block and tx indexes, and the best state of the shard goes back to the
+previous block.
+Only the best block can be rolled back, call it again to go further back,
+down to UndoDepth blocks below the best block.
+*/
+func (self *BlockChain) RollbackShardBlock(shardID byte) error {
+	self.chainLock.Lock()
+	defer self.chainLock.Unlock()
+
+	bestState, ok := self.BestState.Shard[shardID]
+	if !ok || bestState == nil {
+		return NewBlockChainError(RollbackError, errors.New("shard best state is not initialized"))
+	}
+	if bestState.ShardHeight <= 1 {
+		return NewBlockChainError(RollbackError, errors.New("can not rollback genesis block"))
+	}
+	blockHash := bestState.BestShardBlockHash
+	if err := self.revertBlock(&blockHash); err != nil {
+		return err
+	}
+	// proofs are verified against commitments which may be reverted
+	self.txVerifyCache.reset()
+
+	prevBestState := &BestStateShard{}
+	bestStateBytes, err := self.config.DataBase.FetchBestState(shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := json.Unmarshal(bestStateBytes, prevBestState); err != nil {
+		return NewBlockChainError(UnmashallJsonBlockError, err)
+	}
+	self.BestState.Shard[shardID] = prevBestState
+	Logger.log.Infof("Rollback shard %d block %+v, best block is now %d %+v", shardID, blockHash, prevBestState.ShardHeight, prevBestState.BestShardBlockHash)
+	return nil
+}
+
+/*
+RollbackBeaconBlock - revert the best block of the beacon chain
+Same as RollbackShardBlock: block, indexes, accepted shard to beacon blocks,
+committee and the beacon best state go back to the previous block.
+*/
+func (self *BlockChain) RollbackBeaconBlock() error {
+	self.chainLock.Lock()
+	defer self.chainLock.Unlock()
+
+	if self.BestState.Beacon == nil {
+		return NewBlockChainError(RollbackError, errors.New("beacon best state is not initialized"))
+	}
+	if self.BestState.Beacon.BeaconHeight <= 1 {
+		return NewBlockChainError(RollbackError, errors.New("can not rollback genesis block"))
+	}
+	blockHash := self.BestState.Beacon.BestBlockHash
+	if err := self.revertBlock(&blockHash); err != nil {
+		return err
+	}
+
+	prevBestState := &BestStateBeacon{}
+	bestStateBytes, err := self.config.DataBase.FetchBeaconBestState()
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := json.Unmarshal(bestStateBytes, prevBestState); err != nil {
+		return NewBlockChainError(UnmashallJsonBlockError, err)
+	}
+	self.BestState.Beacon = prevBestState
+	Logger.log.Infof("Rollback beacon block %+v, best block is now %d %+v", blockHash, prevBestState.BeaconHeight, prevBestState.BestBlockHash)
+	return nil
+}
+
+// revertBlock applies the undo journal of a block in one batch and drops the journal
+func (self *BlockChain) revertBlock(blockHash *common.Hash) error {
+	undo, err := self.config.DataBase.FetchUndo(blockHash)
+	if err != nil {
+		return NewBlockChainError(RollbackError, err)
+	}
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
+	if err := database.ApplyUndo(batch, undo); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := batch.DeleteUndo(blockHash); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := batch.Write(); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	return nil
+}
+
+/*
+dropShardUndo - delete the undo journal of the shard block UndoDepth blocks
+below height, it is out of the rollback window. db is the batch of the block
+at height.
+*/
+func (self *BlockChain) dropShardUndo(db database.DatabaseInterface, shardID byte, height uint64) error {
+	if height <= self.config.UndoDepth {
+		return nil
+	}
+	hash, err := db.GetBlockByIndex(height-self.config.UndoDepth, shardID)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := db.DeleteUndo(hash); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	return nil
+}
+
+/*
+dropBeaconUndo - same as dropShardUndo for beacon chain
+*/
+func (self *BlockChain) dropBeaconUndo(db database.DatabaseInterface, height uint64) error {
+	if height <= self.config.UndoDepth {
+		return nil
+	}
+	hash, err := db.GetBeaconBlockHashByIndex(height - self.config.UndoDepth)
+	if err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	if err := db.DeleteUndo(hash); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	return nil
+}
+
+/*
+dropOldUndo - delete the undo journals below the rollback window which were
+stored before UndoDepth was lowered or by an older version. Journals are
+deleted from the top down to the first block without one.
+*/
+func (self *BlockChain) dropOldUndo() error {
+	if self.BestState.Beacon != nil && self.BestState.Beacon.BeaconHeight > self.config.UndoDepth {
+		for height := self.BestState.Beacon.BeaconHeight - self.config.UndoDepth; height >= 1; height-- {
+			if self.interruptRequested() {
+				return nil
+			}
+			hash, err := self.config.DataBase.GetBeaconBlockHashByIndex(height)
+			if err != nil {
+				return NewBlockChainError(DBError, err)
+			}
+			if _, err := self.config.DataBase.FetchUndo(hash); err != nil {
+				break
+			}
+			if err := self.config.DataBase.DeleteUndo(hash); err != nil {
+				return NewBlockChainError(DBError, err)
+			}
+		}
+	}
+	for shardID, bestState := range self.BestState.Shard {
+		if bestState.ShardHeight <= self.config.UndoDepth {
+			continue
+		}
+		for height := bestState.ShardHeight - self.config.UndoDepth; height >= 1; height-- {
+			if self.interruptRequested() {
+				return nil
+			}
+			hash, err := self.config.DataBase.GetBlockByIndex(height, shardID)
+			if err != nil {
+				return NewBlockChainError(DBError, err)
+			}
+			if _, err := self.config.DataBase.FetchUndo(hash); err != nil {
+				break
+			}
+			if err := self.config.DataBase.DeleteUndo(hash); err != nil {
+				return NewBlockChainError(DBError, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+// shardStateJson - best state of a shard without its best block, the codec
+// does not keep empty and nil body fields apart
+func shardStateJson(t *testing.T, bestState *BestStateShard) []byte {
+	state := *bestState
+	state.BestShardBlock = nil
+	res, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("json.Marshal %+v", err)
+	}
+	return res
+}
+
+func beaconStateJson(t *testing.T, bestState *BestStateBeacon) []byte {
+	state := *bestState
+	state.BestBlock = nil
+	res, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("json.Marshal %+v", err)
+	}
+	return res
+}
+
+// checkShardBlockStored checks the index, serial number, commitment and output
+// coin stored for the tx of a block made by storeTestShardBlock
+func checkShardBlockStored(t *testing.T, chain *BlockChain, block *ShardBlock, stored bool) {
+	db := chain.config.DataBase
+	tokenID := &common.Hash{}
+	tokenID.SetBytes(common.ConstantID[:])
+	tx := block.Body.Transactions[0]
+	proof := tx.GetProof()
+	outputCoin := proof.OutputCoins[0].CoinDetails
+
+	hash, err := db.GetBlockByIndex(block.Header.Height, 0)
+	if (err == nil && hash.IsEqual(block.Hash())) != stored {
+		t.Fatalf("height %d index of block %+v is stored %v, want %v", block.Header.Height, block.Hash(), err == nil, stored)
+	}
+	if _, _, err := db.GetTransactionIndexById(tx.Hash()); (err == nil) != stored {
+		t.Fatalf("tx index is stored %v, want %v", err == nil, stored)
+	}
+	if ok, err := db.HasSerialNumber(tokenID, proof.InputCoins[0].CoinDetails.SerialNumber.Compress(), 0); err != nil || ok != stored {
+		t.Fatalf("serial number is stored %v, want %v, err %+v", ok, stored, err)
+	}
+	if ok, err := db.HasCommitment(tokenID, outputCoin.CoinCommitment.Compress(), 0); err != nil || ok != stored {
+		t.Fatalf("commitment is stored %v, want %v, err %+v", ok, stored, err)
+	}
+	if ok, err := db.HasSNDerivator(tokenID, *outputCoin.SNDerivator, 0); err != nil || ok != stored {
+		t.Fatalf("snderivator is stored %v, want %v, err %+v", ok, stored, err)
+	}
+	outputCoins, err := db.GetOutcoinsByPubkey(tokenID, outputCoin.PublicKey.Compress(), 0)
+	if err != nil {
+		t.Fatalf("GetOutcoinsByPubkey %+v", err)
+	}
+	found := false
+	for _, outputCoinBytes := range outputCoins {
+		if bytes.Equal(outputCoinBytes, proof.OutputCoins[0].Bytes()) {
+			found = true
+		}
+	}
+	if found != stored {
+		t.Fatalf("output coin is stored %v, want %v", found, stored)
+	}
+}
+
+func TestRollbackShardBlock(t *testing.T) {
+	chain := newTestChain(t)
+	genesisState := shardStateJson(t, chain.BestState.Shard[0])
+	first, err := storeTestShardBlock(t, chain)
+	if err != nil {
+		t.Fatalf("ProcessStoreShardBlock %+v", err)
+	}
+	firstState := shardStateJson(t, chain.BestState.Shard[0])
+	second, err := storeTestShardBlock(t, chain)
+	if err != nil {
+		t.Fatalf("ProcessStoreShardBlock %+v", err)
+	}
+	checkShardBlockStored(t, chain, second, true)
+
+	if err := chain.RollbackShardBlock(0); err != nil {
+		t.Fatalf("RollbackShardBlock %+v", err)
+	}
+	if state := shardStateJson(t, chain.BestState.Shard[0]); !bytes.Equal(state, firstState) {
+		t.Fatalf("best state is not restored\ngot  %s\nwant %s", state, firstState)
+	}
+	checkShardBlockStored(t, chain, second, false)
+	checkShardBlockStored(t, chain, first, true)
+
+	if err := chain.RollbackShardBlock(0); err != nil {
+		t.Fatalf("RollbackShardBlock %+v", err)
+	}
+	if state := shardStateJson(t, chain.BestState.Shard[0]); !bytes.Equal(state, genesisState) {
+		t.Fatalf("best state is not restored\ngot  %s\nwant %s", state, genesisState)
+	}
+	checkShardBlockStored(t, chain, first, false)
+	if err := chain.RollbackShardBlock(0); err == nil {
+		t.Fatalf("RollbackShardBlock should not rollback genesis block")
+	}
+
+	// the rolled back block can be stored again
+	bestState := chain.BestState.Shard[0].Clone()
+	if err := bestState.Update(first, nil); err != nil {
+		t.Fatalf("Update %+v", err)
+	}
+	if err := chain.ProcessStoreShardBlock(first, bestState); err != nil {
+		t.Fatalf("ProcessStoreShardBlock %+v", err)
+	}
+	checkShardBlockStored(t, chain, first, true)
+}
+
+func TestRollbackBeaconBlock(t *testing.T) {
+	chain := newTestChain(t)
+	first := insertTestBeaconBlock(t, chain)
+	firstState := beaconStateJson(t, chain.BestState.Beacon)
+	second := insertTestBeaconBlock(t, chain)
+
+	if err := chain.RollbackBeaconBlock(); err != nil {
+		t.Fatalf("RollbackBeaconBlock %+v", err)
+	}
+	if state := beaconStateJson(t, chain.BestState.Beacon); !bytes.Equal(state, firstState) {
+		t.Fatalf("best state is not restored\ngot  %s\nwant %s", state, firstState)
+	}
+	if _, err := chain.config.DataBase.GetBeaconBlockHashByIndex(second.Header.Height); err == nil {
+		t.Fatalf("height index of the rolled back block should be deleted")
+	}
+	if hash, err := chain.config.DataBase.GetBeaconBlockHashByIndex(first.Header.Height); err != nil || !hash.IsEqual(first.Hash()) {
+		t.Fatalf("height index of the previous block should be kept, %+v", err)
+	}
+
+	// the rolled back block can be inserted again
+	if err := chain.InsertBeaconBlock(second); err != nil {
+		t.Fatalf("InsertBeaconBlock %+v", err)
+	}
+	if chain.BestState.Beacon.BestBlockHash != *second.Hash() {
+		t.Fatalf("best block should be the inserted block")
+	}
+}
+
+// blocks out of the rollback window have no journal, rolling back stops
+// there without touching the chain
+func TestRollbackBeyondUndoDepth(t *testing.T) {
+	chain := newTestChain(t)
+	chain.config.UndoDepth = 2
+	for i := 0; i < 3; i++ {
+		insertTestBeaconBlock(t, chain)
+		if _, err := storeTestShardBlock(t, chain); err != nil {
+			t.Fatalf("ProcessStoreShardBlock %+v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := chain.RollbackBeaconBlock(); err != nil {
+			t.Fatalf("RollbackBeaconBlock %d %+v", i, err)
+		}
+		if err := chain.RollbackShardBlock(0); err != nil {
+			t.Fatalf("RollbackShardBlock %d %+v", i, err)
+		}
+	}
+
+	beaconState := beaconStateJson(t, chain.BestState.Beacon)
+	shardBestState := chain.BestState.Shard[0]
+	shardState := shardStateJson(t, shardBestState)
+	if err := chain.RollbackBeaconBlock(); err == nil {
+		t.Fatalf("RollbackBeaconBlock beyond UndoDepth should fail")
+	}
+	if err := chain.RollbackShardBlock(0); err == nil {
+		t.Fatalf("RollbackShardBlock beyond UndoDepth should fail")
+	}
+	if state := beaconStateJson(t, chain.BestState.Beacon); !bytes.Equal(state, beaconState) {
+		t.Fatalf("failed rollback changed beacon best state\ngot  %s\nwant %s", state, beaconState)
+	}
+	if chain.BestState.Shard[0] != shardBestState || !bytes.Equal(shardStateJson(t, chain.BestState.Shard[0]), shardState) {
+		t.Fatalf("failed rollback changed shard best state")
+	}
+	if hash, err := chain.config.DataBase.GetBeaconBlockHashByIndex(2); err != nil || !hash.IsEqual(&chain.BestState.Beacon.BestBlockHash) {
+		t.Fatalf("failed rollback changed beacon height index, %+v", err)
+	}
+	if hash, err := chain.config.DataBase.GetBlockByIndex(2, 0); err != nil || !hash.IsEqual(&shardBestState.BestShardBlockHash) {
+		t.Fatalf("failed rollback changed shard height index, %+v", err)
+	}
+	storedState, err := chain.GetShardBestState(0)
+	if err != nil || storedState.ShardHeight != 2 {
+		t.Fatalf("failed rollback changed stored shard best state, %+v", err)
+	}
+}
+
+func TestDropOldUndo(t *testing.T) {
+	chain := newTestChain(t)
+	for i := 0; i < 5; i++ {
+		insertTestBeaconBlock(t, chain)
+		if _, err := storeTestShardBlock(t, chain); err != nil {
+			t.Fatalf("ProcessStoreShardBlock %+v", err)
+		}
+	}
+	// UndoDepth lowered after blocks were stored
+	chain.config.UndoDepth = 2
+	if err := chain.dropOldUndo(); err != nil {
+		t.Fatalf("dropOldUndo %+v", err)
+	}
+
+	db := chain.config.DataBase
+	for height := uint64(1); height <= chain.BestState.Beacon.BeaconHeight; height++ {
+		hash, err := db.GetBeaconBlockHashByIndex(height)
+		if err != nil {
+			t.Fatalf("GetBeaconBlockHashByIndex %+v", err)
+		}
+		_, err = db.FetchUndo(hash)
+		if keep := height > chain.BestState.Beacon.BeaconHeight-2; (err == nil) != keep {
+			t.Fatalf("beacon block %d has undo journal %v, want %v", height, err == nil, keep)
+		}
+	}
+	for height := uint64(1); height <= chain.BestState.Shard[0].ShardHeight; height++ {
+		hash, err := db.GetBlockByIndex(height, 0)
+		if err != nil {
+			t.Fatalf("GetBlockByIndex %+v", err)
+		}
+		_, err = db.FetchUndo(hash)
+		if keep := height > chain.BestState.Shard[0].ShardHeight-2; (err == nil) != keep {
+			t.Fatalf("shard block %d has undo journal %v, want %v", height, err == nil, keep)
+		}
+	}
+}
@@ -72,3 +72,27 @@ func (self *BestStateShard) GetPubkeyRole(pubkey string) string {
 
 	return ""
 }
+
+/*
+Clone - copy of the best state which can be updated with a new block without
+touching the current one, blocks are shared since they are not modified
+*/
+func (self *BestStateShard) Clone() *BestStateShard {
+	bestState := *self
+	bestState.ShardCommittee = cloneStrings(self.ShardCommittee)
+	bestState.ShardPendingValidator = cloneStrings(self.ShardPendingValidator)
+	if self.BestCrossShard != nil {
+		bestState.BestCrossShard = make(map[byte]uint64, len(self.BestCrossShard))
+		for shardID, height := range self.BestCrossShard {
+			bestState.BestCrossShard[shardID] = height
+		}
+	}
+	return &bestState
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(values)), values...)
+}
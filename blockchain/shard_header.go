@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 /*
@@ -37,28 +38,29 @@ type ShardHeader struct {
 	BeaconHash   common.Hash
 }
 
+/*
+Hash - hash of shard header
+Headers from FullHeaderHashVersion hash the codec encoding of the whole
+header, older headers (genesis, stored chains) keep the legacy string hash
+*/
 func (self ShardHeader) Hash() common.Hash {
+	if self.Version < FullHeaderHashVersion {
+		return self.legacyHash()
+	}
+	data, err := codec.Marshal(self)
+	if err != nil {
+		// header only holds plain fields, encoding can not fail
+		panic(err)
+	}
+	return common.DoubleHashH(data)
+}
+
+// legacyHash only covers timestamp, producer, shard and version
+func (self ShardHeader) legacyHash() common.Hash {
 	record := common.EmptyString
-	// crossShardHash, _ := common.Hash{}.NewHash(self.CrossShards)
-	// add data from header
 	record += strconv.FormatInt(self.Timestamp, 10) +
 		self.Producer +
 		string(self.ShardID) +
 		strconv.Itoa(self.Version)
-		// TODO: Uncomment this when finish genesis shard block
-		// self.PrevBlockHash.String() +
-		// strconv.Itoa(int(self.Height)) +
-		// strconv.Itoa(int(self.Epoch)) +
-		// strconv.Itoa(int(self.Timestamp)) +
-		// strconv.Itoa(int(self.SalaryFund)) +
-		// self.TxRoot.String() +
-		// self.ShardTxRoot.String() +
-		// self.CrossOutputCoinRoot.String() +
-		// self.ActionsRoot.String() +
-		// self.CommitteeRoot.String() +
-		// self.PendingValidatorRoot.String() +
-		// self.BeaconHash.String() +
-		// crossShardHash.String() +
-		// strconv.Itoa(int(self.BeaconHeight))
 	return common.DoubleHashH([]byte(record))
 }
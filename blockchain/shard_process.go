@@ -4,13 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
 )
 
 func (self *BlockChain) VerifyPreSignShardBlock(block *ShardBlock, shardID byte) error {
@@ -60,23 +61,29 @@ func (self *BlockChain) VerifyPreSignShardBlock(block *ShardBlock, shardID byte)
 	return nil
 }
 
-func (self *BlockChain) ProcessStoreShardBlock(block *ShardBlock) error {
+/*
+	Store block, best state, tx view point and indexes of a shard block
+	All writes go through one database batch so that the block
+	and all of its side effects are committed all-or-nothing
+	bestState is the best state already updated with block, it replaces the
+	current best state of the shard only once the batch is written
+*/
+func (self *BlockChain) ProcessStoreShardBlock(block *ShardBlock, bestState *BestStateShard) error {
 	blockHash := block.Hash().String()
 	Logger.log.Debugf("Process store block %+v", blockHash)
 
-	if err := self.BestState.Shard[block.Header.ShardID].Update(block, nil); err != nil {
-		return err
-	}
+	batch := self.config.DataBase.NewBatch()
+	defer batch.Reset()
 
-	if err := self.StoreShardBlock(block); err != nil {
+	if err := self.StoreShardBlock(batch, block); err != nil {
 		return err
 	}
 
-	if err := self.StoreShardBlockIndex(block); err != nil {
+	if err := self.StoreShardBlockIndex(batch, block); err != nil {
 		return err
 	}
 
-	if err := self.StoreShardBestState(block.Header.ShardID); err != nil {
+	if err := batch.StoreBestState(bestState, block.Header.ShardID); err != nil {
 		return err
 	}
 
@@ -88,7 +95,7 @@ func (self *BlockChain) ProcessStoreShardBlock(block *ShardBlock) error {
 	}
 
 	// TODO: Check: store output coin?
-	if err := self.CreateAndSaveTxViewPointFromBlock(block); err != nil {
+	if err := self.CreateAndSaveTxViewPointFromBlock(batch, block); err != nil {
 		return err
 	}
 
@@ -98,13 +105,13 @@ func (self *BlockChain) ProcessStoreShardBlock(block *ShardBlock) error {
 			//TODO: do what???
 		}
 
-		if err := self.StoreTransactionIndex(tx.Hash(), block.Hash(), index); err != nil {
+		if err := self.StoreTransactionIndex(batch, tx.Hash(), block.Hash(), index); err != nil {
 			Logger.log.Error("ERROR", err, "Transaction in block with hash", blockHash, "and index", index, ":", tx)
 			return NewBlockChainError(UnExpectedError, err)
 		}
 		Logger.log.Debugf("Transaction in block with hash", blockHash, "and index", index)
 	}
-	err := self.StoreIncomingCrossShard(block)
+	err := self.StoreIncomingCrossShard(batch, block)
 	if err != nil {
 		return NewBlockChainError(UnExpectedError, err)
 	}
@@ -113,6 +120,23 @@ func (self *BlockChain) ProcessStoreShardBlock(block *ShardBlock) error {
 	// 	return NewBlockChainError(UnExpectedError, err)
 	// }
 	//TODO: store most recent proccess cross shard block
+
+	// Undo journal of this block, used by RollbackShardBlock
+	if err := batch.StoreUndo(block.Hash(), batch.Undo()); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	// blocks below the prune and rollback windows, not in the undo journal
+	if err := self.pruneShardBlock(batch, block.Header.ShardID, block.Header.Height); err != nil {
+		return err
+	}
+	if err := self.dropShardUndo(batch, block.Header.ShardID, block.Header.Height); err != nil {
+		return err
+	}
+	//========Commit all changes of this block at once
+	if err := batch.Write(); err != nil {
+		return NewBlockChainError(DBError, err)
+	}
+	self.BestState.Shard[block.Header.ShardID] = bestState
 	return nil
 }
 
@@ -140,24 +164,36 @@ func (self *BlockChain) InsertShardBlock(block *ShardBlock) error {
 
 	Logger.log.Debugf("SHARD %+v | Update BestState with Block %+v \n", block.Header.ShardID, *block.Hash())
 	//========Update best state with new block
+	// on a copy, current best state stays as is until the block is stored
 	prevBeaconHeight := self.BestState.Shard[shardID].BeaconHeight
 	beaconBlocks, err := FetchBeaconBlockFromHeight(self.config.DataBase, prevBeaconHeight, block.Header.BeaconHeight)
 	if err != nil {
 		return err
 	}
-	if err := self.BestState.Shard[shardID].Update(block, beaconBlocks); err != nil {
+	shardBestState := self.BestState.Shard[shardID].Clone()
+	if err := shardBestState.Update(block, beaconBlocks); err != nil {
 		return err
 	}
 
 	Logger.log.Debugf("SHARD %+v | Verify Post Processing Block %+v \n", block.Header.ShardID, *block.Hash())
 	//========Post verififcation: verify new beaconstate with corresponding block
-	if err := self.BestState.Shard[shardID].VerifyPostProcessingShardBlock(block, shardID); err != nil {
+	if err := shardBestState.VerifyPostProcessingShardBlock(block, shardID); err != nil {
+		return err
+	}
+	//========Store new Shardblock and new Shard bestState
+	if err := self.ProcessStoreShardBlock(block, shardBestState); err != nil {
 		return err
 	}
-	//========Store new Beaconblock and new Beacon bestState
-	self.ProcessStoreShardBlock(block)
 
-	//TODO: Remove cross shard block in pool
+	//=========Remove blocks up to new best state from pools
+	self.config.CrossShardPool.RemoveBlock(self.BestState.Shard[shardID].BestCrossShard)
+	self.config.NodeShardPool.RemoveBlocksBelow(shardID, block.Header.Height)
+	//=========Remove txs of block from mempool, subscribers see them mined
+	if self.config.TxPool != nil {
+		for _, tx := range block.Body.Transactions {
+			self.config.TxPool.RemoveTx(tx, true)
+		}
+	}
 	Logger.log.Infof("SHARD %+v | Finish Insert new block %d, with hash %+v", block.Header.ShardID, block.Header.Height, *block.Hash())
 	return nil
 }
@@ -175,6 +211,7 @@ DO NOT USE THIS with GENESIS BLOCK
 - TxRoot
 - ShardTxRoot
 - CrossOutputCoinRoot
+- CrossShards
 - ActionsRoot
 - BeaconHeight
 - BeaconHash
@@ -195,7 +232,9 @@ func (self *BlockChain) VerifyPreProcessingShardBlock(block *ShardBlock, shardID
 		return NewBlockChainError(DBError, err)
 	}
 	parentBlock := ShardBlock{}
-	json.Unmarshal(parentBlockData, &parentBlock)
+	if err := codec.Unmarshal(parentBlockData, &parentBlock); err != nil {
+		return NewBlockChainError(BlockCodecError, err)
+	}
 	// Verify block height with parent block
 	if parentBlock.Header.Height+1 != block.Header.Height {
 		return NewBlockChainError(BlockHeightError, errors.New("Block height of new block should be :"+strconv.Itoa(int(block.Header.Height+1))))
@@ -213,10 +252,6 @@ func (self *BlockChain) VerifyPreProcessingShardBlock(block *ShardBlock, shardID
 	txRoot := txMerkle[len(txMerkle)-1]
 
 	if bytes.Compare(block.Header.TxRoot.GetBytes(), txRoot.GetBytes()) != 0 {
-		fmt.Println()
-		test, _ := json.Marshal(block.Body.Transactions[0])
-		fmt.Println(len(block.Body.Transactions), string(test))
-		fmt.Println()
 		return NewBlockChainError(HashError, errors.New("Can't Verify Transaction Root"))
 	}
 	// Verify ShardTx Root
@@ -229,9 +264,13 @@ func (self *BlockChain) VerifyPreProcessingShardBlock(block *ShardBlock, shardID
 	if !VerifyMerkleCrossOutputCoin(block.Body.CrossOutputCoin, block.Header.CrossOutputCoinRoot) {
 		return NewBlockChainError(HashError, errors.New("Can't Verify CrossOutputCoin Root"))
 	}
+	// Verify cross shards
+	if !bytes.Equal(block.Header.CrossShards, CreateCrossShardByteArray(block.Body.Transactions)) {
+		return NewBlockChainError(HashError, errors.New("Can't Verify CrossShards"))
+	}
 	//Verify transaction
 	for _, tx := range block.Body.Transactions {
-		if !tx.ValidateTxByItself(tx.IsPrivacy(), self.config.DataBase, self, shardID) {
+		if !self.ValidateTxByItself(tx, shardID) {
 			return NewBlockChainError(TransactionError, errors.New("Can't Validate transaction"))
 		}
 	}
@@ -541,11 +580,11 @@ func VerifyMerkleCrossOutputCoin(crossOutputCoins map[byte][]CrossOutputCoin, ro
 	return newHash.IsEqual(res)
 }
 
-func (self *BlockChain) StoreIncomingCrossShard(block *ShardBlock) error {
+func (self *BlockChain) StoreIncomingCrossShard(db database.DatabaseInterface, block *ShardBlock) error {
 	crossShardMap, _ := block.Body.ExtractIncomingCrossShardMap()
 	for crossShard, crossBlks := range crossShardMap {
 		for _, crossBlk := range crossBlks {
-			self.config.DataBase.StoreIncomingCrossShard(block.Header.ShardID, crossShard, block.Header.Height, &crossBlk)
+			db.StoreIncomingCrossShard(block.Header.ShardID, crossShard, block.Header.Height, &crossBlk)
 		}
 	}
 	return nil
@@ -14,6 +14,7 @@ import (
 
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/privacy"
@@ -38,7 +39,7 @@ func (blockgen *BlkTmplGenerator) NewBlockShard(payToAddress *privacy.PaymentAdd
 	// Remove unrelated shard tx
 	// TODO: Check again Txpool should be remove after create block is successful
 	for _, tx := range txToRemove {
-		blockgen.txPool.RemoveTx(tx)
+		blockgen.txPool.RemoveTx(tx, false)
 	}
 	// Calculate coinbases
 	salaryPerTx := blockgen.rewardAgent.GetSalaryPerTx(shardID)
@@ -288,9 +289,9 @@ func FetchBeaconBlockFromHeight(db database.DatabaseInterface, from uint64, to u
 			return beaconBlocks, err
 		}
 		beaconBlock := BeaconBlock{}
-		err = json.Unmarshal(beaconBlockByte, &beaconBlock)
+		err = codec.Unmarshal(beaconBlockByte, &beaconBlock)
 		if err != nil {
-			return beaconBlocks, NewBlockChainError(UnmashallJsonBlockError, err)
+			return beaconBlocks, NewBlockChainError(BlockCodecError, err)
 		}
 		beaconBlocks = append(beaconBlocks, &beaconBlock)
 	}
@@ -299,6 +300,9 @@ func FetchBeaconBlockFromHeight(db database.DatabaseInterface, from uint64, to u
 func CreateCrossShardByteArray(txList []metadata.Transaction) (crossIDs []byte) {
 	byteMap := make([]byte, common.SHARD_NUMBER)
 	for _, tx := range txList {
+		if tx.GetProof() == nil {
+			continue
+		}
 		for _, outCoin := range tx.GetProof().OutputCoins {
 			lastByte := outCoin.CoinDetails.GetPubKeyLastByte()
 			shardID := common.GetShardIDFromLastByte(lastByte)
@@ -369,20 +373,22 @@ func CreateShardActionFromTransaction(transactions []metadata.Transaction) (acti
 
 // get valid tx for specific shard and their fee, also return unvalid tx
 func (blockgen *BlkTmplGenerator) getPendingTransaction(shardID byte) (txsToAdd []metadata.Transaction, txToRemove []metadata.Transaction, totalFee uint64) {
-	sourceTxns := blockgen.txPool.MiningDescs()
+	sourceTxns := blockgen.txPool.MiningDescs(shardID)
 
 	// get tx and wait for more if not enough
 	if len(sourceTxns) < common.MinTxsInBlock {
 		<-time.Tick(common.MinBlockWaitTime * time.Second)
-		sourceTxns = blockgen.txPool.MiningDescs()
+		sourceTxns = blockgen.txPool.MiningDescs(shardID)
 		if len(sourceTxns) == 0 {
 			<-time.Tick(common.MaxBlockWaitTime * time.Second)
-			sourceTxns = blockgen.txPool.MiningDescs()
+			sourceTxns = blockgen.txPool.MiningDescs(shardID)
 		}
 	}
 
-	//TODO: sort transaction base on fee and check limit block size
-
+	// txs come ordered by priority from pool, a tx which does not fit in the
+	// remaining block size is skipped so smaller ones behind it can be added
+	maxBlockSize := uint64(common.MaxBlockSize / 1024) // same unit as GetTxActualSize (KB)
+	blockSize := uint64(0)
 	// validate tx and calculate total fee
 	for _, txDesc := range sourceTxns {
 		tx := txDesc.Tx
@@ -390,12 +396,17 @@ func (blockgen *BlkTmplGenerator) getPendingTransaction(shardID byte) (txsToAdd
 		if txShardID != shardID {
 			continue
 		}
+		txSize := tx.GetTxActualSize()
+		if blockSize+txSize > maxBlockSize {
+			continue
+		}
 		// TODO: need to determine a tx is in privacy format or not
-		if !tx.ValidateTxByItself(tx.IsPrivacy(), blockgen.chain.config.DataBase, blockgen.chain, shardID) {
+		if !blockgen.chain.ValidateTxByItself(tx, shardID) {
 			txToRemove = append(txToRemove, metadata.Transaction(tx))
 			continue
 		}
 		totalFee += tx.GetTxFee()
+		blockSize += txSize
 		txsToAdd = append(txsToAdd, tx)
 		if len(txsToAdd) == common.MaxTxsInBlock {
 			break
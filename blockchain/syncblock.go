@@ -18,6 +18,14 @@ type PeerShardChainState struct {
 	State *ShardChainState
 	Peer  libp2p.ID
 }
+type PeerBeaconBlock struct {
+	Block *BeaconBlock
+	Peer  libp2p.ID
+}
+type PeerShardBlock struct {
+	Block *ShardBlock
+	Peer  libp2p.ID
+}
 
 type ShardChainState struct {
 	Height               uint64
@@ -47,9 +55,9 @@ func (self *BlockChain) SyncShard(shardID byte) error {
 		ShardID: shardID,
 	}
 	var shardStateCh chan *PeerShardChainState
-	var newShardBlkCh chan *ShardBlock
+	var newShardBlkCh chan *PeerShardBlock
 	shardStateCh = make(chan *PeerShardChainState)
-	newShardBlkCh = make(chan *ShardBlock)
+	newShardBlkCh = make(chan *PeerShardBlock)
 
 	self.ShardStateCh[shardID] = shardStateCh
 	self.newShardBlkCh[shardID] = &newShardBlkCh
@@ -107,23 +115,26 @@ func (self *BlockChain) SyncShard(shardID byte) error {
 					// 	}
 					// }
 				}
-			case newBlk := <-newShardBlkCh:
+			case peerBlk := <-newShardBlkCh:
 				fmt.Println("Shard block received")
+				newBlk := peerBlk.Block
 				if self.BestState.Shard[shardID].ShardHeight < newBlk.Header.Height {
 					blkHash := newBlk.Header.Hash()
 					err := cashec.ValidateDataB58(newBlk.Header.Producer, newBlk.ProducerSig, blkHash.GetBytes())
 					if err != nil {
 						Logger.log.Error(err)
+						self.rejectBlock(peerBlk.Peer, NewBlockChainError(SignatureError, err))
 						continue
 					} else {
 						if self.BestState.Shard[shardID].ShardHeight == newBlk.Header.Height-1 {
-							err = self.InsertShardBlock(newBlk)
+							err = self.ProcessShardBlock(newBlk)
 							if err != nil {
 								Logger.log.Error(err)
+								self.rejectBlock(peerBlk.Peer, err)
 								continue
 							}
-						} else {
-							self.config.NodeShardPool.PushBlock(*newBlk)
+						} else if err := self.config.NodeShardPool.PushBlock(*newBlk); err != nil {
+							Logger.log.Error(err)
 						}
 					}
 				}
@@ -139,7 +150,7 @@ func (self *BlockChain) SyncShard(shardID byte) error {
 							continue
 						}
 						for _, newBlk := range blks {
-							err = self.InsertShardBlock(&newBlk)
+							err = self.ProcessShardBlock(&newBlk)
 							if err != nil {
 								Logger.log.Error(err)
 								continue
@@ -169,7 +180,7 @@ func (self *BlockChain) SyncBeacon() error {
 	}
 	Logger.log.Info("Beacon synchronzation started")
 	self.BeaconStateCh = make(chan *PeerBeaconChainState)
-	self.newBeaconBlkCh = make(chan *BeaconBlock)
+	self.newBeaconBlkCh = make(chan *PeerBeaconBlock)
 	self.knownChainState.Beacon.Height = self.BestState.Beacon.BeaconHeight
 	self.syncStatus.Beacon = true
 
@@ -238,23 +249,26 @@ func (self *BlockChain) SyncBeacon() error {
 						}
 					}
 				}
-			case newBlk := <-self.newBeaconBlkCh:
+			case peerBlk := <-self.newBeaconBlkCh:
 				fmt.Println("Beacon block received")
+				newBlk := peerBlk.Block
 				if self.BestState.Beacon.BeaconHeight < newBlk.Header.Height {
 					blkHash := newBlk.Header.Hash()
 					err := cashec.ValidateDataB58(newBlk.Header.Producer, newBlk.ProducerSig, blkHash.GetBytes())
 					if err != nil {
 						Logger.log.Error(err)
+						self.rejectBlock(peerBlk.Peer, NewBlockChainError(SignatureError, err))
 						continue
 					} else {
 						if self.BestState.Beacon.BeaconHeight == newBlk.Header.Height-1 {
-							err = self.InsertBeaconBlock(newBlk)
+							err = self.ProcessBeaconBlock(newBlk)
 							if err != nil {
 								Logger.log.Error(err)
+								self.rejectBlock(peerBlk.Peer, err)
 								continue
 							}
-						} else {
-							self.config.NodeBeaconPool.PushBlock(*newBlk)
+						} else if err := self.config.NodeBeaconPool.PushBlock(*newBlk); err != nil {
+							Logger.log.Error(err)
 						}
 					}
 				}
@@ -270,7 +284,7 @@ func (self *BlockChain) SyncBeacon() error {
 							continue
 						}
 						for _, newBlk := range blks {
-							err = self.InsertBeaconBlock(&newBlk)
+							err = self.ProcessBeaconBlock(&newBlk)
 							if err != nil {
 								Logger.log.Error(err)
 								continue
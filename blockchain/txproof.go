@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+/*
+TxMerkleProof - proof that a transaction is included in a shard block
+Path holds the sibling of each node from the tx leaf up to Header.TxRoot,
+Index is the position of the tx in the block and selects the side of each
+sibling. Header and BlockHash let the verifier check the proof without
+trusting the node which made it
+*/
+type TxMerkleProof struct {
+	BlockHash common.Hash
+	Header    ShardHeader
+	TxHash    common.Hash
+	Index     int
+	Path      []common.Hash
+}
+
+/*
+GetMerklePath - return the merkle path of leaf at index from a tree built by
+BuildMerkleTreeStore, a missing right sibling is replaced by the node itself
+as the tree hashes a lone left child with itself
+*/
+func (self Merkle) GetMerklePath(merkles []*common.Hash, index int) ([]common.Hash, error) {
+	width := (len(merkles) + 1) / 2
+	if index < 0 || index >= width || merkles[index] == nil {
+		return nil, errors.New("leaf index " + strconv.Itoa(index) + " is out of merkle tree")
+	}
+	path := []common.Hash{}
+	offset := 0
+	for ; width > 1; width /= 2 {
+		node := merkles[offset+index]
+		sibling := merkles[offset+(index^1)]
+		if sibling == nil {
+			sibling = node
+		}
+		path = append(path, *sibling)
+		offset += width
+		index /= 2
+	}
+	return path, nil
+}
+
+/*
+GetTxMerkleProof - build inclusion proof of a transaction under TxRoot of its shard block
+*/
+func (self *BlockChain) GetTxMerkleProof(txHash *common.Hash) (*TxMerkleProof, error) {
+	blockHash, index, dbErr := self.config.DataBase.GetTransactionIndexById(txHash)
+	if dbErr != nil {
+		return nil, NewBlockChainError(DBError, dbErr)
+	}
+	block, err := self.GetShardBlockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(block.Body.Transactions) {
+		return nil, NewBlockChainError(MerkleProofError, errors.New("tx index is out of block"))
+	}
+	merkles := Merkle{}.BuildMerkleTreeStore(block.Body.Transactions)
+	path, err := Merkle{}.GetMerklePath(merkles, index)
+	if err != nil {
+		return nil, NewBlockChainError(MerkleProofError, err)
+	}
+	return &TxMerkleProof{
+		BlockHash: *blockHash,
+		Header:    block.Header,
+		TxHash:    *block.Body.Transactions[index].Hash(),
+		Index:     index,
+		Path:      path,
+	}, nil
+}
+
+/*
+VerifyTxMerkleProof - verify a tx inclusion proof offline
+- Header hash must be BlockHash and commit to TxRoot (FullHeaderHashVersion)
+- Path folded from TxHash by Index must give Header.TxRoot
+Caller still need to check BlockHash is in the chain it trusts
+*/
+func VerifyTxMerkleProof(proof *TxMerkleProof) error {
+	if proof == nil {
+		return NewBlockChainError(MerkleProofError, errors.New("proof is empty"))
+	}
+	if proof.Header.Version < FullHeaderHashVersion {
+		return NewBlockChainError(MerkleProofError, errors.New("header hash of version "+strconv.Itoa(proof.Header.Version)+" does not commit to tx root"))
+	}
+	headerHash := proof.Header.Hash()
+	if !headerHash.IsEqual(&proof.BlockHash) {
+		return NewBlockChainError(MerkleProofError, errors.New("header does not match block hash"))
+	}
+	if proof.Index < 0 || len(proof.Path) >= strconv.IntSize-1 || proof.Index >= 1<<uint(len(proof.Path)) {
+		return NewBlockChainError(MerkleProofError, errors.New("tx index does not match merkle path"))
+	}
+	hash := &proof.TxHash
+	index := proof.Index
+	for i := range proof.Path {
+		if index%2 == 0 {
+			hash = Merkle{}.hashMerkleBranches(hash, &proof.Path[i])
+		} else {
+			// only a lone left child is hashed with itself, a right one
+			// equal to its sibling would prove a position not in the block
+			if hash.IsEqual(&proof.Path[i]) {
+				return NewBlockChainError(MerkleProofError, errors.New("merkle path has a duplicated right node"))
+			}
+			hash = Merkle{}.hashMerkleBranches(&proof.Path[i], hash)
+		}
+		index /= 2
+	}
+	if !hash.IsEqual(&proof.Header.TxRoot) {
+		return NewBlockChainError(MerkleProofError, errors.New("merkle path does not lead to tx root"))
+	}
+	return nil
+}
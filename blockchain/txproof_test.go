@@ -0,0 +1,114 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+func testTxs(count int) []metadata.Transaction {
+	txs := []metadata.Transaction{}
+	for i := 0; i < count; i++ {
+		txs = append(txs, &transaction.Tx{LockTime: int64(i)})
+	}
+	return txs
+}
+
+// testTxProof builds the proof of tx at index in a block holding txs
+func testTxProof(t *testing.T, txs []metadata.Transaction, index int) *TxMerkleProof {
+	merkles := Merkle{}.BuildMerkleTreeStore(txs)
+	path, err := Merkle{}.GetMerklePath(merkles, index)
+	if err != nil {
+		t.Fatalf("GetMerklePath %d of %d %+v", index, len(txs), err)
+	}
+	header := ShardHeader{
+		Version: FullHeaderHashVersion,
+		Height:  2,
+		TxRoot:  *merkles[len(merkles)-1],
+	}
+	return &TxMerkleProof{
+		BlockHash: header.Hash(),
+		Header:    header,
+		TxHash:    *txs[index].Hash(),
+		Index:     index,
+		Path:      path,
+	}
+}
+
+func TestTxMerkleProof(t *testing.T) {
+	// odd counts have a lone left child hashed with itself at some level
+	for count := 1; count <= 9; count++ {
+		txs := testTxs(count)
+		for index := 0; index < count; index++ {
+			if err := VerifyTxMerkleProof(testTxProof(t, txs, index)); err != nil {
+				t.Fatalf("proof of tx %d of %d should verify %+v", index, count, err)
+			}
+		}
+	}
+}
+
+func TestGetMerklePathOutOfTree(t *testing.T) {
+	merkles := Merkle{}.BuildMerkleTreeStore(testTxs(5))
+	for _, index := range []int{-1, 5, 8} {
+		if _, err := (Merkle{}).GetMerklePath(merkles, index); err == nil {
+			t.Fatalf("leaf %d is not in a tree of 5 txs", index)
+		}
+	}
+}
+
+func TestTxMerkleProofWrongIndex(t *testing.T) {
+	txs := testTxs(6)
+	proof := testTxProof(t, txs, 2)
+	for _, index := range []int{0, 1, 3, 5, 8, -1} {
+		proof.Index = index
+		if err := VerifyTxMerkleProof(proof); err == nil {
+			t.Fatalf("proof of tx 2 should not verify at index %d", index)
+		}
+	}
+}
+
+// the last tx of an odd block has itself as sibling, the same path must not
+// prove a position after it
+func TestTxMerkleProofLastIndex(t *testing.T) {
+	txs := testTxs(5)
+	proof := testTxProof(t, txs, 4)
+	if proof.Path[0] != proof.TxHash {
+		t.Fatalf("lone left leaf should be its own sibling")
+	}
+	proof.Index = 5
+	if err := VerifyTxMerkleProof(proof); err == nil {
+		t.Fatalf("proof of the last tx should not verify past the block")
+	}
+}
+
+func TestTxMerkleProofTampered(t *testing.T) {
+	txs := testTxs(7)
+	for level := range testTxProof(t, txs, 3).Path {
+		proof := testTxProof(t, txs, 3)
+		proof.Path[level] = common.HashH([]byte("tampered"))
+		if err := VerifyTxMerkleProof(proof); err == nil {
+			t.Fatalf("proof with a tampered sibling at level %d should not verify", level)
+		}
+	}
+
+	proof := testTxProof(t, txs, 3)
+	proof.TxHash = *txs[4].Hash()
+	if err := VerifyTxMerkleProof(proof); err == nil {
+		t.Fatalf("proof should not verify another tx")
+	}
+
+	proof = testTxProof(t, txs, 3)
+	proof.Header.TxRoot = common.HashH([]byte("root"))
+	if err := VerifyTxMerkleProof(proof); err == nil {
+		t.Fatalf("header not matching block hash should not verify")
+	}
+
+	proof = testTxProof(t, txs, 3)
+	proof.Header.Version = FullHeaderHashVersion - 1
+	proof.BlockHash = proof.Header.Hash()
+	if err := VerifyTxMerkleProof(proof); err == nil {
+		t.Fatalf("legacy header does not commit to tx root and should not verify")
+	}
+}
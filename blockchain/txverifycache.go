@@ -0,0 +1,105 @@
+package blockchain
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+/*
+txVerifyCache - LRU of txs whose signature and proof are verified
+Entries are keyed by tx hash. Tx hash does not commit to signature and some
+other fields, so every entry also keeps a digest of the whole encoded tx and
+the verify params, a tx is only a hit when the digest is the same
+*/
+type txVerifyCache struct {
+	mtx     sync.Mutex
+	max     int
+	entries map[common.Hash]*list.Element
+	order   *list.List // front is the most recently used
+}
+
+type txVerifyCacheEntry struct {
+	txHash common.Hash
+	digest common.Hash
+}
+
+func newTxVerifyCache(max int) *txVerifyCache {
+	return &txVerifyCache{
+		max:     max,
+		entries: make(map[common.Hash]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// txVerifyDigest returns digest of encoded tx and verify params, false when
+// tx can not be encoded so it is never cached
+func txVerifyDigest(tx metadata.Transaction, hasPrivacy bool, shardID byte) (common.Hash, bool) {
+	data, err := codec.Marshal(struct {
+		Tx         metadata.Transaction
+		HasPrivacy bool
+		ShardID    byte
+	}{tx, hasPrivacy, shardID})
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return common.DoubleHashH(data), true
+}
+
+func (self *txVerifyCache) contains(txHash common.Hash, digest common.Hash) bool {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	element, ok := self.entries[txHash]
+	if !ok || element.Value.(*txVerifyCacheEntry).digest != digest {
+		return false
+	}
+	self.order.MoveToFront(element)
+	return true
+}
+
+func (self *txVerifyCache) add(txHash common.Hash, digest common.Hash) {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	if element, ok := self.entries[txHash]; ok {
+		element.Value.(*txVerifyCacheEntry).digest = digest
+		self.order.MoveToFront(element)
+		return
+	}
+	self.entries[txHash] = self.order.PushFront(&txVerifyCacheEntry{txHash: txHash, digest: digest})
+	for self.order.Len() > self.max {
+		oldest := self.order.Back()
+		self.order.Remove(oldest)
+		delete(self.entries, oldest.Value.(*txVerifyCacheEntry).txHash)
+	}
+}
+
+func (self *txVerifyCache) reset() {
+	self.mtx.Lock()
+	defer self.mtx.Unlock()
+	self.entries = make(map[common.Hash]*list.Element)
+	self.order.Init()
+}
+
+/*
+ValidateTxByItself - verify signature and proof of tx (tx.ValidateTxByItself)
+A tx which passes is cached, so a tx verified when entering mempool is not
+verified again when its block is produced or validated
+*/
+func (self *BlockChain) ValidateTxByItself(tx metadata.Transaction, shardID byte) bool {
+	hasPrivacy := tx.IsPrivacy()
+	txHash := *tx.Hash()
+	digest, cacheable := txVerifyDigest(tx, hasPrivacy, shardID)
+	if cacheable && self.txVerifyCache.contains(txHash, digest) {
+		return true
+	}
+	if !tx.ValidateTxByItself(hasPrivacy, self.config.DataBase, self, shardID) {
+		return false
+	}
+	if cacheable {
+		self.txVerifyCache.add(txHash, digest)
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+func TestTxVerifyCacheEviction(t *testing.T) {
+	cache := newTxVerifyCache(2)
+	hashes := []common.Hash{common.HashH([]byte{1}), common.HashH([]byte{2}), common.HashH([]byte{3})}
+	digest := common.HashH([]byte("digest"))
+	cache.add(hashes[0], digest)
+	cache.add(hashes[1], digest)
+	// a hit makes the first tx the most recently used one
+	if !cache.contains(hashes[0], digest) {
+		t.Fatalf("cached tx should be a hit")
+	}
+	cache.add(hashes[2], digest)
+	if cache.contains(hashes[1], digest) {
+		t.Fatalf("least recently used tx should be evicted")
+	}
+	if !cache.contains(hashes[0], digest) || !cache.contains(hashes[2], digest) {
+		t.Fatalf("recently used txs should be kept")
+	}
+	if cache.order.Len() != 2 || len(cache.entries) != 2 {
+		t.Fatalf("cache should hold 2 txs, got %d %d", cache.order.Len(), len(cache.entries))
+	}
+}
+
+// a tx with the same hash but another signature is not a hit
+func TestTxVerifyCacheDigest(t *testing.T) {
+	tx := &transaction.Tx{Type: common.TxNormalType, LockTime: 1, Sig: []byte{1}}
+	forged := &transaction.Tx{Type: common.TxNormalType, LockTime: 1, Sig: []byte{2}}
+	if *tx.Hash() != *forged.Hash() {
+		t.Fatalf("tx hash should not cover the signature")
+	}
+	digest, ok := txVerifyDigest(tx, false, 0)
+	if !ok {
+		t.Fatalf("tx should be cacheable")
+	}
+	forgedDigest, _ := txVerifyDigest(forged, false, 0)
+	otherShardDigest, _ := txVerifyDigest(tx, false, 1)
+	if digest == forgedDigest || digest == otherShardDigest {
+		t.Fatalf("digest should cover signature and verify params")
+	}
+
+	cache := newTxVerifyCache(10)
+	cache.add(*tx.Hash(), digest)
+	if cache.contains(*forged.Hash(), forgedDigest) {
+		t.Fatalf("tx with another signature should not be a hit")
+	}
+	if !cache.contains(*tx.Hash(), digest) {
+		t.Fatalf("verified tx should be a hit")
+	}
+}
+
+func TestTxVerifyCacheReset(t *testing.T) {
+	cache := newTxVerifyCache(10)
+	txHash := common.HashH([]byte{1})
+	digest := common.HashH([]byte("digest"))
+	cache.add(txHash, digest)
+	cache.reset()
+	if cache.contains(txHash, digest) || cache.order.Len() != 0 {
+		t.Fatalf("reset should drop every tx")
+	}
+	cache.add(txHash, digest)
+	if !cache.contains(txHash, digest) {
+		t.Fatalf("cache should be usable after reset")
+	}
+}
+
+func TestCheckConsistencyRepairResetsTxVerifyCache(t *testing.T) {
+	chain := newTestChain(t)
+	txHash := common.HashH([]byte{1})
+	digest := common.HashH([]byte("digest"))
+	chain.txVerifyCache.add(txHash, digest)
+
+	// nothing to repair keeps the cache
+	checkConsistency(t, chain, true)
+	if !chain.txVerifyCache.contains(txHash, digest) {
+		t.Fatalf("check of a consistent chain should keep the cache")
+	}
+
+	wrongHash := common.HashH([]byte("wrong"))
+	if err := chain.config.DataBase.StoreBeaconBlockIndex(&wrongHash, 1); err != nil {
+		t.Fatalf("StoreBeaconBlockIndex %+v", err)
+	}
+	if report := checkConsistency(t, chain, true); report.Category(BlockIndexCategory).Repaired != 1 {
+		t.Fatalf("damaged index should be repaired\n%s", report.String())
+	}
+	if chain.txVerifyCache.contains(txHash, digest) {
+		t.Fatalf("repair should reset the cache")
+	}
+}
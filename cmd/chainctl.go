@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/database"
+	_ "github.com/ninjadotorg/constant/database/lvdb"
+	"github.com/ninjadotorg/constant/mempool"
+)
+
+// loadChain opens the block database of a node, which must not be running
+func loadChain() (*blockchain.BlockChain, database.DatabaseInterface, error) {
+	db, err := database.Open("leveldb", filepath.Join(cfg.DataDir, cfg.DatabaseDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	chainParams := &blockchain.ChainMainParam
+	if cfg.TestNet {
+		chainParams = &blockchain.ChainTestParam
+	}
+	chain := &blockchain.BlockChain{}
+	err = chain.Init(&blockchain.Config{
+		ChainParams:       chainParams,
+		DataBase:          db,
+		NodeBeaconPool:    mempool.NewNodeBeaconPool(mempool.DefaultNodeBlockPoolConfig),
+		NodeShardPool:     mempool.NewNodeShardPool(mempool.DefaultNodeBlockPoolConfig),
+		ShardToBeaconPool: mempool.NewShardToBeaconPool(mempool.DefaultShardToBeaconPoolConfig),
+		CrossShardPool:    mempool.NewCrossShardPool(mempool.DefaultCrossShardPoolConfig),
+		OrphanBlockPool:   mempool.NewOrphanBlockPool(mempool.DefaultOrphanBlockPoolConfig),
+	})
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	chain.SetShardToBeaconPool(db)
+	return chain, db, nil
+}
+
+func exportChain() error {
+	chain, db, err := loadChain()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	file, err := os.Create(cfg.ChainFile)
+	if err != nil {
+		return err
+	}
+	beaconCount, shardCount, err := chain.ExportChain(file, cfg.FromHeight, cfg.ToHeight)
+	if err != nil {
+		file.Close()
+		os.Remove(cfg.ChainFile)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	log.Printf("Exported %d beacon blocks and %d shard blocks to %s", beaconCount, shardCount, cfg.ChainFile)
+	return nil
+}
+
+func importChain() error {
+	chain, db, err := loadChain()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	file, err := os.Open(cfg.ChainFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	beaconCount, shardCount, err := chain.ImportChain(file)
+	log.Printf("Imported %d beacon blocks and %d shard blocks from %s", beaconCount, shardCount, cfg.ChainFile)
+	return err
+}
+
+func checkChain() error {
+	chain, db, err := loadChain()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := chain.CheckConsistency(cfg.Repair)
+	if err != nil {
+		return err
+	}
+	log.Printf("Consistency report\n%s", report.String())
+	if !report.IsConsistent() {
+		return errors.New("chain store is not consistent")
+	}
+	return nil
+}
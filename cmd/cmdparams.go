@@ -11,9 +11,10 @@ import (
 )
 
 const (
-	defaultConfigFilename = "params.conf"
-	defaultDataDirname    = "data"
-	defaultLogDirname     = "logs"
+	defaultConfigFilename  = "params.conf"
+	defaultDataDirname     = "data"
+	defaultLogDirname      = "logs"
+	defaultDatabaseDirname = "block"
 )
 
 var (
@@ -33,6 +34,15 @@ type params struct {
 	WalletName        string `long:"wallet" description:"Wallet Database Name file, default is 'wallet'"`
 	WalletPassphrase  string `long:"walletpassphrase" description:"Wallet passphrase"`
 	WalletAccountName string `long:"walletaccountname" description:"Wallet account name"`
+
+	// For chain export/import
+	DatabaseDir string `long:"datapre" description:"Database dir, relative to datadir"`
+	ChainFile   string `long:"chainfile" description:"File to export blocks to or import blocks from"`
+	FromHeight  uint64 `long:"fromheight" description:"First beacon block height to export, default is 1"`
+	ToHeight    uint64 `long:"toheight" description:"Last beacon block height to export, default is the best block"`
+
+	// For chain check
+	Repair bool `long:"repair" description:"Rewrite damaged block and tx index entries found by checkchain"`
 }
 
 // newConfigParser returns a new command line flags parser.
@@ -43,8 +53,10 @@ func newConfigParser(cfg *params, options flags.Options) *flags.Parser {
 
 func loadParams() (*params, error) {
 	cfg := params{
-		DataDir: defaultDataDir,
-		TestNet: false,
+		DataDir:     defaultDataDir,
+		TestNet:     false,
+		DatabaseDir: defaultDatabaseDirname,
+		FromHeight:  1,
 	}
 
 	preParser := newConfigParser(&cfg, flags.HelpFlag)
@@ -91,6 +91,38 @@ func main() {
 				}
 				log.Println(string(result))
 			}
+		case ExportChainCmd:
+			{
+				if cfg.ChainFile == "" {
+					log.Println("Wrong param")
+					return
+				}
+				err := exportChain()
+				if err != nil {
+					log.Println(err)
+					return
+				}
+			}
+		case ImportChainCmd:
+			{
+				if cfg.ChainFile == "" {
+					log.Println("Wrong param")
+					return
+				}
+				err := importChain()
+				if err != nil {
+					log.Println(err)
+					return
+				}
+			}
+		case CheckChainCmd:
+			{
+				err := checkChain()
+				if err != nil {
+					log.Println(err)
+					return
+				}
+			}
 		}
 	} else {
 		log.Println("Parse params error", err.Error())
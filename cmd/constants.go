@@ -5,6 +5,9 @@ const (
 	ListWalletAccountCmd   = "listaccounts"
 	GetWalletAccountCmd    = "getaccount"
 	CreateWalletAccountCmd = "createaccount"
+	ExportChainCmd         = "exportchain"
+	ImportChainCmd         = "importchain"
+	CheckChainCmd          = "checkchain"
 )
 
-var CmdList = []string{CreateWalletCmd, ListWalletAccountCmd, GetWalletAccountCmd, CreateWalletAccountCmd}
+var CmdList = []string{CreateWalletCmd, ListWalletAccountCmd, GetWalletAccountCmd, CreateWalletAccountCmd, ExportChainCmd, ImportChainCmd, CheckChainCmd}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/database"
+	"github.com/ninjadotorg/constant/mempool"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/transaction"
+	"github.com/ninjadotorg/constant/wallet"
+)
+
+var (
+	backendLog        = common.NewBackend(os.Stdout)
+	dbLogger          = backendLog.Logger("Database Log")
+	walletLogger      = backendLog.Logger("Wallet log")
+	blockchainLogger  = backendLog.Logger("BlockChain log")
+	mempoolLogger     = backendLog.Logger("Mempool log")
+	transactionLogger = backendLog.Logger("Transaction log")
+	privacyLogger     = backendLog.Logger("Privacy log")
+)
+
+func init() {
+	// packages used by the commands log through these
+	database.Logger.Init(dbLogger)
+	wallet.Logger.Init(walletLogger)
+	blockchain.Logger.Init(blockchainLogger)
+	mempool.Logger.Init(mempoolLogger)
+	transaction.Logger.Init(transactionLogger)
+	privacy.Logger.Init(privacyLogger)
+}
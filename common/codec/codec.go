@@ -0,0 +1,654 @@
+/*
+Package codec - deterministic binary encoding of blocks, transactions and
+everything they carry, used for storage and for the p2p messages (json is
+kept for rpc output).
+
+Every encoding starts with the codec Version, followed by the value:
+  - bool: 1 byte, 0 or 1
+  - uint8: 1 byte; other unsigned integers: uvarint; signed integers: zigzag varint
+  - float32/float64: 4/8 bytes big endian IEEE 754
+  - string, []byte: uvarint length + bytes
+  - byte arrays: raw bytes; other arrays: every element
+  - slices: uvarint length + every element, nil and empty slices are the same
+  - maps: uvarint length + key/value pairs sorted by the encoding of the key,
+    nil and empty maps are the same
+  - pointers: 1 byte presence flag + value
+  - structs: every exported field in declaration order, unexported fields are skipped
+  - big.Int: sign byte (0 zero, 1 positive, 2 negative) + magnitude as a []byte
+  - types implementing encoding.BinaryMarshaler and BinaryUnmarshaler
+    (on the pointer receiver): their MarshalBinary output as a []byte
+  - interfaces registered with RegisterInterface: 1 byte presence flag,
+    tag of the concrete type as a string and the concrete value
+
+Decoding only accepts the encoding Marshal produces, so that a value has
+exactly one valid encoding.
+*/
+package codec
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Version of the encoding, written as the first byte
+const Version = byte(1)
+
+const (
+	bigIntZero     = byte(0)
+	bigIntPositive = byte(1)
+	bigIntNegative = byte(2)
+)
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	bigIntType            = reflect.TypeOf(big.Int{})
+)
+
+type interfaceCodec struct {
+	tag    func(value interface{}) (string, error)
+	create func(tag string) (interface{}, error)
+}
+
+var (
+	interfacesMtx sync.RWMutex
+	interfaces    = map[reflect.Type]*interfaceCodec{}
+)
+
+/*
+RegisterInterface - make the values of an interface type encodable.
+iface is a nil pointer to the interface, e.g. (*metadata.Metadata)(nil).
+tag returns a stable name for the concrete type of a value and create
+returns a new value (usually a pointer to a zero struct) for a tag.
+*/
+func RegisterInterface(iface interface{}, tag func(value interface{}) (string, error), create func(tag string) (interface{}, error)) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic("codec: RegisterInterface expects a nil pointer to an interface")
+	}
+	interfacesMtx.Lock()
+	defer interfacesMtx.Unlock()
+	if _, ok := interfaces[t.Elem()]; ok {
+		panic(fmt.Sprintf("codec: interface %s registered twice", t.Elem()))
+	}
+	interfaces[t.Elem()] = &interfaceCodec{tag: tag, create: create}
+}
+
+func getInterfaceCodec(t reflect.Type) (*interfaceCodec, error) {
+	interfacesMtx.RLock()
+	defer interfacesMtx.RUnlock()
+	ifaceCodec, ok := interfaces[t]
+	if !ok {
+		return nil, fmt.Errorf("codec: interface %s is not registered", t)
+	}
+	return ifaceCodec, nil
+}
+
+// Marshal returns the encoding of v, or of the value v points to
+func Marshal(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return nil, errors.New("codec: can not marshal nil")
+	}
+	buf := &bytes.Buffer{}
+	buf.WriteByte(Version)
+	if err := encodeValue(buf, rv); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data into v, which must be a non nil pointer
+func Unmarshal(data []byte, v interface{}) (err error) {
+	// data comes from peers, don't let a BinaryUnmarshaler crash the node
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("codec: %v", r)
+		}
+	}()
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("codec: unmarshal expects a non nil pointer")
+	}
+	if len(data) == 0 {
+		return errors.New("codec: empty data")
+	}
+	if data[0] != Version {
+		return fmt.Errorf("codec: unsupported version %d", data[0])
+	}
+	d := &decoder{data: data[1:]}
+	if err := d.decodeValue(rv.Elem()); err != nil {
+		return err
+	}
+	if len(d.data) != 0 {
+		return fmt.Errorf("codec: %d trailing bytes", len(d.data))
+	}
+	return nil
+}
+
+// IsEncoded tells if data looks like an encoding of this codec rather than
+// json, which always starts with a printable character
+func IsEncoded(data []byte) bool {
+	return len(data) > 0 && data[0] == Version
+}
+
+// hasBinaryMethods tells if *t implements encoding.BinaryMarshaler and BinaryUnmarshaler
+func hasBinaryMethods(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface {
+		return false
+	}
+	pt := reflect.PtrTo(t)
+	return pt.Implements(binaryMarshalerType) && pt.Implements(binaryUnmarshalerType)
+}
+
+var fieldsCache sync.Map // reflect.Type -> []int
+
+// encodedFields returns the indexes of the exported fields of a struct type
+func encodedFields(t reflect.Type) []int {
+	if fields, ok := fieldsCache.Load(t); ok {
+		return fields.([]int)
+	}
+	fields := []int{}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			fields = append(fields, i)
+		}
+	}
+	fieldsCache.Store(t, fields)
+	return fields
+}
+
+func writeUvarint(buf *bytes.Buffer, x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	buf.Write(tmp[:n])
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	writeUvarint(buf, uint64(len(b)))
+	buf.Write(b)
+}
+
+func encodeValue(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+	if (t == bigIntType || hasBinaryMethods(t)) && !v.CanAddr() {
+		copied := reflect.New(t)
+		copied.Elem().Set(v)
+		v = copied.Elem()
+	}
+	if t == bigIntType {
+		x := v.Addr().Interface().(*big.Int)
+		switch x.Sign() {
+		case 0:
+			buf.WriteByte(bigIntZero)
+			return nil
+		case 1:
+			buf.WriteByte(bigIntPositive)
+		default:
+			buf.WriteByte(bigIntNegative)
+		}
+		writeBytes(buf, x.Bytes())
+		return nil
+	}
+	if hasBinaryMethods(t) {
+		b, err := v.Addr().Interface().(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("codec: %s: %v", t, err)
+		}
+		writeBytes(buf, b)
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case reflect.Uint8:
+		buf.WriteByte(byte(v.Uint()))
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUvarint(buf, v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var tmp [binary.MaxVarintLen64]byte
+		n := binary.PutVarint(tmp[:], v.Int())
+		buf.Write(tmp[:n])
+	case reflect.Float32:
+		var tmp [4]byte
+		binary.BigEndian.PutUint32(tmp[:], math.Float32bits(float32(v.Float())))
+		buf.Write(tmp[:])
+	case reflect.Float64:
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], math.Float64bits(v.Float()))
+		buf.Write(tmp[:])
+	case reflect.String:
+		writeUvarint(buf, uint64(v.Len()))
+		buf.WriteString(v.String())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			writeBytes(buf, v.Bytes())
+			return nil
+		}
+		writeUvarint(buf, uint64(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			for i := 0; i < v.Len(); i++ {
+				buf.WriteByte(byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return encodeMap(buf, v)
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteByte(0)
+			return nil
+		}
+		buf.WriteByte(1)
+		return encodeValue(buf, v.Elem())
+	case reflect.Struct:
+		for _, i := range encodedFields(t) {
+			if err := encodeValue(buf, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		return encodeInterface(buf, v)
+	default:
+		return fmt.Errorf("codec: unsupported type %s", t)
+	}
+	return nil
+}
+
+func encodeMap(buf *bytes.Buffer, v reflect.Value) error {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keyBuf := &bytes.Buffer{}
+		if err := encodeValue(keyBuf, key); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: keyBuf.Bytes(), value: v.MapIndex(key)})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	writeUvarint(buf, uint64(len(entries)))
+	for _, e := range entries {
+		buf.Write(e.key)
+		if err := encodeValue(buf, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeInterface(buf *bytes.Buffer, v reflect.Value) error {
+	if v.IsNil() {
+		buf.WriteByte(0)
+		return nil
+	}
+	ifaceCodec, err := getInterfaceCodec(v.Type())
+	if err != nil {
+		return err
+	}
+	tag, err := ifaceCodec.tag(v.Interface())
+	if err != nil {
+		return fmt.Errorf("codec: %s: %v", v.Type(), err)
+	}
+	buf.WriteByte(1)
+	writeUvarint(buf, uint64(len(tag)))
+	buf.WriteString(tag)
+	concrete := v.Elem()
+	if concrete.Kind() == reflect.Ptr {
+		if concrete.IsNil() {
+			return fmt.Errorf("codec: %s holds a nil %s", v.Type(), concrete.Type())
+		}
+		concrete = concrete.Elem()
+	}
+	return encodeValue(buf, concrete)
+}
+
+type decoder struct {
+	data []byte
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if len(d.data) == 0 {
+		return 0, errors.New("codec: unexpected end of data")
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b, nil
+}
+
+func (d *decoder) readN(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)) {
+		return nil, errors.New("codec: unexpected end of data")
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b, nil
+}
+
+func (d *decoder) readUvarint() (uint64, error) {
+	x, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		return 0, errors.New("codec: invalid uvarint")
+	}
+	var tmp [binary.MaxVarintLen64]byte
+	if binary.PutUvarint(tmp[:], x) != n {
+		return 0, errors.New("codec: non canonical uvarint")
+	}
+	d.data = d.data[n:]
+	return x, nil
+}
+
+func (d *decoder) readVarint() (int64, error) {
+	x, n := binary.Varint(d.data)
+	if n <= 0 {
+		return 0, errors.New("codec: invalid varint")
+	}
+	var tmp [binary.MaxVarintLen64]byte
+	if binary.PutVarint(tmp[:], x) != n {
+		return 0, errors.New("codec: non canonical varint")
+	}
+	d.data = d.data[n:]
+	return x, nil
+}
+
+// readLength reads a length prefix, every element takes at least one byte
+// so a length above the remaining data can only be corrupted
+func (d *decoder) readLength() (int, error) {
+	n, err := d.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(d.data)) {
+		return 0, fmt.Errorf("codec: length %d exceeds the remaining %d bytes", n, len(d.data))
+	}
+	return int(n), nil
+}
+
+func (d *decoder) readBytes() ([]byte, error) {
+	n, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+	return d.readN(uint64(n))
+}
+
+func (d *decoder) readFlag() (bool, error) {
+	b, err := d.readByte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	}
+	return false, fmt.Errorf("codec: invalid flag %d", b)
+}
+
+func (d *decoder) decodeValue(v reflect.Value) error {
+	t := v.Type()
+	if t == bigIntType {
+		sign, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		x := v.Addr().Interface().(*big.Int)
+		if sign == bigIntZero {
+			x.SetInt64(0)
+			return nil
+		}
+		if sign != bigIntPositive && sign != bigIntNegative {
+			return fmt.Errorf("codec: invalid big.Int sign %d", sign)
+		}
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		if len(b) == 0 || b[0] == 0 {
+			return errors.New("codec: non canonical big.Int")
+		}
+		x.SetBytes(b)
+		if sign == bigIntNegative {
+			x.Neg(x)
+		}
+		return nil
+	}
+	if hasBinaryMethods(t) {
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		// the unmarshaler may keep the slice
+		b = append([]byte{}, b...)
+		if err := v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(b); err != nil {
+			return fmt.Errorf("codec: %s: %v", t, err)
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		b, err := d.readFlag()
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Uint8:
+		b, err := d.readByte()
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(b))
+	case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		x, err := d.readUvarint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(x) {
+			return fmt.Errorf("codec: %d overflows %s", x, t)
+		}
+		v.SetUint(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := d.readVarint()
+		if err != nil {
+			return err
+		}
+		if v.OverflowInt(x) {
+			return fmt.Errorf("codec: %d overflows %s", x, t)
+		}
+		v.SetInt(x)
+	case reflect.Float32:
+		b, err := d.readN(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(b))))
+	case reflect.Float64:
+		b, err := d.readN(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(binary.BigEndian.Uint64(b)))
+	case reflect.String:
+		b, err := d.readBytes()
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		n, err := d.readLength()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := d.readN(uint64(n))
+			if err != nil {
+				return err
+			}
+			s := reflect.MakeSlice(t, n, n)
+			reflect.Copy(s, reflect.ValueOf(b))
+			v.Set(s)
+			return nil
+		}
+		s := reflect.MakeSlice(t, n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decodeValue(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := d.readN(uint64(v.Len()))
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := d.decodeValue(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		return d.decodeMap(v)
+	case reflect.Ptr:
+		present, err := d.readFlag()
+		if err != nil {
+			return err
+		}
+		if !present {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		elem := reflect.New(t.Elem())
+		if err := d.decodeValue(elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Struct:
+		for _, i := range encodedFields(t) {
+			if err := d.decodeValue(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		return d.decodeInterface(v)
+	default:
+		return fmt.Errorf("codec: unsupported type %s", t)
+	}
+	return nil
+}
+
+func (d *decoder) decodeMap(v reflect.Value) error {
+	t := v.Type()
+	n, err := d.readLength()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		v.Set(reflect.Zero(t))
+		return nil
+	}
+	m := reflect.MakeMapWithSize(t, n)
+	var prevKey []byte
+	for i := 0; i < n; i++ {
+		start := d.data
+		key := reflect.New(t.Key()).Elem()
+		if err := d.decodeValue(key); err != nil {
+			return err
+		}
+		keyBytes := start[:len(start)-len(d.data)]
+		if i > 0 && bytes.Compare(prevKey, keyBytes) >= 0 {
+			return errors.New("codec: map keys are not sorted")
+		}
+		prevKey = keyBytes
+		value := reflect.New(t.Elem()).Elem()
+		if err := d.decodeValue(value); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, value)
+	}
+	v.Set(m)
+	return nil
+}
+
+func (d *decoder) decodeInterface(v reflect.Value) error {
+	present, err := d.readFlag()
+	if err != nil {
+		return err
+	}
+	if !present {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	ifaceCodec, err := getInterfaceCodec(v.Type())
+	if err != nil {
+		return err
+	}
+	tag, err := d.readBytes()
+	if err != nil {
+		return err
+	}
+	created, err := ifaceCodec.create(string(tag))
+	if err != nil {
+		return fmt.Errorf("codec: %s: %v", v.Type(), err)
+	}
+	concrete := reflect.ValueOf(created)
+	if !concrete.IsValid() || !concrete.Type().Implements(v.Type()) {
+		return fmt.Errorf("codec: tag %q does not create a %s", tag, v.Type())
+	}
+	if concrete.Kind() == reflect.Ptr {
+		if concrete.IsNil() {
+			return fmt.Errorf("codec: tag %q creates a nil %s", tag, concrete.Type())
+		}
+		if err := d.decodeValue(concrete.Elem()); err != nil {
+			return err
+		}
+	} else {
+		elem := reflect.New(concrete.Type()).Elem()
+		if err := d.decodeValue(elem); err != nil {
+			return err
+		}
+		concrete = elem
+	}
+	v.Set(concrete)
+	return nil
+}
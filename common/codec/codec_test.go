@@ -0,0 +1,381 @@
+package codec_test
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/privacy/zeroknowledge"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+const fuzzRounds = 200
+
+type inner struct {
+	Name  string
+	Value *big.Int
+}
+
+type sample struct {
+	Flag     bool
+	Small    int8
+	Signed   int64
+	Unsigned uint64
+	Byte     byte
+	Float    float64
+	Text     string
+	Raw      []byte
+	Hash     common.Hash
+	Numbers  []int
+	Nested   [][]uint32
+	Pairs    map[string]int
+	ByNumber map[int32][]byte
+	Pointer  *inner
+	Inners   []inner
+	hidden   int
+}
+
+var (
+	bigIntType        = reflect.TypeOf(big.Int{})
+	pointType         = reflect.TypeOf(privacy.EllipticPoint{})
+	ciphertextType    = reflect.TypeOf(privacy.Ciphertext{})
+	paymentProofType  = reflect.TypeOf(zkp.PaymentProof{})
+	metadataType      = reflect.TypeOf((*metadata.Metadata)(nil)).Elem()
+	transactionType   = reflect.TypeOf((*metadata.Transaction)(nil)).Elem()
+	metadataTypeRange = 200
+)
+
+// fill sets v to random data, the same way for every run of a seed
+func fill(t *testing.T, r *rand.Rand, v reflect.Value, depth int) {
+	typ := v.Type()
+	switch typ {
+	case bigIntType:
+		x := new(big.Int).SetUint64(r.Uint64())
+		x.Lsh(x, uint(r.Intn(128)))
+		if r.Intn(2) == 0 {
+			x.Neg(x)
+		}
+		v.Set(reflect.ValueOf(*x))
+		return
+	case pointType:
+		point := new(privacy.EllipticPoint)
+		point.Randomize()
+		v.Set(reflect.ValueOf(*point))
+		return
+	case ciphertextType:
+		data := make([]byte, 67+r.Intn(64))
+		r.Read(data)
+		if err := v.Addr().Interface().(*privacy.Ciphertext).UnmarshalBinary(data); err != nil {
+			t.Fatalf("Ciphertext.UnmarshalBinary %+v", err)
+		}
+		return
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x := int64(r.Uint64())
+		v.SetInt(x >> uint(64-typ.Bits()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(r.Uint64() >> uint(64-typ.Bits()))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.NormFloat64())
+	case reflect.String:
+		data := make([]byte, r.Intn(10))
+		r.Read(data)
+		v.SetString(string(data))
+	case reflect.Slice:
+		n := r.Intn(4)
+		if n == 0 || depth == 0 {
+			return
+		}
+		s := reflect.MakeSlice(typ, n, n)
+		for i := 0; i < n; i++ {
+			fill(t, r, s.Index(i), depth-1)
+		}
+		v.Set(s)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(t, r, v.Index(i), depth-1)
+		}
+	case reflect.Map:
+		n := r.Intn(4)
+		if n == 0 || depth == 0 {
+			return
+		}
+		m := reflect.MakeMap(typ)
+		for i := 0; i < n; i++ {
+			key := reflect.New(typ.Key()).Elem()
+			fill(t, r, key, depth-1)
+			value := reflect.New(typ.Elem()).Elem()
+			fill(t, r, value, depth-1)
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Ptr:
+		// a valid proof can not be made up from random data
+		if depth == 0 || r.Intn(4) == 0 || typ.Elem() == paymentProofType {
+			return
+		}
+		p := reflect.New(typ.Elem())
+		fill(t, r, p.Elem(), depth-1)
+		v.Set(p)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if typ.Field(i).PkgPath == "" {
+				fill(t, r, v.Field(i), depth-1)
+			}
+		}
+	case reflect.Interface:
+		if depth == 0 || r.Intn(4) == 0 {
+			return
+		}
+		switch typ {
+		case metadataType:
+			v.Set(reflect.ValueOf(randomMetadata(t, r, depth-1)))
+		case transactionType:
+			v.Set(reflect.ValueOf(randomTransaction(t, r, depth-1)))
+		}
+	}
+}
+
+func allMetadataTypes() []int {
+	types := []int{}
+	for metaType := 0; metaType < metadataTypeRange; metaType++ {
+		if _, err := metadata.NewMetadata(metaType); err == nil {
+			types = append(types, metaType)
+		}
+	}
+	return types
+}
+
+// setMetaType sets the Type fields of a metadata, its tag
+func setMetaType(v reflect.Value, metaType int) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Name == "Type" && field.Type.Kind() == reflect.Int {
+			v.Field(i).SetInt(int64(metaType))
+		} else if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			setMetaType(v.Field(i), metaType)
+		} else if field.Anonymous && field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			if v.Field(i).IsNil() {
+				v.Field(i).Set(reflect.New(field.Type.Elem()))
+			}
+			setMetaType(v.Field(i).Elem(), metaType)
+		}
+	}
+}
+
+func newRandomMetadata(t *testing.T, r *rand.Rand, metaType int, depth int) metadata.Metadata {
+	md, err := metadata.NewMetadata(metaType)
+	if err != nil {
+		t.Fatalf("metadata.NewMetadata(%d) %+v", metaType, err)
+	}
+	fill(t, r, reflect.ValueOf(md).Elem(), depth)
+	setMetaType(reflect.ValueOf(md).Elem(), metaType)
+	if md.GetType() != metaType {
+		t.Fatalf("%T of type %d reports type %d", md, metaType, md.GetType())
+	}
+	return md
+}
+
+func randomMetadata(t *testing.T, r *rand.Rand, depth int) metadata.Metadata {
+	types := allMetadataTypes()
+	return newRandomMetadata(t, r, types[r.Intn(len(types))], depth)
+}
+
+func randomTransaction(t *testing.T, r *rand.Rand, depth int) metadata.Transaction {
+	switch r.Intn(3) {
+	case 0:
+		tx := &transaction.Tx{}
+		fill(t, r, reflect.ValueOf(tx).Elem(), depth)
+		tx.Type = common.TxNormalType
+		return tx
+	case 1:
+		tx := &transaction.TxCustomToken{}
+		fill(t, r, reflect.ValueOf(tx).Elem(), depth)
+		return tx
+	}
+	tx := &transaction.TxCustomTokenPrivacy{}
+	fill(t, r, reflect.ValueOf(tx).Elem(), depth)
+	return tx
+}
+
+// checkRoundTrip decodes the encoding of v into a new value, which must
+// encode to the same bytes
+func checkRoundTrip(t *testing.T, v interface{}) reflect.Value {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		t.Fatalf("codec.Marshal %T %+v", v, err)
+	}
+	decoded := reflect.New(reflect.TypeOf(v).Elem())
+	if err := codec.Unmarshal(data, decoded.Interface()); err != nil {
+		t.Fatalf("codec.Unmarshal %T %+v", v, err)
+	}
+	again, err := codec.Marshal(decoded.Interface())
+	if err != nil {
+		t.Fatalf("codec.Marshal decoded %T %+v", v, err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatalf("%T does not round trip", v)
+	}
+	return decoded
+}
+
+func TestRoundTripSample(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < fuzzRounds; i++ {
+		v := &sample{}
+		fill(t, r, reflect.ValueOf(v).Elem(), 6)
+		v.hidden = 0
+		decoded := checkRoundTrip(t, v).Interface().(*sample)
+		if !reflect.DeepEqual(v, decoded) {
+			t.Fatalf("decoded value differs\n%+v\n%+v", v, decoded)
+		}
+	}
+}
+
+func TestDeterministicMap(t *testing.T) {
+	m := map[string]int{}
+	for i := 0; i < 100; i++ {
+		m[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	first, err := codec.Marshal(m)
+	if err != nil {
+		t.Fatalf("codec.Marshal %+v", err)
+	}
+	for i := 0; i < 10; i++ {
+		data, _ := codec.Marshal(m)
+		if !bytes.Equal(first, data) {
+			t.Fatalf("map encoding is not deterministic")
+		}
+	}
+}
+
+func TestRejectNonCanonical(t *testing.T) {
+	data, _ := codec.Marshal(&sample{Numbers: []int{1}})
+	cases := map[string][]byte{
+		"version":   append([]byte{codec.Version + 1}, data[1:]...),
+		"trailing":  append(append([]byte{}, data...), 0),
+		"truncated": data[:len(data)-1],
+	}
+	for name, bad := range cases {
+		if err := codec.Unmarshal(bad, &sample{}); err == nil {
+			t.Fatalf("%s: codec.Unmarshal should fail", name)
+		}
+	}
+	// bool must be 0 or 1
+	if err := codec.Unmarshal([]byte{codec.Version, 2}, new(bool)); err == nil {
+		t.Fatalf("codec.Unmarshal should reject bool 2")
+	}
+	// 0x80 0x00 is a non minimal uvarint of 0
+	if err := codec.Unmarshal([]byte{codec.Version, 0x80, 0x00}, new(uint64)); err == nil {
+		t.Fatalf("codec.Unmarshal should reject non minimal uvarint")
+	}
+	// map keys out of order
+	if err := codec.Unmarshal([]byte{codec.Version, 2, 1, 'b', 0, 1, 'a', 0}, &map[string]int{}); err == nil {
+		t.Fatalf("codec.Unmarshal should reject unsorted map keys")
+	}
+}
+
+// TestFuzzCorrupted decodes random mutations of valid encodings, anything
+// accepted must be the canonical encoding of the decoded value
+func TestFuzzCorrupted(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < fuzzRounds*5; i++ {
+		v := &sample{}
+		fill(t, r, reflect.ValueOf(v).Elem(), 6)
+		data, err := codec.Marshal(v)
+		if err != nil {
+			t.Fatalf("codec.Marshal %+v", err)
+		}
+		for j := 0; j < 1+r.Intn(3); j++ {
+			data[1+r.Intn(len(data)-1)] = byte(r.Intn(256))
+		}
+		decoded := &sample{}
+		if err := codec.Unmarshal(data, decoded); err != nil {
+			continue
+		}
+		again, err := codec.Marshal(decoded)
+		if err != nil {
+			t.Fatalf("codec.Marshal %+v", err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Fatalf("non canonical encoding accepted")
+		}
+	}
+}
+
+func TestRoundTripPrivacyTypes(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		point := new(privacy.EllipticPoint)
+		point.Randomize()
+		decoded := checkRoundTrip(t, point).Interface().(*privacy.EllipticPoint)
+		if !decoded.IsEqual(point) {
+			t.Fatalf("decoded point differs")
+		}
+	}
+	zero := new(privacy.EllipticPoint).Zero()
+	if decoded := checkRoundTrip(t, zero).Interface().(*privacy.EllipticPoint); decoded.X.Sign() != 0 || decoded.Y.Sign() != 0 {
+		t.Fatalf("decoded zero point differs")
+	}
+	checkRoundTrip(t, &privacy.OutputCoin{})
+}
+
+func TestRoundTripMetadata(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	types := allMetadataTypes()
+	if len(types) == 0 {
+		t.Fatalf("no metadata type")
+	}
+	for _, metaType := range types {
+		for i := 0; i < fuzzRounds/10; i++ {
+			md := newRandomMetadata(t, r, metaType, 6)
+			holder := &struct{ Metadata metadata.Metadata }{md}
+			decoded := checkRoundTrip(t, holder).Interface().(*struct{ Metadata metadata.Metadata })
+			if reflect.TypeOf(decoded.Metadata) != reflect.TypeOf(md) {
+				t.Fatalf("metadata %d decoded as %T instead of %T", metaType, decoded.Metadata, md)
+			}
+		}
+	}
+}
+
+func TestRoundTripTransactions(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < fuzzRounds; i++ {
+		tx := randomTransaction(t, r, 8)
+		holder := &struct{ Tx metadata.Transaction }{tx}
+		decoded := checkRoundTrip(t, holder).Interface().(*struct{ Tx metadata.Transaction })
+		if reflect.TypeOf(decoded.Tx) != reflect.TypeOf(tx) {
+			t.Fatalf("transaction decoded as %T instead of %T", decoded.Tx, tx)
+		}
+	}
+	for _, v := range []interface{}{&transaction.Tx{Type: common.TxSalaryType}, &transaction.TxCustomToken{}, &transaction.TxCustomTokenPrivacy{}} {
+		fill(t, r, reflect.ValueOf(v).Elem(), 8)
+		if tx, ok := v.(*transaction.Tx); ok {
+			tx.Type = common.TxSalaryType
+		}
+		checkRoundTrip(t, v)
+	}
+}
+
+func TestRoundTripBlocks(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	for i := 0; i < fuzzRounds/4; i++ {
+		for _, v := range []interface{}{&blockchain.ShardBlock{}, &blockchain.BeaconBlock{}, &blockchain.CrossShardBlock{}, &blockchain.ShardToBeaconBlock{}} {
+			fill(t, r, reflect.ValueOf(v).Elem(), 10)
+			checkRoundTrip(t, v)
+		}
+	}
+}
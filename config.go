@@ -13,6 +13,7 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/jessevdk/go-flags"
@@ -40,6 +41,17 @@ const (
 	sampleConfigFilename      = "sample-config.conf"
 	defaultDisableRpcTLS      = true
 	defaultFastStartup        = true
+	defaultPruneDepth         = 1000
+	defaultUndoDepth          = 100
+	defaultMempoolMaxTxs      = 10000
+	defaultMempoolMaxSize     = 300 * 1024 // KB
+	defaultMempoolExpiry      = 24 * time.Hour
+	defaultReplaceFeeBump     = 10 // percent
+	defaultMaxOrphanTxs       = 100
+	defaultOrphanTxTTL        = 15 * time.Minute
+	defaultBanDuration        = 24 * time.Hour
+	defaultBanThreshold       = 100
+	defaultMempoolFilename    = "mempool.dat"
 	defaultNodeMode           = "relay"
 	// For wallet
 	defaultWalletName = "wallet"
@@ -64,6 +76,7 @@ type config struct {
 	ConfigFile  string `short:"C" long:"configfile" description:"Path to configuratio\n file"`
 	DataDir     string `short:"D" long:"datadir" description:"Directory to store data"`
 	DatabaseDir string `short:"d" long:"datapre" description:"Database dir"`
+	MemDB       bool   `long:"memdb" description:"Keep chain data in memory only, nothing is written to the database dir"`
 	LogDir      string `short:"L" long:"logdir" description:"Directory to log output."`
 	LogLevel    string `short:"l" long:"loglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 
@@ -82,6 +95,10 @@ type config struct {
 	MaxPeersNoShard      int      `long:"maxpeernoshard" description:"Max peers in no shard for connection"`
 	MaxPeersBeacon       int      `long:"maxpeerbeacon" description:"Max peers in beacon for connection"`
 
+	BanDuration      time.Duration `long:"banduration" description:"How long to ban misbehaving peers"`
+	BanThreshold     int           `long:"banthreshold" description:"Ban score of a misbehaving peer to be disconnected and banned (0 disables banning)"`
+	DisableRateLimit bool          `long:"noratelimit" description:"Do not limit messages per peer and per message type"`
+
 	ExternalAddress string `long:"externaladdress" description:"External address"`
 
 	RPCDisableAuth bool     `long:"norpcauth" description:"Disable RPC authorization by username/password"`
@@ -107,14 +124,29 @@ type config struct {
 
 	SpendingKey string `long:"spendingkey" description:"User spending key used for operation in consensus"`
 	NodeMode    string `long:"nodemode" description:"Role of this node (beacon/shard/wallet/relay | default role is 'relay' (relayshards must be set to run), 'auto' mode will switch between 'beacon' and 'shard')"`
-	RelayShards string `long:"relayshards" description:"set relay shards of this node when in 'relay' mode if noderole is auto then it only sync shard data when user is a shard producer/validator"`
+	RelayShards string `long:"relayshards" description:"set relay shards of this node when in 'relay' mode if noderole is auto then it only sync shard data when user is a shard producer/validator, mempool only accepts txs of relay shards and of the shard of the user, a relay node without relay shards accepts txs of every shard"`
 	// For Wallet
 	Wallet           bool   `long:"enablewallet" description:"Enable wallet"`
 	WalletName       string `long:"wallet" description:"Wallet Database Name file, default is 'wallet'"`
 	WalletPassphrase string `long:"walletpassphrase" description:"Wallet passphrase"`
 	WalletAutoInit   bool   `long:"walletautoinit" description:"Init wallet automatically if not exist"`
 
-	FastStartup bool `long:"faststartup" description:"Load existed shard/chain dependencies instead of rebuild from block data"`
+	FastStartup bool   `long:"faststartup" description:"Load existed shard/chain dependencies instead of rebuild from block data"`
+	Prune       bool   `long:"prune" description:"Delete the body of old blocks, only headers, tx index, serial numbers and commitments are kept"`
+	PruneDepth  uint64 `long:"prunedepth" description:"Number of most recent blocks of each chain which keep their body in prune mode"`
+	UndoDepth   uint64 `long:"undodepth" description:"Number of most recent blocks of each chain which keep their undo journal and can be rolled back"`
+
+	MigrateDryRun bool `long:"migratedryrun" description:"Report the database migrations which would run at startup, then exit without changing anything"`
+
+	MempoolMaxTxs       int           `long:"mempoolmaxtxs" description:"Max number of txs in mempool, txs with the lowest fee per KB are evicted when it is full (0 means no limit)"`
+	MempoolMaxSize      uint64        `long:"mempoolmaxsize" description:"Max total size of txs in mempool in KB (0 means no limit)"`
+	MempoolExpiry       time.Duration `long:"mempoolexpiry" description:"Remove txs which stay in mempool longer than this duration (0 means never)"`
+	MempoolExpiryBlocks uint64        `long:"mempoolexpiryblocks" description:"Remove txs added to mempool more than this number of blocks ago (0 means never)"`
+	NoMempoolPersist    bool          `long:"nomempoolpersist" description:"Do not save mempool txs to the data directory on shutdown and periodically, they are re-validated and restored at startup otherwise"`
+	MaxOrphanTxs        int           `long:"maxorphantx" description:"Max number of txs kept while the commitments they spend are not known yet (0 disables the orphan pool)"`
+	OrphanTxTTL         time.Duration `long:"orphantxttl" description:"Drop orphan txs which wait for the commitments they spend longer than this duration"`
+	ReplaceFeeBump      uint64        `long:"replacefeebump" description:"Min percent a tx must pay over the fees of pooled txs spending the same serial numbers to replace them"`
+	TxPriority          []string      `long:"txpriority" description:"Block selection priority of txs by metadata type as <metatype>:<level>, txs of higher level are selected first regardless of fee -- replaces the default which puts loan, oracle and vote txs at level 1"`
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -286,6 +318,16 @@ func loadConfig() (*config, []string, error) {
 		NodeMode:             defaultNodeMode,
 		SpendingKey:          common.EmptyString,
 		FastStartup:          defaultFastStartup,
+		PruneDepth:           defaultPruneDepth,
+		UndoDepth:            defaultUndoDepth,
+		MempoolMaxTxs:        defaultMempoolMaxTxs,
+		MempoolMaxSize:       defaultMempoolMaxSize,
+		MempoolExpiry:        defaultMempoolExpiry,
+		ReplaceFeeBump:       defaultReplaceFeeBump,
+		MaxOrphanTxs:         defaultMaxOrphanTxs,
+		OrphanTxTTL:          defaultOrphanTxTTL,
+		BanDuration:          defaultBanDuration,
+		BanThreshold:         defaultBanThreshold,
 	}
 
 	// Service options which are only added on Windows.
@@ -0,0 +1,70 @@
+package connmanager
+
+import (
+	"time"
+
+	"github.com/ninjadotorg/constant/addrmanager"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/peer"
+)
+
+// handleBanned bans remote peer of peerConn whose ban score crosses threshold
+func (connManager *ConnManager) handleBanned(peerConn *peer.PeerConn, reason string) {
+	Logger.log.Infof("handleBanned %s", peerConn.RemotePeerID.Pretty())
+	connManager.BanPeer(peerConn.RemotePeerID.Pretty(), peerConn.RemotePeer.RawAddress, connManager.Config.BanDuration, reason)
+}
+
+/*
+BanPeer - refuse connections from and to the peer with given id and address
+for duration, the peer is disconnected if it is connected
+*/
+func (connManager *ConnManager) BanPeer(peerID string, rawAddress string, duration time.Duration, reason string) {
+	if connManager.Config.AddrManager == nil {
+		Logger.log.Errorf("Can not ban peer %s without address manager", peerID)
+		return
+	}
+	Logger.log.Warnf("Ban peer %s (address: %s) for %s: %s", peerID, rawAddress, duration, reason)
+	connManager.Config.AddrManager.Ban(peerID, rawAddress, time.Now().Add(duration), reason)
+
+	listener := connManager.Config.ListenerPeer
+	if listener == nil {
+		return
+	}
+	for _, peerConn := range listener.GetPeerConnOfAll() {
+		if peerConn.RemotePeerID.Pretty() == peerID || (rawAddress != common.EmptyString && peerConn.RemoteRawAddress == rawAddress) {
+			peerConn.ForceClose()
+		}
+	}
+}
+
+// UnbanPeer lifts ban of peer with given id or address
+func (connManager *ConnManager) UnbanPeer(peerIDOrAddress string) bool {
+	if connManager.Config.AddrManager == nil {
+		return false
+	}
+	return connManager.Config.AddrManager.Unban(peerIDOrAddress)
+}
+
+// ClearBanned lifts all bans
+func (connManager *ConnManager) ClearBanned() {
+	if connManager.Config.AddrManager == nil {
+		return
+	}
+	connManager.Config.AddrManager.ClearBanned()
+}
+
+// ListBanned returns bans which are not expired
+func (connManager *ConnManager) ListBanned() []addrmanager.BannedPeer {
+	if connManager.Config.AddrManager == nil {
+		return []addrmanager.BannedPeer{}
+	}
+	return connManager.Config.AddrManager.BannedPeers()
+}
+
+// IsBanned returns true if peer id or address is banned
+func (connManager *ConnManager) IsBanned(peerID string, rawAddress string) bool {
+	if connManager.Config.AddrManager == nil {
+		return false
+	}
+	return connManager.Config.AddrManager.IsBanned(peerID, rawAddress)
+}
@@ -14,6 +14,7 @@ import (
 	libpeer "github.com/libp2p/go-libp2p-peer"
 	pstore "github.com/libp2p/go-libp2p-peerstore"
 	ma "github.com/multiformats/go-multiaddr"
+	"github.com/ninjadotorg/constant/addrmanager"
 	"github.com/ninjadotorg/constant/bootnode/server"
 	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/peer"
@@ -126,6 +127,11 @@ type Config struct {
 	DiscoverPeers        bool
 	DiscoverPeersAddress string
 	ConsensusState       *ConsensusState
+
+	// AddrManager keeps banned peers across restarts
+	AddrManager *addrmanager.AddrManager
+	// BanDuration is how long a peer whose ban score crosses threshold is banned
+	BanDuration time.Duration
 }
 
 type DiscoverPeerInfo struct {
@@ -270,6 +276,11 @@ func (connManager *ConnManager) Connect(addr string, pubKey string, cConn chan *
 		return
 	}
 
+	if connManager.IsBanned(peerId.Pretty(), addr) {
+		Logger.log.Infof("Skip connecting to banned peer %s", addr)
+		return
+	}
+
 	// Decapsulate the /ipfs/<peerID> part from the target
 	// /ip4/<a.b.c.d>/ipfs/<peer> becomes /ip4/<a.b.c.d>
 
@@ -280,6 +291,7 @@ func (connManager *ConnManager) Connect(addr string, pubKey string, cConn chan *
 	listen.HandleConnected = connManager.handleConnected
 	listen.HandleDisconnected = connManager.handleDisconnected
 	listen.HandleFailed = connManager.handleFailed
+	listen.HandleBanned = connManager.handleBanned
 
 	peer := peer.Peer{
 		TargetAddress:      targetAddr,
@@ -291,6 +303,7 @@ func (connManager *ConnManager) Connect(addr string, pubKey string, cConn chan *
 		HandleConnected:    connManager.handleConnected,
 		HandleDisconnected: connManager.handleDisconnected,
 		HandleFailed:       connManager.handleFailed,
+		HandleBanned:       connManager.handleBanned,
 	}
 
 	if pubKey != common.EmptyString {
@@ -320,6 +333,7 @@ func (connManager *ConnManager) Start(discoverPeerAddress string) {
 		listner.HandleConnected = connManager.handleConnected
 		listner.HandleDisconnected = connManager.handleDisconnected
 		listner.HandleFailed = connManager.handleFailed
+		listner.HandleBanned = connManager.handleBanned
 		go connManager.listenHandler(listner)
 		connManager.ListeningPeer = listner
 
@@ -663,6 +677,10 @@ func (connManager *ConnManager) CheckForAcceptConn(peerConn *peer.PeerConn) bool
 	if peerConn == nil {
 		return false
 	}
+	// refuse banned peer
+	if connManager.IsBanned(peerConn.RemotePeerID.Pretty(), peerConn.RemotePeer.RawAddress) {
+		return false
+	}
 	// check max shard conn
 	sh := connManager.getShardOfPbk(peerConn.RemotePeer.PublicKey)
 	currentShard := connManager.Config.ConsensusState.CurrentShard
@@ -77,7 +77,10 @@ func (protocol *BFTProtocol) Start(isProposer bool, layer string, shardID byte)
 					if err != nil {
 						return nil, err
 					}
-					jsonBlock, _ := json.Marshal(newBlock)
+					jsonBlock, err := json.Marshal(newBlock)
+					if err != nil {
+						return nil, err
+					}
 					msg, err = MakeMsgBFTPropose(jsonBlock)
 					if err != nil {
 						return nil, err
@@ -91,7 +94,10 @@ func (protocol *BFTProtocol) Start(isProposer bool, layer string, shardID byte)
 					if err != nil {
 						return nil, err
 					}
-					jsonBlock, _ := json.Marshal(newBlock)
+					jsonBlock, err := json.Marshal(newBlock)
+					if err != nil {
+						return nil, err
+					}
 					msg, err = MakeMsgBFTPropose(jsonBlock)
 					if err != nil {
 						return nil, err
@@ -161,7 +167,10 @@ func (protocol *BFTProtocol) Start(isProposer bool, layer string, shardID byte)
 							fmt.Println("Propose block received")
 							if layer == "beacon" {
 								pendingBlk := blockchain.BeaconBlock{}
-								pendingBlk.UnmarshalJSON(msgPropose.(*wire.MessageBFTPropose).Block)
+								if err := pendingBlk.UnmarshalJSON(msgPropose.(*wire.MessageBFTPropose).Block); err != nil {
+									Logger.log.Error(err)
+									continue
+								}
 								blkHash := pendingBlk.Header.Hash()
 								err := cashec.ValidateDataB58(pendingBlk.Header.Producer, pendingBlk.ProducerSig, blkHash.GetBytes())
 								if err != nil {
@@ -177,7 +186,10 @@ func (protocol *BFTProtocol) Start(isProposer bool, layer string, shardID byte)
 								protocol.multiSigScheme.dataToSig = pendingBlk.Header.Hash()
 							} else {
 								pendingBlk := blockchain.ShardBlock{}
-								pendingBlk.UnmarshalJSON(msgPropose.(*wire.MessageBFTPropose).Block)
+								if err := pendingBlk.UnmarshalJSON(msgPropose.(*wire.MessageBFTPropose).Block); err != nil {
+									Logger.log.Error(err)
+									continue
+								}
 								blkHash := pendingBlk.Header.Hash()
 								err := cashec.ValidateDataB58(pendingBlk.Header.Producer, pendingBlk.ProducerSig, blkHash.GetBytes())
 								if err != nil {
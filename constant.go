@@ -52,12 +52,36 @@ func mainMaster(serverChan chan<- *Server) error {
 	}
 
 	// Create db and use it.
-	db, err := database.Open("leveldb", filepath.Join(cfg.DataDir, cfg.DatabaseDir))
+	// Migrations run on open can be interrupted, the database then stays at
+	// the version of the last completed migration.
+	migrationOptions := &database.MigrationOptions{
+		DryRun:    cfg.MigrateDryRun,
+		Interrupt: interrupt,
+		Progress: func(result database.MigrationResult) {
+			if result.DryRun {
+				Logger.log.Infof("Database migration %d (%s) would make %d changes", result.Version, result.Description, result.Changes)
+			} else {
+				Logger.log.Infof("Database migrated to version %d (%s), %d changes", result.Version, result.Description, result.Changes)
+			}
+		},
+	}
+	var db database.DatabaseInterface
+	if cfg.MemDB {
+		db, err = database.Open("memdb", migrationOptions)
+	} else {
+		db, err = database.Open("leveldb", filepath.Join(cfg.DataDir, cfg.DatabaseDir), migrationOptions)
+	}
 	if err != nil {
+		if interruptRequested(interrupt) {
+			return nil
+		}
 		Logger.log.Error("could not open connection to leveldb")
 		Logger.log.Error(err)
 		panic(err)
 	}
+	if cfg.MigrateDryRun {
+		return db.Close()
+	}
 
 	// Check wallet and start it
 	var walletObj *wallet.Wallet
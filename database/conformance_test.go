@@ -0,0 +1,499 @@
+package database_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
+	_ "github.com/ninjadotorg/constant/database/lvdb"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// every driver must pass the same conformance suite
+var conformanceDrivers = []struct {
+	name string
+	open func(t *testing.T) (database.DatabaseInterface, func())
+}{
+	{
+		name: "leveldb",
+		open: func(t *testing.T) (database.DatabaseInterface, func()) {
+			dbPath, err := ioutil.TempDir(os.TempDir(), "test_conformance_")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %+v", err)
+			}
+			db, err := database.Open("leveldb", dbPath)
+			if err != nil {
+				t.Fatalf("could not open db path: %s, %+v", dbPath, err)
+			}
+			return db, func() {
+				db.Close()
+				os.RemoveAll(dbPath)
+			}
+		},
+	},
+	{
+		name: "memdb",
+		open: func(t *testing.T) (database.DatabaseInterface, func()) {
+			db, err := database.Open("memdb")
+			if err != nil {
+				t.Fatalf("database.Open memdb %+v", err)
+			}
+			return db, func() {
+				db.Close()
+			}
+		},
+	},
+}
+
+var conformanceTests = []struct {
+	name string
+	test func(t *testing.T, db database.DatabaseInterface)
+}{
+	{"KeyValue", testKeyValue},
+	{"Iterator", testIterator},
+	{"ShardBlock", testShardBlock},
+	{"BeaconBlock", testBeaconBlock},
+	{"TransactionIndex", testTransactionIndex},
+	{"SerialNumbers", testSerialNumbers},
+	{"Commitments", testCommitments},
+	{"SNDerivators", testSNDerivators},
+	{"Vote", testVote},
+	{"CMB", testCMB},
+	{"Batch", testBatch},
+	{"BatchPending", testBatchPending},
+	{"Undo", testUndo},
+	{"UndoList", testUndoList},
+	{"Prune", testPrune},
+}
+
+func TestDriverConformance(t *testing.T) {
+	for _, driver := range conformanceDrivers {
+		for _, test := range conformanceTests {
+			t.Run(driver.name+"/"+test.name, func(t *testing.T) {
+				db, teardown := driver.open(t)
+				defer teardown()
+				test.test(t, db)
+			})
+		}
+	}
+}
+
+func TestMemDBIsNotShared(t *testing.T) {
+	db1, _ := database.Open("memdb")
+	defer db1.Close()
+	db2, _ := database.Open("memdb")
+	defer db2.Close()
+	db1.Put([]byte("key"), []byte("value"))
+	if ok, _ := db2.HasValue([]byte("key")); ok {
+		t.Fatalf("memdb instances should not share data")
+	}
+}
+
+var testPaymentAddress = privacy.PaymentAddress{
+	Pk: bytes.Repeat([]byte{1}, privacy.CompressedPointSize),
+	Tk: bytes.Repeat([]byte{2}, privacy.CompressedPointSize),
+}
+
+type testBlock struct {
+	Height uint64
+}
+
+func (b *testBlock) Hash() *common.Hash {
+	h := common.HashH(big.NewInt(int64(b.Height)).Bytes())
+	return &h
+}
+
+func testKeyValue(t *testing.T, db database.DatabaseInterface) {
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("db.Put %+v", err)
+	}
+	value, err := db.Get([]byte("key"))
+	if err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Fatalf("db.Get got %s, %+v", value, err)
+	}
+	if err := db.Delete([]byte("key")); err != nil {
+		t.Fatalf("db.Delete %+v", err)
+	}
+	if ok, _ := db.HasValue([]byte("key")); ok {
+		t.Fatalf("key should be deleted")
+	}
+	if _, err := db.Get([]byte("key")); err == nil {
+		t.Fatalf("db.Get should fail on missing key")
+	}
+}
+
+func testIterator(t *testing.T, db database.DatabaseInterface) {
+	db.Put([]byte("a-2"), []byte{2})
+	db.Put([]byte("a-1"), []byte{1})
+	db.Put([]byte("b-1"), []byte{3})
+	iter := db.NewIterator(util.BytesPrefix([]byte("a-")), nil)
+	defer iter.Release()
+	var values []byte
+	for iter.Next() {
+		values = append(values, iter.Value()...)
+	}
+	if !bytes.Equal(values, []byte{1, 2}) {
+		t.Fatalf("iterator should return sorted values of prefix, got %v", values)
+	}
+}
+
+func testShardBlock(t *testing.T, db database.DatabaseInterface) {
+	block := &testBlock{Height: 1}
+	if err := db.StoreShardBlock(block, 0); err != nil {
+		t.Fatalf("db.StoreShardBlock %+v", err)
+	}
+	if err := db.StoreShardBlock(block, 0); err == nil {
+		t.Fatalf("db.StoreShardBlock should reject existed block")
+	}
+	if ok, err := db.HasBlock(block.Hash()); err != nil || !ok {
+		t.Fatalf("db.HasBlock got %v, %+v", ok, err)
+	}
+	if _, err := db.FetchBlock(block.Hash()); err != nil {
+		t.Fatalf("db.FetchBlock %+v", err)
+	}
+	if err := db.StoreShardBlockIndex(block.Hash(), block.Height, 0); err != nil {
+		t.Fatalf("db.StoreShardBlockIndex %+v", err)
+	}
+	height, shardID, err := db.GetIndexOfBlock(block.Hash())
+	if err != nil || height != 1 || shardID != 0 {
+		t.Fatalf("db.GetIndexOfBlock got %d %d, %+v", height, shardID, err)
+	}
+	hash, err := db.GetBlockByIndex(1, 0)
+	if err != nil || !hash.IsEqual(block.Hash()) {
+		t.Fatalf("db.GetBlockByIndex got %v, %+v", hash, err)
+	}
+	hashes, err := db.FetchChainBlocks(0)
+	if err != nil || len(hashes) != 1 {
+		t.Fatalf("db.FetchChainBlocks got %v, %+v", hashes, err)
+	}
+	if err := db.DeleteBlock(block.Hash(), block.Height, 0); err != nil {
+		t.Fatalf("db.DeleteBlock %+v", err)
+	}
+	if ok, _ := db.HasBlock(block.Hash()); ok {
+		t.Fatalf("block should be deleted")
+	}
+}
+
+func testBeaconBlock(t *testing.T, db database.DatabaseInterface) {
+	block := &testBlock{Height: 2}
+	if err := db.StoreBeaconBlock(block); err != nil {
+		t.Fatalf("db.StoreBeaconBlock %+v", err)
+	}
+	if ok, err := db.HasBeaconBlock(block.Hash()); err != nil || !ok {
+		t.Fatalf("db.HasBeaconBlock got %v, %+v", ok, err)
+	}
+	if err := db.StoreBeaconBlockIndex(block.Hash(), block.Height); err != nil {
+		t.Fatalf("db.StoreBeaconBlockIndex %+v", err)
+	}
+	height, err := db.GetIndexOfBeaconBlock(block.Hash())
+	if err != nil || height != 2 {
+		t.Fatalf("db.GetIndexOfBeaconBlock got %d, %+v", height, err)
+	}
+	hash, err := db.GetBeaconBlockHashByIndex(2)
+	if err != nil || !hash.IsEqual(block.Hash()) {
+		t.Fatalf("db.GetBeaconBlockHashByIndex got %v, %+v", hash, err)
+	}
+}
+
+func testTransactionIndex(t *testing.T, db database.DatabaseInterface) {
+	txHash := common.HashH([]byte("tx"))
+	blockHash := common.HashH([]byte("block"))
+	if err := db.StoreTransactionIndex(&txHash, &blockHash, 3); err != nil {
+		t.Fatalf("db.StoreTransactionIndex %+v", err)
+	}
+	hash, index, dbErr := db.GetTransactionIndexById(&txHash)
+	if dbErr != nil || index != 3 || !hash.IsEqual(&blockHash) {
+		t.Fatalf("db.GetTransactionIndexById got %v %d, %+v", hash, index, dbErr)
+	}
+}
+
+func testSerialNumbers(t *testing.T, db database.DatabaseInterface) {
+	tokenID := &common.Hash{}
+	if err := db.StoreSerialNumbers(tokenID, []byte{1}, 0); err != nil {
+		t.Fatalf("db.StoreSerialNumbers %+v", err)
+	}
+	if ok, _ := db.HasSerialNumber(tokenID, []byte{1}, 0); !ok {
+		t.Fatalf("serial number should be stored")
+	}
+	if ok, _ := db.HasSerialNumber(tokenID, []byte{1}, 1); ok {
+		t.Fatalf("serial number should not be stored in other shard")
+	}
+	if err := db.CleanSerialNumbers(); err != nil {
+		t.Fatalf("db.CleanSerialNumbers %+v", err)
+	}
+	if ok, _ := db.HasSerialNumber(tokenID, []byte{1}, 0); ok {
+		t.Fatalf("serial number should be cleaned")
+	}
+}
+
+func testCommitments(t *testing.T, db database.DatabaseInterface) {
+	tokenID := &common.Hash{}
+	pubkey := []byte{9, 9, 9}
+	for _, commitment := range [][]byte{{1, 1}, {2, 2}} {
+		if err := db.StoreCommitments(tokenID, pubkey, commitment, 0); err != nil {
+			t.Fatalf("db.StoreCommitments %+v", err)
+		}
+	}
+	length, err := db.GetCommitmentLength(tokenID, 0)
+	if err != nil || length.Uint64() != 2 {
+		t.Fatalf("db.GetCommitmentLength got %v, %+v", length, err)
+	}
+	index, err := db.GetCommitmentIndex(tokenID, []byte{2, 2}, 0)
+	if err != nil || index.Uint64() != 1 {
+		t.Fatalf("db.GetCommitmentIndex got %v, %+v", index, err)
+	}
+	commitment, err := db.GetCommitmentByIndex(tokenID, 1, 0)
+	if err != nil || !bytes.Equal(commitment, []byte{2, 2}) {
+		t.Fatalf("db.GetCommitmentByIndex got %v, %+v", commitment, err)
+	}
+	indexes, err := db.GetCommitmentIndexsByPubkey(tokenID, pubkey, 0)
+	if err != nil || len(indexes) != 2 {
+		t.Fatalf("db.GetCommitmentIndexsByPubkey got %v, %+v", indexes, err)
+	}
+	if ok, _ := db.HasCommitment(tokenID, []byte{1, 1}, 0); !ok {
+		t.Fatalf("commitment should be stored")
+	}
+}
+
+func testSNDerivators(t *testing.T, db database.DatabaseInterface) {
+	tokenID := &common.Hash{}
+	if err := db.StoreSNDerivators(tokenID, *big.NewInt(7), 0); err != nil {
+		t.Fatalf("db.StoreSNDerivators %+v", err)
+	}
+	if ok, _ := db.HasSNDerivator(tokenID, *big.NewInt(7), 0); !ok {
+		t.Fatalf("snderivator should be stored")
+	}
+	snds, err := db.FetchSNDerivator(tokenID, 0)
+	if err != nil || len(snds) != 1 || snds[0].Int64() != 7 {
+		t.Fatalf("db.FetchSNDerivator got %v, %+v", snds, err)
+	}
+}
+
+func testVote(t *testing.T, db database.DatabaseInterface) {
+	if err := db.SendInitVoteToken("dcb", 1, testPaymentAddress, 5); err != nil {
+		t.Fatalf("db.SendInitVoteToken %+v", err)
+	}
+	if err := db.SendInitVoteToken("dcb", 1, testPaymentAddress, 3); err != nil {
+		t.Fatalf("db.SendInitVoteToken %+v", err)
+	}
+	amount, err := db.GetVoteTokenAmount("dcb", 1, testPaymentAddress)
+	if err != nil || amount != 8 {
+		t.Fatalf("db.GetVoteTokenAmount got %d, %+v", amount, err)
+	}
+}
+
+func testCMB(t *testing.T, db database.DatabaseInterface) {
+	mainAccount := bytes.Repeat([]byte{1}, 66)
+	reserveAccount := bytes.Repeat([]byte{2}, 66)
+	members := [][]byte{bytes.Repeat([]byte{3}, 66)}
+	txHash := common.HashH([]byte("cmb"))
+	if err := db.StoreCMB(mainAccount, reserveAccount, members, 100, txHash[:]); err != nil {
+		t.Fatalf("db.StoreCMB %+v", err)
+	}
+	if err := db.StoreCMB(mainAccount, reserveAccount, members, 100, txHash[:]); err == nil {
+		t.Fatalf("db.StoreCMB should reject existed cmb")
+	}
+	if err := db.UpdateCMBState(mainAccount, 2); err != nil {
+		t.Fatalf("db.UpdateCMBState %+v", err)
+	}
+	reserve, cmbMembers, capital, cmbTxHash, state, _, err := db.GetCMB(mainAccount)
+	if err != nil || !bytes.Equal(reserve, reserveAccount) || capital != 100 || state != 2 {
+		t.Fatalf("db.GetCMB got %v %d %d, %+v", reserve, capital, state, err)
+	}
+	if len(cmbMembers) != 1 || !bytes.Equal(cmbMembers[0], members[0]) || !bytes.Equal(cmbTxHash, txHash[:]) {
+		t.Fatalf("db.GetCMB got members %v, tx hash %v", cmbMembers, cmbTxHash)
+	}
+}
+
+func testBatch(t *testing.T, db database.DatabaseInterface) {
+	batch := db.NewBatch()
+	batch.Put([]byte("key"), []byte("value"))
+	if ok, _ := db.HasValue([]byte("key")); ok {
+		t.Fatalf("batch write should not be visible before Write")
+	}
+	if ok, _ := batch.HasValue([]byte("key")); !ok {
+		t.Fatalf("batch should see its own writes")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+	if ok, _ := db.HasValue([]byte("key")); !ok {
+		t.Fatalf("batch write should be visible after Write")
+	}
+}
+
+// typed reads of a batch see its pending writes, deletes and Reset
+func testBatchPending(t *testing.T, db database.DatabaseInterface) {
+	tokenID := &common.Hash{}
+	db.Put([]byte("deleted"), []byte{1})
+	batch := db.NewBatch()
+	if err := batch.StoreSerialNumbers(tokenID, []byte{1}, 0); err != nil {
+		t.Fatalf("batch.StoreSerialNumbers %+v", err)
+	}
+	if err := batch.StoreSerialNumbers(tokenID, []byte{2}, 0); err != nil {
+		t.Fatalf("batch.StoreSerialNumbers %+v", err)
+	}
+	serialNumbers, err := batch.FetchSerialNumbers(tokenID, 0)
+	if err != nil || len(serialNumbers) != 2 || !bytes.Equal(serialNumbers[1], []byte{2}) {
+		t.Fatalf("batch should see 2 pending serial numbers, got %v, %+v", serialNumbers, err)
+	}
+	if ok, _ := db.HasSerialNumber(tokenID, []byte{1}, 0); ok {
+		t.Fatalf("serial number should not be stored before Write")
+	}
+	batch.Delete([]byte("deleted"))
+	if ok, _ := batch.HasValue([]byte("deleted")); ok {
+		t.Fatalf("batch should not see a key it deletes")
+	}
+	if ok, _ := db.HasValue([]byte("deleted")); !ok {
+		t.Fatalf("delete should not be visible before Write")
+	}
+	if batch.Len() == 0 {
+		t.Fatalf("batch.Len() should count pending writes")
+	}
+
+	batch.Reset()
+	if batch.Len() != 0 {
+		t.Fatalf("batch.Len() = %d after Reset, want 0", batch.Len())
+	}
+	if ok, _ := batch.HasSerialNumber(tokenID, []byte{1}, 0); ok {
+		t.Fatalf("Reset should drop pending serial numbers")
+	}
+	if err := batch.StoreSerialNumbers(tokenID, []byte{3}, 0); err != nil {
+		t.Fatalf("batch.StoreSerialNumbers %+v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+	serialNumbers, err = db.FetchSerialNumbers(tokenID, 0)
+	if err != nil || len(serialNumbers) != 1 || !bytes.Equal(serialNumbers[0], []byte{3}) {
+		t.Fatalf("db should only have the serial number written after Reset, got %v, %+v", serialNumbers, err)
+	}
+	if ok, _ := db.HasValue([]byte("deleted")); !ok {
+		t.Fatalf("delete dropped by Reset should not be written")
+	}
+}
+
+func testUndo(t *testing.T, db database.DatabaseInterface) {
+	db.Put([]byte("a"), []byte{1})
+	db.Put([]byte("c"), []byte{3})
+	batch := db.NewBatch()
+	batch.Put([]byte("a"), []byte{2})
+	batch.Put([]byte("a"), []byte{4})
+	batch.Put([]byte("b"), []byte{5})
+	batch.Delete([]byte("c"))
+	undo := batch.Undo()
+	if len(undo) != 3 {
+		t.Fatalf("undo should have one entry per key, got %+v", undo)
+	}
+	blockHash := common.HashH([]byte("block"))
+	if err := batch.StoreUndo(&blockHash, undo); err != nil {
+		t.Fatalf("batch.StoreUndo %+v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+
+	undo, err := db.FetchUndo(&blockHash)
+	if err != nil {
+		t.Fatalf("db.FetchUndo %+v", err)
+	}
+	if err := database.ApplyUndo(batch, undo); err != nil {
+		t.Fatalf("database.ApplyUndo %+v", err)
+	}
+	batch.DeleteUndo(&blockHash)
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+	if value, _ := db.Get([]byte("a")); !bytes.Equal(value, []byte{1}) {
+		t.Fatalf("undo should restore updated key, got %v", value)
+	}
+	if ok, _ := db.HasValue([]byte("b")); ok {
+		t.Fatalf("undo should delete created key")
+	}
+	if value, _ := db.Get([]byte("c")); !bytes.Equal(value, []byte{3}) {
+		t.Fatalf("undo should restore deleted key, got %v", value)
+	}
+	if _, err := db.FetchUndo(&blockHash); err == nil {
+		t.Fatalf("undo journal should be deleted")
+	}
+}
+
+func testUndoList(t *testing.T, db database.DatabaseInterface) {
+	tokenID := common.HashH([]byte("token"))
+	if err := db.StoreSerialNumbers(&tokenID, []byte{1}, 0); err != nil {
+		t.Fatalf("db.StoreSerialNumbers %+v", err)
+	}
+	batch := db.NewBatch()
+	batch.StoreSerialNumbers(&tokenID, []byte{2}, 0)
+	batch.StoreSerialNumbers(&tokenID, []byte{3}, 0)
+	undo := batch.Undo()
+	lists := 0
+	for _, entry := range undo {
+		if entry.IsList {
+			lists++
+			if entry.Value != nil || entry.ListLen != 1 {
+				t.Fatalf("list undo should keep only the committed length, got %+v", entry)
+			}
+		}
+	}
+	if lists != 1 {
+		t.Fatalf("undo should have one list entry, got %+v", undo)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+
+	if err := database.ApplyUndo(batch, undo); err != nil {
+		t.Fatalf("database.ApplyUndo %+v", err)
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch.Write %+v", err)
+	}
+	serialNumbers, err := db.FetchSerialNumbers(&tokenID, 0)
+	if err != nil {
+		t.Fatalf("db.FetchSerialNumbers %+v", err)
+	}
+	if len(serialNumbers) != 1 || !bytes.Equal(serialNumbers[0], []byte{1}) {
+		t.Fatalf("undo should cut appended items off, got %v", serialNumbers)
+	}
+	for _, sn := range [][]byte{{2}, {3}} {
+		if ok, _ := db.HasSerialNumber(&tokenID, sn, 0); ok {
+			t.Fatalf("undo should delete index of appended item %v", sn)
+		}
+	}
+	if ok, _ := db.HasSerialNumber(&tokenID, []byte{1}, 0); !ok {
+		t.Fatalf("undo should keep committed item")
+	}
+}
+
+func testPrune(t *testing.T, db database.DatabaseInterface) {
+	block := &testBlock{Height: 3}
+	if err := db.StoreShardBlock(block, 0); err != nil {
+		t.Fatalf("db.StoreShardBlock %+v", err)
+	}
+	if pruned, _ := db.IsBlockPruned(block.Hash()); pruned {
+		t.Fatalf("block should not be pruned")
+	}
+	if err := db.PruneBlockBody(block.Hash(), struct{}{}); err != nil {
+		t.Fatalf("db.PruneBlockBody %+v", err)
+	}
+	if pruned, err := db.IsBlockPruned(block.Hash()); err != nil || !pruned {
+		t.Fatalf("block should be pruned, got %v, %+v", pruned, err)
+	}
+	expected, _ := codec.Marshal(struct{}{})
+	if data, err := db.FetchBlock(block.Hash()); err != nil || !bytes.Equal(data, expected) {
+		t.Fatalf("db.FetchBlock should return pruned data, got %s, %+v", data, err)
+	}
+	if err := db.DeleteBlock(block.Hash(), block.Height, 0); err != nil {
+		t.Fatalf("db.DeleteBlock %+v", err)
+	}
+	if pruned, _ := db.IsBlockPruned(block.Hash()); pruned {
+		t.Fatalf("pruned mark should be deleted with the block")
+	}
+}
@@ -20,11 +20,28 @@ func RegisterDriver(d Driver) error {
 	return nil
 }
 
-// Open opens the db connection.
+// Open opens the db connection and migrates it to the latest schema version
+// of the driver. A *MigrationOptions can be passed as last argument, it is not
+// forwarded to the driver.
 func Open(typ string, args ...interface{}) (DatabaseInterface, error) {
 	d, exists := drivers[typ]
 	if !exists {
 		return nil, NewDatabaseError(DriverNotRegisterErr, errors.Errorf("Driver %s is not registered", typ))
 	}
-	return d.Open(args...)
+	var options *MigrationOptions
+	if len(args) > 0 {
+		if o, ok := args[len(args)-1].(*MigrationOptions); ok {
+			options = o
+			args = args[:len(args)-1]
+		}
+	}
+	db, err := d.Open(args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db, typ, options); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
@@ -24,6 +24,11 @@ const (
 	//voting err
 	NotEnoughCandidate
 	ErrUnexpected
+
+	// migration err
+	MigrationErr
+	SchemaVersionErr
+	MigrationInterrupted
 )
 
 var ErrCodeMessage = map[int]struct {
@@ -48,6 +53,11 @@ var ErrCodeMessage = map[int]struct {
 	// -4xxx voting
 	NotEnoughCandidate: {-4000, "Not enough candidate for DCB Board"},
 	ErrUnexpected:      {-4001, "unknown"},
+
+	// -5xxx migration
+	MigrationErr:         {-5000, "Database migration failed"},
+	SchemaVersionErr:     {-5001, "Unsupported database schema version"},
+	MigrationInterrupted: {-5002, "Database migration interrupted"},
 }
 
 type DatabaseError struct {
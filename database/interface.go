@@ -17,6 +17,14 @@ type DatabaseInterface interface {
 	Delete(key []byte) error
 	HasValue(key []byte) (bool, error)
 
+	// Batch
+	NewBatch() Batch
+
+	// Undo journal of a block
+	StoreUndo(blockHash *common.Hash, entries []UndoEntry) error
+	FetchUndo(blockHash *common.Hash) ([]UndoEntry, error)
+	DeleteUndo(blockHash *common.Hash) error
+
 	// Block
 	StoreShardBlock(interface{}, byte) error
 	StoreShardBlockHeader(interface{}, *common.Hash, byte) error
@@ -45,6 +53,10 @@ type DatabaseInterface interface {
 	FetchBeaconBlockChain() ([]*common.Hash, error)
 	DeleteBeaconBlock(*common.Hash, uint64) error
 
+	// Pruning
+	PruneBlockBody(hash *common.Hash, v interface{}) error
+	IsBlockPruned(hash *common.Hash) (bool, error)
+
 	// Block index
 	StoreShardBlockIndex(*common.Hash, uint64, byte) error
 	GetIndexOfBlock(*common.Hash) (uint64, byte, error)
@@ -172,3 +184,33 @@ type DatabaseInterface interface {
 
 	Close() error
 }
+
+// Batch is a DatabaseInterface which buffers its writes in memory and applies
+// them all at once on Write, so that a group of changes (a block and all its
+// side effects) is committed all-or-nothing.
+// Reads through a batch see the writes already buffered in it, iterators only
+// see committed data.
+type Batch interface {
+	DatabaseInterface
+
+	// Write commits every buffered write atomically
+	Write() error
+	// Reset drops every buffered write
+	Reset()
+	// Len returns the number of buffered writes
+	Len() int
+	// Undo returns the committed value of every key the batch writes or
+	// deletes, applying them after Write reverts the batch
+	Undo() []UndoEntry
+}
+
+// UndoEntry restores one key to the value it had before a batch was written.
+// Append-only JSON lists keep only the number of items the list had, the
+// items appended by the batch are cut off on revert, see ApplyUndo
+type UndoEntry struct {
+	Key     []byte
+	Value   []byte
+	Exists  bool // false when the key did not exist, it must be deleted
+	IsList  bool
+	ListLen int
+}
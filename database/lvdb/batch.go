@@ -0,0 +1,204 @@
+package lvdb
+
+import (
+	"encoding/json"
+
+	"github.com/ninjadotorg/constant/database"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	lvdberr "github.com/syndtr/goleveldb/leveldb/errors"
+)
+
+// writeBatch keeps the writes of a batch in a leveldb.Batch, which is applied
+// atomically on commit, together with an index of the pending values so that
+// reads made through the batch see its own writes.
+// The committed value of every key is saved the first time the batch touches
+// it, to build the undo journal of the batch.
+type writeBatch struct {
+	batch   *leveldb.Batch
+	pending map[string][]byte // nil value marks a deleted key
+	undo    []database.UndoEntry
+}
+
+func newWriteBatch() *writeBatch {
+	return &writeBatch{
+		batch:   new(leveldb.Batch),
+		pending: make(map[string][]byte),
+	}
+}
+
+// record saves the committed value of key before the batch changes it
+func (w *writeBatch) record(lvdb store, key []byte) error {
+	if _, found := w.pending[string(key)]; found {
+		return nil
+	}
+	entry := database.UndoEntry{Key: make([]byte, len(key))}
+	copy(entry.Key, key)
+	value, err := lvdb.Get(key, nil)
+	switch err {
+	case nil:
+		entry.Value = value
+		entry.Exists = true
+	case lvdberr.ErrNotFound:
+	default:
+		return err
+	}
+	w.undo = append(w.undo, entry)
+	return nil
+}
+
+// recordList saves only the number of items of the committed JSON list at key,
+// the batch must only append to it
+func (w *writeBatch) recordList(lvdb store, key []byte) error {
+	if _, found := w.pending[string(key)]; found {
+		return nil
+	}
+	entry := database.UndoEntry{Key: make([]byte, len(key))}
+	copy(entry.Key, key)
+	value, err := lvdb.Get(key, nil)
+	switch err {
+	case nil:
+		var items []json.RawMessage
+		if err := json.Unmarshal(value, &items); err != nil {
+			return err
+		}
+		entry.Exists = true
+		entry.IsList = true
+		entry.ListLen = len(items)
+	case lvdberr.ErrNotFound:
+	default:
+		return err
+	}
+	w.undo = append(w.undo, entry)
+	return nil
+}
+
+func (w *writeBatch) put(key, value []byte) {
+	v := make([]byte, len(value))
+	copy(v, value)
+	w.batch.Put(key, v)
+	w.pending[string(key)] = v
+}
+
+func (w *writeBatch) delete(key []byte) {
+	w.batch.Delete(key)
+	w.pending[string(key)] = nil
+}
+
+// lookup returns the pending value of key; found is false when the batch
+// does not touch key at all
+func (w *writeBatch) lookup(key []byte) (value []byte, found bool) {
+	value, found = w.pending[string(key)]
+	return value, found
+}
+
+func (w *writeBatch) reset() {
+	w.batch.Reset()
+	w.pending = make(map[string][]byte)
+	w.undo = nil
+}
+
+// batch is a view of the database whose writes are buffered until Write.
+// It implements database.Batch.
+type batch struct {
+	db
+}
+
+func newBatch(lvdb store) *batch {
+	return &batch{
+		db: db{
+			lvdb:   lvdb,
+			writes: newWriteBatch(),
+		},
+	}
+}
+
+// Write commits all buffered writes to leveldb in one atomic operation
+func (b *batch) Write() error {
+	if err := b.lvdb.Write(b.writes.batch, nil); err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Write"))
+	}
+	b.writes.reset()
+	return nil
+}
+
+// Reset drops all buffered writes
+func (b *batch) Reset() {
+	b.writes.reset()
+}
+
+// Len returns number of buffered writes
+func (b *batch) Len() int {
+	return b.writes.batch.Len()
+}
+
+// Undo returns the committed values of all keys touched by the batch
+func (b *batch) Undo() []database.UndoEntry {
+	undo := make([]database.UndoEntry, len(b.writes.undo))
+	copy(undo, b.writes.undo)
+	return undo
+}
+
+// Close discards the batch, the underlying database stays open
+func (b *batch) Close() error {
+	b.writes.reset()
+	return nil
+}
+
+func (db *db) put(key, value []byte) error {
+	if db.writes != nil {
+		if err := db.writes.record(db.lvdb, key); err != nil {
+			return err
+		}
+		db.writes.put(key, value)
+		return nil
+	}
+	return db.lvdb.Put(key, value, nil)
+}
+
+// putList writes a JSON list which is the list at key with items appended,
+// its undo entry does not copy the whole list
+func (db *db) putList(key, value []byte) error {
+	if db.writes != nil {
+		if err := db.writes.recordList(db.lvdb, key); err != nil {
+			return err
+		}
+		db.writes.put(key, value)
+		return nil
+	}
+	return db.lvdb.Put(key, value, nil)
+}
+
+func (db *db) get(key []byte) ([]byte, error) {
+	if db.writes != nil {
+		if value, found := db.writes.lookup(key); found {
+			if value == nil {
+				return nil, lvdberr.ErrNotFound
+			}
+			ret := make([]byte, len(value))
+			copy(ret, value)
+			return ret, nil
+		}
+	}
+	return db.lvdb.Get(key, nil)
+}
+
+func (db *db) has(key []byte) (bool, error) {
+	if db.writes != nil {
+		if value, found := db.writes.lookup(key); found {
+			return value != nil, nil
+		}
+	}
+	return db.lvdb.Has(key, nil)
+}
+
+func (db *db) delete(key []byte) error {
+	if db.writes != nil {
+		if err := db.writes.record(db.lvdb, key); err != nil {
+			return err
+		}
+		db.writes.delete(key)
+		return nil
+	}
+	return db.lvdb.Delete(key, nil)
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -26,9 +27,9 @@ func (db *db) StoreBeaconBlock(v interface{}) error {
 	if ok, _ := db.HasValue(key); ok {
 		return database.NewDatabaseError(database.BlockExisted, errors.Errorf("block %s already exists", hash.String()))
 	}
-	val, err := json.Marshal(v)
+	val, err := codec.Marshal(v)
 	if err != nil {
-		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "codec.Marshal"))
 	}
 	if err := db.Put(key, keyB); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.Put"))
@@ -49,9 +50,9 @@ func (db *db) StoreBeaconBlockHeader(v interface{}, hash *common.Hash) error {
 	if ok, _ := db.HasValue(key); ok {
 		return database.NewDatabaseError(database.BlockExisted, errors.Errorf("block %s already exists", hash.String()))
 	}
-	val, err := json.Marshal(v)
+	val, err := codec.Marshal(v)
 	if err != nil {
-		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "codec.Marshal"))
 	}
 	if err := db.Put(key, keyB); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.Put"))
@@ -96,13 +97,18 @@ func (db *db) DeleteBeaconBlock(hash *common.Hash, idx uint64) error {
 	// Delete block
 	// bea-b-{hash}
 	key := append(append(beaconPrefix, blockKeyPrefix...), hash[:]...)
-	err := db.lvdb.Delete(key, nil)
+	err := db.delete(key)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
 	}
 	// b-{hash}
 	keyB := append(blockKeyPrefix, hash[:]...)
-	err = db.lvdb.Delete(keyB, nil)
+	err = db.delete(keyB)
+	if err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
+	}
+	// pruned-{hash}
+	err = db.delete(getPrunedKey(hash))
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
 	}
@@ -110,7 +116,7 @@ func (db *db) DeleteBeaconBlock(hash *common.Hash, idx uint64) error {
 	// delete by index
 	// bea-i-{hash} -> index
 	keyIndex := append(append(beaconPrefix, blockKeyIdxPrefix...), hash[:]...)
-	err = db.lvdb.Delete(keyIndex, nil)
+	err = db.delete(keyIndex)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -118,12 +124,12 @@ func (db *db) DeleteBeaconBlock(hash *common.Hash, idx uint64) error {
 	// index -> {hash}
 	buf := make([]byte, 8)
 	binary.LittleEndian.PutUint64(buf, idx)
-	err = db.lvdb.Delete(buf, nil)
+	err = db.delete(buf)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
 
-	err = db.lvdb.Delete(key, nil)
+	err = db.delete(key)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
 	}
@@ -153,7 +159,7 @@ func (db *db) FetchBeaconBestState() ([]byte, error) {
 
 func (db *db) CleanBeaconBestState() error {
 	key := beaconBestBlockkey
-	err := db.lvdb.Delete(key, nil)
+	err := db.delete(key)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.delete"))
 	}
@@ -165,12 +171,12 @@ func (db *db) StoreBeaconBlockIndex(h *common.Hash, idx uint64) error {
 	binary.LittleEndian.PutUint64(buf, idx)
 	key := append(append(beaconPrefix, blockKeyIdxPrefix...), h[:]...)
 	//{bea-i-{hash}}:index
-	if err := db.lvdb.Put(key, buf, nil); err != nil {
+	if err := db.put(key, buf); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	//bea-i-{index}:[hash]
 	beaconBuf := append(append(beaconPrefix, blockKeyIdxPrefix...), buf...)
-	if err := db.lvdb.Put(beaconBuf, h[:], nil); err != nil {
+	if err := db.put(beaconBuf, h[:]); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	return nil
@@ -178,7 +184,7 @@ func (db *db) StoreBeaconBlockIndex(h *common.Hash, idx uint64) error {
 
 func (db *db) GetIndexOfBeaconBlock(h *common.Hash) (uint64, error) {
 	key := append(append(beaconPrefix, blockKeyIdxPrefix...), h[:]...)
-	b, err := db.lvdb.Get(key, nil)
+	b, err := db.get(key)
 	//{bea-i-[hash]}:index
 	if err != nil {
 		return 0, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.get"))
@@ -196,7 +202,7 @@ func (db *db) GetBeaconBlockHashByIndex(idx uint64) (*common.Hash, error) {
 	binary.LittleEndian.PutUint64(buf, idx)
 	//bea-i-{index}:[hash]
 	beaconBuf := append(append(beaconPrefix, blockKeyIdxPrefix...), buf...)
-	b, err := db.lvdb.Get(beaconBuf, nil)
+	b, err := db.get(beaconBuf)
 	if err != nil {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -229,7 +235,7 @@ func (db *db) StoreAcceptedShardToBeacon(shardID byte, blkHeight uint64, shardBl
 	prefix := append([]byte{shardID}, shardBlkHash[:]...)
 	// stb-ShardID-ShardBlockHash : BeaconBlockHeight
 	key := append(shardToBeaconKeyPrefix, prefix...)
-	if err := db.lvdb.Put(key, buf, nil); err != nil {
+	if err := db.put(key, buf); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	return nil
@@ -274,7 +280,7 @@ func (db *db) StoreBeaconCommitteeByHeight(blkHeight uint64, v interface{}) erro
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
 	}
 
-	if err := db.lvdb.Put(key, val, nil); err != nil {
+	if err := db.put(key, val); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	return nil
@@ -287,7 +293,7 @@ func (db *db) FetchBeaconCommitteeByHeight(blkHeight uint64) ([]byte, error) {
 	binary.LittleEndian.PutUint64(buf, blkHeight)
 	key = append(key, buf[:]...)
 
-	b, err := db.lvdb.Get(key, nil)
+	b, err := db.get(key)
 	if err != nil {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.get"))
 	}
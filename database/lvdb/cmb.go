@@ -36,14 +36,14 @@ func (db *db) StoreCMB(
 	capital uint64,
 	txHash []byte,
 ) error {
-	ok, err := db.HasValue(mainAccount)
+	cmbInitKey := getCMBInitKey(mainAccount)
+	ok, err := db.HasValue(cmbInitKey)
 	if err != nil {
 		return errUnexpected(err, "error retrieving cmb")
 	}
-	if !ok {
+	if ok {
 		return database.NewDatabaseError(database.KeyExisted, errors.Errorf("CMB main account existed"))
 	}
-	cmbInitKey := getCMBInitKey(mainAccount)
 
 	state := metadata.CMBRequested
 	fine := uint64(0)
@@ -90,7 +90,7 @@ func parseCMBInitValue(value []byte) ([]byte, [][]byte, uint64, []byte, uint8, u
 	txHash := value[len(value)-common.HashSize-1 : len(value)-1]
 
 	// The rest: members
-	value = value[8 : len(value)-common.HashSize-2]
+	value = value[8 : len(value)-common.HashSize-1]
 	if len(value)%PaymentAddressLen != 0 {
 		return nil, nil, 0, nil, 0, 0, errors.Errorf("error parsing cmb value")
 	}
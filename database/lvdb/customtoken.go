@@ -16,7 +16,7 @@ import (
 
 func (db *db) StoreCustomToken(tokenID *common.Hash, txHash []byte) error {
 	key := db.GetKey(string(tokenInitPrefix), tokenID) // token-init-{tokenID}
-	if err := db.lvdb.Put(key, txHash, nil); err != nil {
+	if err := db.put(key, txHash); err != nil {
 		return err
 	}
 	return nil
@@ -24,7 +24,7 @@ func (db *db) StoreCustomToken(tokenID *common.Hash, txHash []byte) error {
 
 func (db *db) StorePrivacyCustomToken(tokenID *common.Hash, txHash []byte) error {
 	key := db.GetKey(string(privacyTokenInitPrefix), tokenID) // token-init-{tokenID}
-	if err := db.lvdb.Put(key, txHash, nil); err != nil {
+	if err := db.put(key, txHash); err != nil {
 		return err
 	}
 	return nil
@@ -40,7 +40,7 @@ func (db *db) StoreCustomTokenTx(tokenID *common.Hash, shardID byte, blockHeight
 	binary.LittleEndian.PutUint32(bs, uint32(bigNumber-txIndex))
 	key = append(key, bs...)
 	log.Println(string(key))
-	if err := db.lvdb.Put(key, txHash, nil); err != nil {
+	if err := db.put(key, txHash); err != nil {
 		return err
 	}
 	return nil
@@ -56,7 +56,7 @@ func (db *db) StorePrivacyCustomTokenTx(tokenID *common.Hash, shardID byte, bloc
 	binary.LittleEndian.PutUint32(bs, uint32(bigNumber-txIndex))
 	key = append(key, bs...)
 	log.Println(string(key))
-	if err := db.lvdb.Put(key, txHash, nil); err != nil {
+	if err := db.put(key, txHash); err != nil {
 		return err
 	}
 	return nil
@@ -267,7 +267,7 @@ func (db *db) UpdateRewardAccountUTXO(tokenID *common.Hash, pubkey []byte, txHas
 	reses := strings.Split(string(res), string(Splitter))
 	// {value}-unspent-unreward
 	value := reses[0] + reses[1] + string(rewared)
-	if err := db.lvdb.Put([]byte(key), []byte(value), nil); err != nil {
+	if err := db.put([]byte(key), []byte(value)); err != nil {
 		return err
 	}
 	return nil
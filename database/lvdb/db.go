@@ -8,7 +8,11 @@ import (
 )
 
 type db struct {
-	lvdb *leveldb.DB
+	lvdb store
+
+	// writes buffers every change made through a batch view of the
+	// database, nil when writes go straight to leveldb.
+	writes *writeBatch
 }
 
 type hasher interface {
@@ -45,6 +49,8 @@ var (
 	loanRequestPostfix        = []byte("-req")
 	loanResponsePostfix       = []byte("-res")
 	rewared                   = []byte("reward")
+	undoPrefix                = []byte("undo-")
+	prunedPrefix              = []byte("pruned-")
 
 	//vote prefix
 	voteBoardSumPrefix            = []byte("votesumboard-")
@@ -86,12 +92,22 @@ func open(dbPath string) (database.DatabaseInterface, error) {
 	return &db{lvdb: lvdb}, nil
 }
 
+// openMem opens a database kept in memory by memStore, keys and values are
+// laid out the same as on disk but the engine under them is not leveldb
+func openMem() (database.DatabaseInterface, error) {
+	return &db{lvdb: newMemStore()}, nil
+}
+
+func (db *db) NewBatch() database.Batch {
+	return newBatch(db.lvdb)
+}
+
 func (db *db) Close() error {
 	return errors.Wrap(db.lvdb.Close(), "db.lvdb.Close")
 }
 
 func (db *db) HasValue(key []byte) (bool, error) {
-	ret, err := db.lvdb.Has(key, nil)
+	ret, err := db.has(key)
 	if err != nil {
 		return false, database.NewDatabaseError(database.NotExistValue, err)
 	}
@@ -99,14 +115,14 @@ func (db *db) HasValue(key []byte) (bool, error) {
 }
 
 func (db *db) Put(key, value []byte) error {
-	if err := db.lvdb.Put(key, value, nil); err != nil {
+	if err := db.put(key, value); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Put"))
 	}
 	return nil
 }
 
 func (db *db) Delete(key []byte) error {
-	err := db.lvdb.Delete(key, nil)
+	err := db.delete(key)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
 	}
@@ -114,7 +130,7 @@ func (db *db) Delete(key []byte) error {
 }
 
 func (db *db) Get(key []byte) ([]byte, error) {
-	value, err := db.lvdb.Get(key, nil)
+	value, err := db.get(key)
 	if err != nil {
 		return nil, database.NewDatabaseError(database.LvDbNotFound, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -14,6 +14,16 @@ func init() {
 	if err := database.RegisterDriver(driver); err != nil {
 		panic("failed to register db driver")
 	}
+
+	// memdb keeps everything in memory and loses it on Close,
+	// used by tests and ephemeral nodes
+	memDriver := database.Driver{
+		DbType: "memdb",
+		Open:   openMemDriver,
+	}
+	if err := database.RegisterDriver(memDriver); err != nil {
+		panic("failed to register memdb driver")
+	}
 }
 
 func openDriver(args ...interface{}) (database.DatabaseInterface, error) {
@@ -26,3 +36,10 @@ func openDriver(args ...interface{}) (database.DatabaseInterface, error) {
 	}
 	return open(dbPath)
 }
+
+func openMemDriver(args ...interface{}) (database.DatabaseInterface, error) {
+	if len(args) != 0 {
+		return nil, errors.New("invalid arguments")
+	}
+	return openMem()
+}
@@ -0,0 +1,88 @@
+package lvdb
+
+import (
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// migrations of the lvdb key layout, in order. Version N upgrades a database
+// from version N-1, append new migrations at the end and never edit or
+// reorder the released ones.
+var migrations = []database.Migration{
+	{
+		Version:     1,
+		Description: "initial key layout, stamp schema version on data written before versioning",
+		Migrate: func(db database.DatabaseInterface, batch database.Batch, interrupt <-chan struct{}) error {
+			return nil
+		},
+	},
+	{
+		Version:     2,
+		Description: "re-encode stored blocks from json to the binary codec",
+		Migrate:     migrateBlocksToCodec,
+	},
+}
+
+func init() {
+	for _, dbType := range []string{"leveldb", "memdb"} {
+		for _, m := range migrations {
+			if err := database.RegisterMigration(dbType, m); err != nil {
+				panic("failed to register db migration")
+			}
+		}
+	}
+}
+
+// blockMigrationChunkSize is the number of blocks re-encoded by
+// migrateBlocksToCodec in one committed chunk
+const blockMigrationChunkSize = 1000
+
+// migrateBlocksToCodec re-encodes every b-{hash} block which is still in json,
+// blocks are decoded by database.DecodeJSONBlock.
+// The chain does not fit in one batch, blocks are committed by chunks and
+// blocks already encoded are skipped, so an interrupted run is resumed.
+func migrateBlocksToCodec(db database.DatabaseInterface, batch database.Batch, interrupt <-chan struct{}) error {
+	iter := db.NewIterator(util.BytesPrefix(blockKeyPrefix), nil)
+	defer iter.Release()
+	chunkSize := 0
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != len(blockKeyPrefix)+32 || codec.IsEncoded(iter.Value()) {
+			continue
+		}
+		hash := key[len(blockKeyPrefix):]
+		// bea-b-{hash} is set for beacon blocks only
+		isBeacon, err := db.HasValue(append(append(append([]byte{}, beaconPrefix...), blockKeyPrefix...), hash...))
+		if err != nil {
+			return err
+		}
+		block, err := database.DecodeJSONBlock(iter.Value(), isBeacon)
+		if err != nil {
+			return errors.Wrapf(err, "block %x", hash)
+		}
+		value, err := codec.Marshal(block)
+		if err != nil {
+			return errors.Wrapf(err, "block %x", hash)
+		}
+		if err := batch.Put(append([]byte{}, key...), value); err != nil {
+			return err
+		}
+		chunkSize++
+		if chunkSize < blockMigrationChunkSize {
+			continue
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		chunkSize = 0
+		select {
+		case <-interrupt:
+			return errors.New("block encoding migration interrupted")
+		default:
+		}
+	}
+	// the last chunk is committed with the schema version
+	return iter.Error()
+}
@@ -0,0 +1,38 @@
+package lvdb
+
+import (
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/database"
+	"github.com/pkg/errors"
+)
+
+func getPrunedKey(hash *common.Hash) []byte {
+	// pruned-{blockhash}
+	return append(append([]byte{}, prunedPrefix...), hash[:]...)
+}
+
+// PruneBlockBody replaces the data of a shard or beacon block by v, the same
+// block without its body, and marks the block as pruned
+func (db *db) PruneBlockBody(hash *common.Hash, v interface{}) error {
+	val, err := codec.Marshal(v)
+	if err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "codec.Marshal"))
+	}
+	// {b-blockhash}:block without body
+	if err := db.put(db.GetKey(string(blockKeyPrefix), hash), val); err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
+	}
+	if err := db.put(getPrunedKey(hash), []byte{1}); err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
+	}
+	return nil
+}
+
+func (db *db) IsBlockPruned(hash *common.Hash) (bool, error) {
+	ok, err := db.has(getPrunedKey(hash))
+	if err != nil {
+		return false, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.has"))
+	}
+	return ok, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/pkg/errors"
 	lvdberr "github.com/syndtr/goleveldb/leveldb/errors"
@@ -27,9 +28,9 @@ func (db *db) StoreShardBlock(v interface{}, shardID byte) error {
 	if ok, _ := db.HasValue(key); ok {
 		return database.NewDatabaseError(database.BlockExisted, errors.Errorf("block %s already exists", hash.String()))
 	}
-	val, err := json.Marshal(v)
+	val, err := codec.Marshal(v)
 	if err != nil {
-		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "codec.Marshal"))
 	}
 	if err := db.Put(key, keyB); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.Put"))
@@ -51,9 +52,9 @@ func (db *db) StoreShardBlockHeader(v interface{}, hash *common.Hash, shardID by
 	if ok, _ := db.HasValue(key); ok {
 		return database.NewDatabaseError(database.BlockExisted, errors.Errorf("block %s already exists", hash.String()))
 	}
-	val, err := json.Marshal(v)
+	val, err := codec.Marshal(v)
 	if err != nil {
-		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "codec.Marshal"))
 	}
 	if err := db.Put(key, keyB); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.Put"))
@@ -75,7 +76,7 @@ func (db *db) HasBlock(hash *common.Hash) (bool, error) {
 }
 
 func (db *db) FetchBlock(hash *common.Hash) ([]byte, error) {
-	block, err := db.lvdb.Get(db.GetKey(string(blockKeyPrefix), hash), nil)
+	block, err := db.get(db.GetKey(string(blockKeyPrefix), hash))
 	if err != nil {
 		if err != lvdberr.ErrNotFound {
 			return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
@@ -89,23 +90,28 @@ func (db *db) FetchBlock(hash *common.Hash) ([]byte, error) {
 
 func (db *db) DeleteBlock(hash *common.Hash, idx uint64, shardID byte) error {
 	// Delete block
-	err := db.lvdb.Delete(db.GetKey(string(blockKeyPrefix), hash), nil)
+	err := db.delete(db.GetKey(string(blockKeyPrefix), hash))
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
 
 	// Delete block index
-	err = db.lvdb.Delete(db.GetKey(string(blockKeyIdxPrefix), hash), nil)
+	err = db.delete(db.GetKey(string(blockKeyIdxPrefix), hash))
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
 	buf := make([]byte, 9)
 	binary.LittleEndian.PutUint64(buf, idx)
 	buf[8] = shardID
-	err = db.lvdb.Delete(buf, nil)
+	err = db.delete(buf)
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
+	// Delete pruned mark
+	err = db.delete(getPrunedKey(hash))
+	if err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Delete"))
+	}
 	return nil
 }
 
@@ -133,7 +139,7 @@ func (db *db) FetchBestState(shardID byte) ([]byte, error) {
 func (db *db) CleanBestState() error {
 	for shardID := byte(0); shardID < common.TotalValidators; shardID++ {
 		key := append(bestBlockKey, shardID)
-		err := db.lvdb.Delete(key, nil)
+		err := db.delete(key)
 		if err != nil {
 			return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.delete"))
 		}
@@ -146,18 +152,18 @@ func (db *db) StoreShardBlockIndex(h *common.Hash, idx uint64, shardID byte) err
 	binary.LittleEndian.PutUint64(buf, idx)
 	buf[8] = shardID
 	//{i-[hash]}:index-shardID
-	if err := db.lvdb.Put(db.GetKey(string(blockKeyIdxPrefix), h), buf, nil); err != nil {
+	if err := db.put(db.GetKey(string(blockKeyIdxPrefix), h), buf); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	//{index-shardID}:[hash]
-	if err := db.lvdb.Put(buf, h[:], nil); err != nil {
+	if err := db.put(buf, h[:]); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	return nil
 }
 
 func (db *db) GetIndexOfBlock(h *common.Hash) (uint64, byte, error) {
-	b, err := db.lvdb.Get(db.GetKey(string(blockKeyIdxPrefix), h), nil)
+	b, err := db.get(db.GetKey(string(blockKeyIdxPrefix), h))
 	//{i-[hash]}:index-shardID
 	if err != nil {
 		return 0, 0, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.get"))
@@ -180,7 +186,7 @@ func (db *db) GetBlockByIndex(idx uint64, shardID byte) (*common.Hash, error) {
 	buf[8] = shardID
 	// {index-shardID}: {blockhash}
 
-	b, err := db.lvdb.Get(buf, nil)
+	b, err := db.get(buf)
 	if err != nil {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -235,7 +241,7 @@ func (db *db) StoreIncomingCrossShard(shardID byte, crossShardID byte, blkHeight
 	if ok, _ := db.HasValue(key); ok {
 		return database.NewDatabaseError(database.BlockExisted, errors.Errorf("block %s already exists"))
 	}
-	if err := db.lvdb.Put(key, buf, nil); err != nil {
+	if err := db.put(key, buf); err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
 	}
 	return nil
@@ -0,0 +1,140 @@
+package lvdb
+
+import (
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	lvdberr "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// store is the key value engine under db, *leveldb.DB for the "leveldb"
+// driver and memStore for the "memdb" driver
+type store interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	Has(key []byte, ro *opt.ReadOptions) (bool, error)
+	Put(key, value []byte, wo *opt.WriteOptions) error
+	Delete(key []byte, wo *opt.WriteOptions) error
+	Write(batch *leveldb.Batch, wo *opt.WriteOptions) error
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+	Close() error
+}
+
+/*
+memStore - store kept in a skiplist in memory, without journal, tables or
+compaction of leveldb. Space of deleted or overwritten values is not reused,
+it is meant for tests and ephemeral nodes
+*/
+type memStore struct {
+	mtx    sync.RWMutex
+	mem    *memdb.DB
+	closed bool
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		mem: memdb.New(comparer.DefaultComparer, 0),
+	}
+}
+
+// Get returns a copy of the value, memdb returns a slice of its own buffer
+func (s *memStore) Get(key []byte, _ *opt.ReadOptions) ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return nil, leveldb.ErrClosed
+	}
+	value, err := s.mem.Get(key)
+	if err != nil {
+		return nil, lvdberr.ErrNotFound
+	}
+	ret := make([]byte, len(value))
+	copy(ret, value)
+	return ret, nil
+}
+
+func (s *memStore) Has(key []byte, _ *opt.ReadOptions) (bool, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return false, leveldb.ErrClosed
+	}
+	return s.mem.Contains(key), nil
+}
+
+func (s *memStore) Put(key, value []byte, _ *opt.WriteOptions) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return leveldb.ErrClosed
+	}
+	return s.mem.Put(key, value)
+}
+
+func (s *memStore) Delete(key []byte, _ *opt.WriteOptions) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return leveldb.ErrClosed
+	}
+	// leveldb does not fail on deleting a missing key
+	if err := s.mem.Delete(key); err != nil && err != memdb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// Write applies every write of batch under one lock, so readers see all or
+// none of them
+func (s *memStore) Write(batch *leveldb.Batch, _ *opt.WriteOptions) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return leveldb.ErrClosed
+	}
+	return batch.Replay(memReplay{mem: s.mem})
+}
+
+// NewIterator iterates a snapshot of the keys in slice, writes made after it
+// is created are not seen
+func (s *memStore) NewIterator(slice *util.Range, _ *opt.ReadOptions) iterator.Iterator {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.closed {
+		return iterator.NewEmptyIterator(leveldb.ErrClosed)
+	}
+	snapshot := memdb.New(comparer.DefaultComparer, 0)
+	iter := s.mem.NewIterator(slice)
+	defer iter.Release()
+	for iter.Next() {
+		snapshot.Put(iter.Key(), iter.Value())
+	}
+	return snapshot.NewIterator(nil)
+}
+
+func (s *memStore) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return leveldb.ErrClosed
+	}
+	s.closed = true
+	s.mem = nil
+	return nil
+}
+
+type memReplay struct {
+	mem *memdb.DB
+}
+
+func (r memReplay) Put(key, value []byte) {
+	r.mem.Put(key, value)
+}
+
+func (r memReplay) Delete(key []byte) {
+	r.mem.Delete(key)
+}
@@ -24,7 +24,7 @@ import (
 func (db *db) StoreSerialNumbers(tokenID *common.Hash, serialNumber []byte, shardID byte) error {
 	key := db.GetKey(string(serialNumbersPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -43,7 +43,7 @@ func (db *db) StoreSerialNumbers(tokenID *common.Hash, serialNumber []byte, shar
 	}
 	//keySpec1 := make([]byte, len(key))
 	keySpec1 := append(key, serialNumber...)
-	if err := db.lvdb.Put(keySpec1, newIndex, nil); err != nil {
+	if err := db.put(keySpec1, newIndex); err != nil {
 		return err
 	}
 
@@ -52,7 +52,7 @@ func (db *db) StoreSerialNumbers(tokenID *common.Hash, serialNumber []byte, shar
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
 	}
-	if err := db.lvdb.Put(key, b, nil); err != nil {
+	if err := db.putList(key, b); err != nil {
 		return err
 	}
 	return nil
@@ -62,7 +62,7 @@ func (db *db) StoreSerialNumbers(tokenID *common.Hash, serialNumber []byte, shar
 func (db *db) FetchSerialNumbers(tokenID *common.Hash, shardID byte) ([][]byte, error) {
 	key := db.GetKey(string(serialNumbersPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return make([][]byte, 0), database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -121,7 +121,7 @@ func (db *db) HasSerialNumber(tokenID *common.Hash, serialNumber []byte, shardID
 func (db *db) CleanSerialNumbers() error {
 	iter := db.lvdb.NewIterator(util.BytesPrefix(serialNumbersPrefix), nil)
 	for iter.Next() {
-		err := db.lvdb.Delete(iter.Key(), nil)
+		err := db.delete(iter.Key())
 		if err != nil {
 			return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 		}
@@ -140,7 +140,7 @@ func (db *db) StoreOutputCoins(tokenID *common.Hash, pubkey []byte, outputcoin [
 	// store for pubkey:[outcoint1, outcoint2, ...]
 	key = append(key, pubkey...)
 	var arrDatabyPubkey [][]byte
-	resByPubkey, err := db.lvdb.Get(key, nil)
+	resByPubkey, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -154,7 +154,7 @@ func (db *db) StoreOutputCoins(tokenID *common.Hash, pubkey []byte, outputcoin [
 	if err != nil {
 		return err
 	}
-	if err := db.lvdb.Put(key, resByPubkey, nil); err != nil {
+	if err := db.putList(key, resByPubkey); err != nil {
 		return err
 	}
 
@@ -165,7 +165,7 @@ func (db *db) StoreOutputCoins(tokenID *common.Hash, pubkey []byte, outputcoin [
 func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments []byte, shardID byte) error {
 	key := db.GetKey(string(commitmentsPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -185,21 +185,21 @@ func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments
 	}
 	//keySpec1 := make([]byte, len(key))
 	keySpec1 := append(key, newIndex...)
-	if err := db.lvdb.Put(keySpec1, commitments, nil); err != nil {
+	if err := db.put(keySpec1, commitments); err != nil {
 		return err
 	}
 
 	// use for validate
 	//keySpec2 := make([]byte, len(key))
 	keySpec2 := append(key, commitments...)
-	if err := db.lvdb.Put(keySpec2, newIndex, nil); err != nil {
+	if err := db.put(keySpec2, newIndex); err != nil {
 		return err
 	}
 
 	// store length of array commitment
 	//keySpec3 := make([]byte, len(key))
 	keySpec3 := append(key, []byte("len")...)
-	if err := db.lvdb.Put(keySpec3, newIndex, nil); err != nil {
+	if err := db.put(keySpec3, newIndex); err != nil {
 		return err
 	}
 
@@ -207,7 +207,7 @@ func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments
 	//keySpec4 := make([]byte, len(key))
 	keySpec4 := append(key, pubkey...)
 	var arrDatabyPubkey [][]byte
-	resByPubkey, err := db.lvdb.Get(keySpec4, nil)
+	resByPubkey, err := db.get(keySpec4)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -221,7 +221,7 @@ func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments
 	if err != nil {
 		return err
 	}
-	if err := db.lvdb.Put(keySpec4, resByPubkey, nil); err != nil {
+	if err := db.putList(keySpec4, resByPubkey); err != nil {
 		return err
 	}
 
@@ -230,7 +230,7 @@ func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
 	}
-	if err := db.lvdb.Put(key, b, nil); err != nil {
+	if err := db.putList(key, b); err != nil {
 		return err
 	}
 	return nil
@@ -240,7 +240,7 @@ func (db *db) StoreCommitments(tokenID *common.Hash, pubkey []byte, commitments
 func (db *db) FetchCommitments(tokenID *common.Hash, shardID byte) ([][]byte, error) {
 	key := db.GetKey(string(commitmentsPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return make([][]byte, 0), database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -337,7 +337,7 @@ func (db *db) GetCommitmentIndexsByPubkey(tokenID *common.Hash, pubkey []byte, s
 	//keySpec4 := make([]byte, len(key))
 	keySpec4 := append(key, pubkey...)
 	var arrDatabyPubkey [][]byte
-	resByPubkey, err := db.lvdb.Get(keySpec4, nil)
+	resByPubkey, err := db.get(keySpec4)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -355,7 +355,7 @@ func (db *db) GetOutcoinsByPubkey(tokenID *common.Hash, pubkey []byte, shardID b
 
 	key = append(key, pubkey...)
 	var arrDatabyPubkey [][]byte
-	resByPubkey, err := db.lvdb.Get(key, nil)
+	resByPubkey, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -371,7 +371,7 @@ func (db *db) GetOutcoinsByPubkey(tokenID *common.Hash, pubkey []byte, shardID b
 func (db *db) CleanCommitments() error {
 	iter := db.lvdb.NewIterator(util.BytesPrefix(commitmentsPrefix), nil)
 	for iter.Next() {
-		err := db.lvdb.Delete(iter.Key(), nil)
+		err := db.delete(iter.Key())
 		if err != nil {
 			return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 		}
@@ -387,7 +387,7 @@ func (db *db) CleanCommitments() error {
 func (db *db) StoreSNDerivators(tokenID *common.Hash, data big.Int, shardID byte) error {
 	key := db.GetKey(string(snderivatorsPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -396,7 +396,7 @@ func (db *db) StoreSNDerivators(tokenID *common.Hash, data big.Int, shardID byte
 	snderivatorData := data.Bytes()
 	//keySpec := make([]byte, len(key))
 	keySpec := append(key, snderivatorData...)
-	if err := db.lvdb.Put(keySpec, snderivatorData, nil); err != nil {
+	if err := db.put(keySpec, snderivatorData); err != nil {
 		return err
 	}
 
@@ -411,7 +411,7 @@ func (db *db) StoreSNDerivators(tokenID *common.Hash, data big.Int, shardID byte
 	if err != nil {
 		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
 	}
-	if err := db.lvdb.Put(key, b, nil); err != nil {
+	if err := db.putList(key, b); err != nil {
 		return err
 	}
 	return nil
@@ -421,7 +421,7 @@ func (db *db) StoreSNDerivators(tokenID *common.Hash, data big.Int, shardID byte
 func (db *db) FetchSNDerivator(tokenID *common.Hash, shardID byte) ([]big.Int, error) {
 	key := db.GetKey(string(snderivatorsPrefix), tokenID)
 	key = append(key, shardID)
-	res, err := db.lvdb.Get(key, nil)
+	res, err := db.get(key)
 	if err != nil && err != lvdberr.ErrNotFound {
 		return make([]big.Int, 0), database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -460,7 +460,7 @@ func (db *db) HasSNDerivator(tokenID *common.Hash, data big.Int, shardID byte) (
 func (db *db) CleanSNDerivator() error {
 	iter := db.lvdb.NewIterator(util.BytesPrefix(snderivatorsPrefix), nil)
 	for iter.Next() {
-		err := db.lvdb.Delete(iter.Key(), nil)
+		err := db.delete(iter.Key())
 		if err != nil {
 			return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 		}
@@ -482,7 +482,7 @@ func (db *db) StoreFeeEstimator(val []byte, shardID byte) error {
 
 // GetFeeEstimator - Get data for FeeEstimator object as a json in byte format
 func (db *db) GetFeeEstimator(shardID byte) ([]byte, error) {
-	b, err := db.lvdb.Get(append(feeEstimator, shardID), nil)
+	b, err := db.get(append(feeEstimator, shardID))
 	if err != nil {
 		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 	}
@@ -493,7 +493,7 @@ func (db *db) GetFeeEstimator(shardID byte) ([]byte, error) {
 func (db *db) CleanFeeEstimator() error {
 	iter := db.lvdb.NewIterator(util.BytesPrefix(feeEstimator), nil)
 	for iter.Next() {
-		err := db.lvdb.Delete(iter.Key(), nil)
+		err := db.delete(iter.Key())
 		if err != nil {
 			return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.Get"))
 		}
@@ -514,7 +514,7 @@ func (db *db) CleanFeeEstimator() error {
 func (db *db) StoreTransactionIndex(txId *common.Hash, blockHash *common.Hash, index int) error {
 	key := string(transactionKeyPrefix) + txId.String()
 	value := blockHash.String() + string(Splitter) + strconv.Itoa(index)
-	if err := db.lvdb.Put([]byte(key), []byte(value), nil); err != nil {
+	if err := db.put([]byte(key), []byte(value)); err != nil {
 		return err
 	}
 
@@ -647,7 +647,7 @@ for iter.Next() {
 	if err != nil {
 		return nil, nil, err
 	}
-	value, err := db.lvdb.Get([]byte(key), nil)
+	value, err := db.get([]byte(key))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -655,7 +655,7 @@ for iter.Next() {
 	if err1 != nil {
 		return nil, nil, err
 	}
-	tx, err := db.lvdb.Get([]byte(value), nil)
+	tx, err := db.get([]byte(value))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,45 @@
+package lvdb
+
+import (
+	"encoding/json"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/database"
+	"github.com/pkg/errors"
+)
+
+func getUndoKey(blockHash *common.Hash) []byte {
+	// undo-{blockhash}
+	return append(append([]byte{}, undoPrefix...), blockHash[:]...)
+}
+
+// StoreUndo stores the undo journal of a block, the writes which revert it
+func (db *db) StoreUndo(blockHash *common.Hash, entries []database.UndoEntry) error {
+	val, err := json.Marshal(entries)
+	if err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Marshal"))
+	}
+	if err := db.put(getUndoKey(blockHash), val); err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.put"))
+	}
+	return nil
+}
+
+func (db *db) FetchUndo(blockHash *common.Hash) ([]database.UndoEntry, error) {
+	val, err := db.get(getUndoKey(blockHash))
+	if err != nil {
+		return nil, database.NewDatabaseError(database.LvDbNotFound, errors.Wrapf(err, "undo journal of block %s", blockHash.String()))
+	}
+	var entries []database.UndoEntry
+	if err := json.Unmarshal(val, &entries); err != nil {
+		return nil, database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "json.Unmarshal"))
+	}
+	return entries, nil
+}
+
+func (db *db) DeleteUndo(blockHash *common.Hash) error {
+	if err := db.delete(getUndoKey(blockHash)); err != nil {
+		return database.NewDatabaseError(database.UnexpectedError, errors.Wrap(err, "db.lvdb.delete"))
+	}
+	return nil
+}
@@ -30,7 +30,7 @@ func (db *db) AddVoteBoard(
 	//add to sum amount of vote token to this candidate
 	key := GetKeyVoteBoardSum(boardType, boardIndex, &CandidatePaymentAddress)
 
-	currentVoteInBytes, err := db.lvdb.Get(key, nil)
+	currentVoteInBytes, err := db.get(key)
 	if err != nil {
 		currentVoteInBytes = make([]byte, 8)
 		binary.LittleEndian.PutUint64(currentVoteInBytes, uint64(0))
@@ -48,7 +48,7 @@ func (db *db) AddVoteBoard(
 
 	// add to count amount of vote to this candidate
 	key = GetKeyVoteBoardCount(boardType, boardIndex, CandidatePaymentAddress)
-	currentCountInBytes, err := db.lvdb.Get(key, nil)
+	currentCountInBytes, err := db.get(key)
 	if err != nil {
 		currentCountInBytes = make([]byte, 4)
 		binary.LittleEndian.PutUint32(currentCountInBytes, uint32(0))
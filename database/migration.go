@@ -0,0 +1,206 @@
+package database
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// schemaVersionKey holds the version of the key layout a database was written with
+var schemaVersionKey = []byte("schema-version")
+
+// Migration upgrades the data of a database from schema version Version-1 to Version.
+// Migrate reads from db and must write every change into batch, what is left
+// in batch is committed together with the new schema version.
+// A migration whose writes fit in memory is applied all-or-nothing this way.
+// A migration over data of unbounded size, ex: every block, must commit its
+// writes in chunks with batch.Write, it is then applied again over its own
+// partial result when it is interrupted or the node stops before the schema
+// version is updated, so it must skip data which is already migrated.
+// Long running migrations should stop between chunks and return an error
+// when interrupt is closed.
+type Migration struct {
+	Version     uint32
+	Description string
+	Migrate     func(db DatabaseInterface, batch Batch, interrupt <-chan struct{}) error
+}
+
+// MigrationResult describes one migration step run (or planned in dry-run mode) on Open
+type MigrationResult struct {
+	Version     uint32
+	Description string
+	// Changes is the number of writes made by the migration, or which
+	// would be made in dry-run mode
+	Changes int
+	DryRun  bool
+}
+
+// MigrationOptions can be passed as last argument of Open to control the
+// migrations run on the opened database.
+type MigrationOptions struct {
+	// DryRun runs every pending migration without committing anything,
+	// so that Progress reports what would change. The schema version is left
+	// untouched. Later steps see the data as it was before earlier steps,
+	// chunks written by a migration are dropped instead of committed.
+	DryRun bool
+
+	// Interrupt specifies a channel the caller can close to stop migrating,
+	// the database stays at the version of the last completed migration.
+	//
+	// This field can be nil if the caller does not desire the behavior.
+	Interrupt <-chan struct{}
+
+	// Progress is called after every migration step (optional)
+	Progress func(MigrationResult)
+}
+
+var migrations = make(map[string][]Migration)
+
+// JSONBlockDecoder decodes a block stored in json before the binary codec into
+// a value which common/codec encodes, light mode shard blocks may hold only
+// their header
+type JSONBlockDecoder func(data []byte, isBeacon bool) (interface{}, error)
+
+var jsonBlockDecoder JSONBlockDecoder
+
+// SetJSONBlockDecoder is called by the package defining blocks, the database
+// layer does not know their types
+func SetJSONBlockDecoder(decoder JSONBlockDecoder) {
+	jsonBlockDecoder = decoder
+}
+
+// DecodeJSONBlock decodes data with the decoder set by SetJSONBlockDecoder
+func DecodeJSONBlock(data []byte, isBeacon bool) (interface{}, error) {
+	if jsonBlockDecoder == nil {
+		return nil, NewDatabaseError(MigrationErr, errors.New("no json block decoder is set"))
+	}
+	return jsonBlockDecoder(data, isBeacon)
+}
+
+// RegisterMigration adds m to the ordered list of migrations of the driver dbType.
+// Migrations must be registered in order, starting at version 1.
+func RegisterMigration(dbType string, m Migration) error {
+	if expected := uint32(len(migrations[dbType]) + 1); m.Version != expected {
+		return NewDatabaseError(MigrationErr, errors.Errorf("migration %d of driver %s registered out of order, expected version %d", m.Version, dbType, expected))
+	}
+	migrations[dbType] = append(migrations[dbType], m)
+	return nil
+}
+
+// LatestSchemaVersion returns the schema version of a database of type dbType
+// once all its registered migrations are applied
+func LatestSchemaVersion(dbType string) uint32 {
+	return uint32(len(migrations[dbType]))
+}
+
+// SchemaVersion returns the schema version stored in db, databases written
+// before versioning was introduced are at version 0
+func SchemaVersion(db DatabaseInterface) (uint32, error) {
+	ok, err := db.HasValue(schemaVersionKey)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, nil
+	}
+	value, err := db.Get(schemaVersionKey)
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 4 {
+		return 0, NewDatabaseError(SchemaVersionErr, errors.Errorf("invalid schema version %x", value))
+	}
+	return binary.BigEndian.Uint32(value), nil
+}
+
+func putSchemaVersion(db DatabaseInterface, version uint32) error {
+	value := make([]byte, 4)
+	binary.BigEndian.PutUint32(value, version)
+	return db.Put(schemaVersionKey, value)
+}
+
+// migrationBatch is the batch given to migrations, it counts the writes of
+// the chunks committed by a migration, and drops them in dry-run mode
+type migrationBatch struct {
+	Batch
+	dryRun  bool
+	changes int
+}
+
+func (batch *migrationBatch) Write() error {
+	batch.changes += batch.Batch.Len()
+	if batch.dryRun {
+		batch.Batch.Reset()
+		return nil
+	}
+	return batch.Batch.Write()
+}
+
+func isEmpty(db DatabaseInterface) bool {
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	return !iter.Next()
+}
+
+// migrate brings db to the latest schema version of dbType. A new database is
+// stamped with the latest version directly, there is nothing to migrate.
+func migrate(db DatabaseInterface, dbType string, options *MigrationOptions) error {
+	if options == nil {
+		options = &MigrationOptions{}
+	}
+	latest := LatestSchemaVersion(dbType)
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return NewDatabaseError(SchemaVersionErr, errors.Errorf("database schema version %d is newer than supported version %d", version, latest))
+	}
+	if version == latest {
+		return nil
+	}
+	if version == 0 && isEmpty(db) {
+		if options.DryRun {
+			return nil
+		}
+		return putSchemaVersion(db, latest)
+	}
+
+	batch := &migrationBatch{
+		Batch:  db.NewBatch(),
+		dryRun: options.DryRun,
+	}
+	defer batch.Reset()
+	for _, m := range migrations[dbType][version:] {
+		select {
+		case <-options.Interrupt:
+			return NewDatabaseError(MigrationInterrupted, errors.Errorf("stopped before migration %d", m.Version))
+		default:
+		}
+
+		batch.changes = 0
+		if err := m.Migrate(db, batch, options.Interrupt); err != nil {
+			return NewDatabaseError(MigrationErr, errors.Wrapf(err, "migration %d (%s)", m.Version, m.Description))
+		}
+		result := MigrationResult{
+			Version:     m.Version,
+			Description: m.Description,
+			Changes:     batch.changes + batch.Len(),
+			DryRun:      options.DryRun,
+		}
+		if options.DryRun {
+			batch.Reset()
+		} else {
+			if err := putSchemaVersion(batch, m.Version); err != nil {
+				return err
+			}
+			if err := batch.Batch.Write(); err != nil {
+				return NewDatabaseError(MigrationErr, errors.Wrapf(err, "migration %d (%s)", m.Version, m.Description))
+			}
+		}
+		if options.Progress != nil {
+			options.Progress(result)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ninjadotorg/constant/database"
+	_ "github.com/ninjadotorg/constant/database/lvdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// migrationTestDB is returned by the "migrationtest" driver, so that tests
+// can prepare the data found by migrations
+var migrationTestDB database.DatabaseInterface
+
+// onMigrationChunk is called after every chunk committed by the rename migration
+var onMigrationChunk func()
+
+// noCloseDB keeps the test database open when Open closes it on a failed migration
+type noCloseDB struct {
+	database.DatabaseInterface
+}
+
+func (noCloseDB) Close() error {
+	return nil
+}
+
+func init() {
+	database.RegisterDriver(database.Driver{
+		DbType: "migrationtest",
+		Open: func(args ...interface{}) (database.DatabaseInterface, error) {
+			return noCloseDB{migrationTestDB}, nil
+		},
+	})
+	database.RegisterMigration("migrationtest", database.Migration{
+		Version:     1,
+		Description: "noop",
+		Migrate: func(db database.DatabaseInterface, batch database.Batch, interrupt <-chan struct{}) error {
+			return nil
+		},
+	})
+	database.RegisterMigration("migrationtest", database.Migration{
+		Version:     2,
+		Description: "rename old- keys to new-, one key per chunk",
+		Migrate: func(db database.DatabaseInterface, batch database.Batch, interrupt <-chan struct{}) error {
+			// renamed keys are deleted, a resumed migration does not see them
+			iter := db.NewIterator(util.BytesPrefix([]byte("old-")), nil)
+			defer iter.Release()
+			for iter.Next() {
+				key := append([]byte("new-"), iter.Key()[len("old-"):]...)
+				batch.Put(key, iter.Value())
+				batch.Delete(iter.Key())
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				if onMigrationChunk != nil {
+					onMigrationChunk()
+				}
+				select {
+				case <-interrupt:
+					return errors.New("rename interrupted")
+				default:
+				}
+			}
+			return iter.Error()
+		},
+	})
+}
+
+func newMigrationTestDB(t *testing.T, legacy bool) {
+	db, err := database.Open("memdb")
+	if err != nil {
+		t.Fatalf("database.Open memdb %+v", err)
+	}
+	// start from an empty database, memdb is stamped with its own version
+	db.Delete([]byte("schema-version"))
+	if legacy {
+		// data written before versioning
+		db.Put([]byte("old-a"), []byte{1})
+		db.Put([]byte("old-b"), []byte{2})
+	}
+	migrationTestDB = db
+}
+
+func TestRegisterMigrationOutOfOrder(t *testing.T) {
+	err := database.RegisterMigration("migrationtest", database.Migration{Version: 5})
+	if err == nil {
+		t.Fatalf("migration registered out of order should be rejected")
+	}
+}
+
+func TestMigrateNewDatabase(t *testing.T) {
+	newMigrationTestDB(t, false)
+	db, err := database.Open("migrationtest")
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	defer db.Close()
+	if version, _ := database.SchemaVersion(db); version != 2 {
+		t.Fatalf("new database should be at latest version, got %d", version)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	newMigrationTestDB(t, true)
+	var results []database.MigrationResult
+	db, err := database.Open("migrationtest", &database.MigrationOptions{
+		DryRun: true,
+		Progress: func(result database.MigrationResult) {
+			results = append(results, result)
+		},
+	})
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	if len(results) != 2 || results[0].Changes != 0 || results[1].Changes != 4 {
+		t.Fatalf("dry run should report 2 migrations, got %+v", results)
+	}
+	if version, _ := database.SchemaVersion(db); version != 0 {
+		t.Fatalf("dry run should not change schema version, got %d", version)
+	}
+	for _, key := range []string{"old-a", "old-b"} {
+		if ok, _ := db.HasValue([]byte(key)); !ok {
+			t.Fatalf("dry run should not change data, %s is missing", key)
+		}
+	}
+
+	db, err = database.Open("migrationtest")
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	defer db.Close()
+	if version, _ := database.SchemaVersion(db); version != 2 {
+		t.Fatalf("database should be migrated to latest version, got %d", version)
+	}
+	for _, key := range []string{"a", "b"} {
+		if ok, _ := db.HasValue([]byte("old-" + key)); ok {
+			t.Fatalf("old key %s should be migrated", key)
+		}
+		if ok, _ := db.HasValue([]byte("new-" + key)); !ok {
+			t.Fatalf("new key %s should be written by migration", key)
+		}
+	}
+}
+
+func TestMigrateResumesChunkedMigration(t *testing.T) {
+	newMigrationTestDB(t, true)
+	db := migrationTestDB
+	interrupt := make(chan struct{})
+	onMigrationChunk = func() {
+		close(interrupt)
+		onMigrationChunk = nil
+	}
+	defer func() {
+		onMigrationChunk = nil
+	}()
+	if _, err := database.Open("migrationtest", &database.MigrationOptions{Interrupt: interrupt}); err == nil {
+		t.Fatalf("interrupted migration should return an error")
+	}
+	// first chunk is committed, schema version is the one of the last completed migration
+	if version, err := database.SchemaVersion(db); err != nil || version != 1 {
+		t.Fatalf("interrupted migration should leave schema version 1, got %d, %+v", version, err)
+	}
+	if ok, _ := db.HasValue([]byte("new-a")); !ok {
+		t.Fatalf("first chunk should be committed")
+	}
+	if ok, _ := db.HasValue([]byte("old-b")); !ok {
+		t.Fatalf("second chunk should not be committed")
+	}
+
+	var results []database.MigrationResult
+	_, err := database.Open("migrationtest", &database.MigrationOptions{
+		Progress: func(result database.MigrationResult) {
+			results = append(results, result)
+		},
+	})
+	if err != nil {
+		t.Fatalf("database.Open %+v", err)
+	}
+	if len(results) != 1 || results[0].Version != 2 || results[0].Changes != 2 {
+		t.Fatalf("resumed migration should only migrate the second key, got %+v", results)
+	}
+	if version, _ := database.SchemaVersion(db); version != 2 {
+		t.Fatalf("database should be migrated to latest version, got %d", version)
+	}
+	for _, key := range []string{"a", "b"} {
+		if ok, _ := db.HasValue([]byte("new-" + key)); !ok {
+			t.Fatalf("new key %s should be written by migration", key)
+		}
+	}
+}
+
+func TestMigrateInterrupted(t *testing.T) {
+	newMigrationTestDB(t, true)
+	db := migrationTestDB
+	interrupt := make(chan struct{})
+	close(interrupt)
+	if _, err := database.Open("migrationtest", &database.MigrationOptions{Interrupt: interrupt}); err == nil {
+		t.Fatalf("interrupted migration should return an error")
+	}
+	if version, err := database.SchemaVersion(db); err != nil || version != 0 {
+		t.Fatalf("interrupted migration should not change schema version, got %d, %+v", version, err)
+	}
+}
+
+func TestMigrateNewerSchemaVersion(t *testing.T) {
+	newMigrationTestDB(t, false)
+	migrationTestDB.Put([]byte("schema-version"), []byte{0, 0, 0, 3})
+	if _, err := database.Open("migrationtest"); err == nil {
+		t.Fatalf("database with a newer schema version should be rejected")
+	}
+}
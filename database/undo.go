@@ -0,0 +1,49 @@
+package database
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+/*
+ApplyUndo - restore every key of an undo journal through db, usually a Batch
+so that the whole journal is applied at once
+*/
+func ApplyUndo(db DatabaseInterface, entries []UndoEntry) error {
+	for _, entry := range entries {
+		var err error
+		switch {
+		case !entry.Exists:
+			err = db.Delete(entry.Key)
+		case entry.IsList:
+			err = truncateList(db, entry.Key, entry.ListLen)
+		default:
+			err = db.Put(entry.Key, entry.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// truncateList keeps the first n items of the JSON list at key
+func truncateList(db DatabaseInterface, key []byte, n int) error {
+	value, err := db.Get(key)
+	if err != nil {
+		return err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(value, &items); err != nil {
+		return NewDatabaseError(UnexpectedError, errors.Wrap(err, "json.Unmarshal"))
+	}
+	if len(items) < n {
+		return NewDatabaseError(UnexpectedError, errors.Errorf("list has %d items, undo expects at least %d", len(items), n))
+	}
+	value, err = json.Marshal(items[:n])
+	if err != nil {
+		return NewDatabaseError(UnexpectedError, errors.Wrap(err, "json.Marshal"))
+	}
+	return db.Put(key, value)
+}
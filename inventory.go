@@ -0,0 +1,250 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/peer"
+	"github.com/ninjadotorg/constant/wire"
+)
+
+// a tx or block requested by getdata is not requested again from another peer
+// until the request times out
+const requestedInventoryTimeout = 30 * time.Second
+
+// peers which announced a requested tx or block and are asked in turn when
+// the delivered copy is rejected
+const maxInventoryAnnouncers = 8
+
+type inventoryRequest struct {
+	expiration time.Time
+	announcers []*peer.PeerConn
+}
+
+// inventoryRequests - txs and blocks requested by getdata
+type inventoryRequests struct {
+	mtx      sync.Mutex
+	requests map[common.Hash]*inventoryRequest
+}
+
+/*
+request - mark hash as requested from peerConn, false if it is already
+requested and the request has not timed out, then peerConn is kept to be asked
+if the delivered copy is rejected
+*/
+func (requests *inventoryRequests) request(hash common.Hash, peerConn *peer.PeerConn, now time.Time) bool {
+	requests.mtx.Lock()
+	defer requests.mtx.Unlock()
+	if requests.requests == nil {
+		requests.requests = make(map[common.Hash]*inventoryRequest)
+	}
+	for requestedHash, request := range requests.requests {
+		if now.After(request.expiration) {
+			delete(requests.requests, requestedHash)
+		}
+	}
+	if request, ok := requests.requests[hash]; ok {
+		if len(request.announcers) < maxInventoryAnnouncers {
+			request.announcers = append(request.announcers, peerConn)
+		}
+		return false
+	}
+	requests.requests[hash] = &inventoryRequest{
+		expiration: now.Add(requestedInventoryTimeout),
+	}
+	return true
+}
+
+// received - the request of hash is done
+func (requests *inventoryRequests) received(hash common.Hash) {
+	requests.mtx.Lock()
+	defer requests.mtx.Unlock()
+	delete(requests.requests, hash)
+}
+
+/*
+retry - the delivered copy of hash is rejected, returns the next connected
+peer which announced it and renews the request, nil if there is none
+*/
+func (requests *inventoryRequests) retry(hash common.Hash, now time.Time) *peer.PeerConn {
+	requests.mtx.Lock()
+	defer requests.mtx.Unlock()
+	request, ok := requests.requests[hash]
+	if !ok {
+		return nil
+	}
+	for len(request.announcers) > 0 {
+		peerConn := request.announcers[0]
+		request.announcers = request.announcers[1:]
+		if peerConn.GetIsConnected() {
+			request.expiration = now.Add(requestedInventoryTimeout)
+			return peerConn
+		}
+	}
+	delete(requests.requests, hash)
+	return nil
+}
+
+/*
+relayInventory - announce msg by an inv message to peers which relay by
+inv/getdata and push it in full to the others, peers which already have it are
+skipped. It returns false if msg is not relayed by inventory
+*/
+func (serverObj *Server) relayInventory(msg wire.Message) bool {
+	invVect, ok := wire.InvVectOfMessage(msg)
+	if !ok {
+		return false
+	}
+	var dc chan<- struct{}
+	for _, peerConn := range serverObj.connManager.Config.ListenerPeer.GetPeerConnOfAll() {
+		if peerConn.HasKnownInventory(invVect.Hash) {
+			continue
+		}
+		peerConn.AddKnownInventory(invVect.Hash)
+		if !peerConn.GetInvRelay() {
+			peerConn.QueueMessageWithEncoding(msg, dc, peer.MESSAGE_TO_PEER, nil)
+			continue
+		}
+		msgInv, err := wire.MakeEmptyMessage(wire.CmdInv)
+		if err != nil {
+			Logger.log.Error(err)
+			return true
+		}
+		msgInv.(*wire.MessageInv).InvList = []wire.InvVect{*invVect}
+		msgInv.(*wire.MessageInv).Timestamp = time.Now().UnixNano()
+		msgInv.SetSenderID(serverObj.connManager.Config.ListenerPeer.PeerID)
+		peerConn.QueueMessageWithEncoding(msgInv, dc, peer.MESSAGE_TO_PEER, nil)
+	}
+	return true
+}
+
+/*
+OnInv is invoked when a peer announces txs or blocks, the ones this node lacks
+and has not requested recently are requested by a getdata message
+*/
+func (serverObj *Server) OnInv(peerConn *peer.PeerConn, msg *wire.MessageInv) {
+	Logger.log.Info("Receive inv message START")
+	invList := []wire.InvVect{}
+	now := time.Now()
+	for _, invVect := range msg.InvList {
+		peerConn.AddKnownInventory(invVect.Hash)
+		if serverObj.haveInventory(invVect) || !serverObj.requestedInventory.request(invVect.Hash, peerConn, now) {
+			continue
+		}
+		invList = append(invList, invVect)
+	}
+	serverObj.pushGetData(peerConn, invList)
+	Logger.log.Info("Receive inv message END")
+}
+
+/*
+OnTxRejected is invoked when mempool rejects a tx for its signature, proof or
+data, which tx hash does not commit to. The tx is requested again from another
+peer which announced it, it may send a valid copy
+*/
+func (serverObj *Server) OnTxRejected(txHash common.Hash) {
+	peerConn := serverObj.requestedInventory.retry(txHash, time.Now())
+	if peerConn == nil {
+		return
+	}
+	Logger.log.Infof("Request tx %s again from peer %s", txHash.String(), peerConn.RemotePeerID.Pretty())
+	serverObj.pushGetData(peerConn, []wire.InvVect{*wire.NewInvVect(wire.InvTypeTx, &txHash)})
+}
+
+func (serverObj *Server) pushGetData(peerConn *peer.PeerConn, invList []wire.InvVect) {
+	if len(invList) == 0 {
+		return
+	}
+	msgGetData, err := wire.MakeEmptyMessage(wire.CmdGetData)
+	if err != nil {
+		Logger.log.Error(err)
+		return
+	}
+	msgGetData.(*wire.MessageGetData).InvList = invList
+	msgGetData.(*wire.MessageGetData).Timestamp = time.Now().UnixNano()
+	msgGetData.SetSenderID(serverObj.connManager.Config.ListenerPeer.PeerID)
+	var dc chan<- struct{}
+	peerConn.QueueMessageWithEncoding(msgGetData, dc, peer.MESSAGE_TO_PEER, nil)
+}
+
+/*
+OnGetData is invoked when a peer requests txs or blocks announced by this node,
+the ones which are not in mempool or chain anymore are skipped
+*/
+func (serverObj *Server) OnGetData(peerConn *peer.PeerConn, msg *wire.MessageGetData) {
+	Logger.log.Info("Receive getdata message START")
+	var dc chan<- struct{}
+	for _, invVect := range msg.InvList {
+		var msgData wire.Message
+		var err error
+		switch invVect.Type {
+		case wire.InvTypeTx:
+			var tx metadata.Transaction
+			tx, err = serverObj.memPool.GetTx(&invVect.Hash)
+			if err != nil {
+				break
+			}
+			msgData, err = newMessageTx(tx)
+		case wire.InvTypeBlockShard:
+			var block *blockchain.ShardBlock
+			block, err = serverObj.blockChain.GetShardBlockByHash(&invVect.Hash)
+			if err != nil {
+				break
+			}
+			msgData, err = wire.MakeEmptyMessage(wire.CmdBlockShard)
+			if err != nil {
+				break
+			}
+			msgData.(*wire.MessageBlockShard).Block = *block
+		case wire.InvTypeBlockBeacon:
+			var block *blockchain.BeaconBlock
+			block, err = serverObj.blockChain.GetBeaconBlockByHash(&invVect.Hash)
+			if err != nil {
+				break
+			}
+			msgData, err = wire.MakeEmptyMessage(wire.CmdBlockBeacon)
+			if err != nil {
+				break
+			}
+			msgData.(*wire.MessageBlockBeacon).Block = *block
+		}
+		if err != nil || msgData == nil {
+			Logger.log.Infof("Can not serve inventory %d %s: %+v", invVect.Type, invVect.Hash.String(), err)
+			continue
+		}
+		peerConn.AddKnownInventory(invVect.Hash)
+		msgData.SetSenderID(serverObj.connManager.Config.ListenerPeer.PeerID)
+		peerConn.QueueMessageWithEncoding(msgData, dc, peer.MESSAGE_TO_PEER, nil)
+	}
+	Logger.log.Info("Receive getdata message END")
+}
+
+// receivedInventory - remote peer sends a block of hash, it knows the hash
+// and the request of it is done. The request of a tx is kept until it expires,
+// so the tx is requested again if it is rejected
+func (serverObj *Server) receivedInventory(peerConn *peer.PeerConn, hash common.Hash) {
+	peerConn.AddKnownInventory(hash)
+	serverObj.requestedInventory.received(hash)
+}
+
+// haveInventory returns true if tx is in mempool or chain, or block is in chain
+func (serverObj *Server) haveInventory(invVect wire.InvVect) bool {
+	switch invVect.Type {
+	case wire.InvTypeTx:
+		if serverObj.memPool.HaveTransaction(&invVect.Hash) {
+			return true
+		}
+		_, _, dbErr := serverObj.dataBase.GetTransactionIndexById(&invVect.Hash)
+		return dbErr == nil
+	case wire.InvTypeBlockShard:
+		ok, err := serverObj.dataBase.HasBlock(&invVect.Hash)
+		return err == nil && ok
+	case wire.InvTypeBlockBeacon:
+		ok, err := serverObj.dataBase.HasBeaconBlock(&invVect.Hash)
+		return err == nil && ok
+	}
+	return false
+}
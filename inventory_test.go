@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/peer"
+)
+
+func newTestPeerConn(connected bool) *peer.PeerConn {
+	peerConn := &peer.PeerConn{}
+	peerConn.SetIsConnected(connected)
+	return peerConn
+}
+
+func TestInventoryRequestsRetry(t *testing.T) {
+	requests := inventoryRequests{}
+	hash := common.HashH([]byte("tx"))
+	now := time.Now()
+	first, second, disconnected, third := newTestPeerConn(true), newTestPeerConn(true), newTestPeerConn(false), newTestPeerConn(true)
+
+	if !requests.request(hash, first, now) {
+		t.Fatalf("first announcement should be requested")
+	}
+	for _, peerConn := range []*peer.PeerConn{second, disconnected, third} {
+		if requests.request(hash, peerConn, now) {
+			t.Fatalf("announcement of a requested tx should not be requested again")
+		}
+	}
+
+	// delivered copy is rejected, announcers are asked in turn
+	if peerConn := requests.retry(hash, now); peerConn != second {
+		t.Fatalf("retry() should ask the second announcer")
+	}
+	if peerConn := requests.retry(hash, now); peerConn != third {
+		t.Fatalf("retry() should skip the disconnected announcer")
+	}
+	if peerConn := requests.retry(hash, now); peerConn != nil {
+		t.Fatalf("retry() without announcers left should return nil")
+	}
+	if !requests.request(hash, first, now) {
+		t.Fatalf("tx should be requested again once every announcer is asked")
+	}
+}
+
+func TestInventoryRequestsExpire(t *testing.T) {
+	requests := inventoryRequests{}
+	hash := common.HashH([]byte("tx"))
+	now := time.Now()
+	requests.request(hash, newTestPeerConn(true), now)
+	if requests.request(hash, newTestPeerConn(true), now.Add(requestedInventoryTimeout)) {
+		t.Fatalf("request should not be renewed before it times out")
+	}
+	if !requests.request(hash, newTestPeerConn(true), now.Add(requestedInventoryTimeout+time.Second)) {
+		t.Fatalf("timed out request should be renewed")
+	}
+
+	requests.received(hash)
+	if !requests.request(hash, newTestPeerConn(true), now) {
+		t.Fatalf("received inventory should be requested again when announced")
+	}
+	if peerConn := requests.retry(common.HashH([]byte("unknown")), now); peerConn != nil {
+		t.Fatalf("retry() of inventory not requested should return nil")
+	}
+}
+
+func TestInventoryRequestsAnnouncersLimit(t *testing.T) {
+	requests := inventoryRequests{}
+	hash := common.HashH([]byte("tx"))
+	now := time.Now()
+	requests.request(hash, newTestPeerConn(true), now)
+	for i := 0; i < maxInventoryAnnouncers*2; i++ {
+		requests.request(hash, newTestPeerConn(true), now)
+	}
+	retried := 0
+	for requests.retry(hash, now) != nil {
+		retried++
+	}
+	if retried != maxInventoryAnnouncers {
+		t.Fatalf("retried %d announcers, want %d", retried, maxInventoryAnnouncers)
+	}
+}
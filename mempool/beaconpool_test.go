@@ -1,35 +0,0 @@
-package mempool
-
-import (
-	"reflect"
-	"testing"
-
-	"github.com/ninjadotorg/constant/blockchain"
-)
-
-func TestGetBeaconBlock(t *testing.T) {
-	type args struct {
-		ShardId     byte
-		BlockHeight uint64
-	}
-	tests := []struct {
-		name    string
-		args    args
-		want    blockchain.ShardToBeaconBlock
-		wantErr bool
-	}{
-	// TODO: Add test cases.
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetBeaconBlock(tt.args.ShardId, tt.args.BlockHeight)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("GetBeaconBlock() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("GetBeaconBlock() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
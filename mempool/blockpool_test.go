@@ -0,0 +1,206 @@
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+)
+
+func newShardBlock(shardID byte, height uint64, timestamp int64) blockchain.ShardBlock {
+	block := blockchain.ShardBlock{}
+	block.Header.ShardID = shardID
+	block.Header.Height = height
+	block.Header.Timestamp = timestamp
+	block.Header.Version = blockchain.VERSION
+	return block
+}
+
+// TestNodeShardPoolInstances checks pools of two nodes in one process are
+// independent, and capacity and eviction below best height of a pool
+func TestNodeShardPoolInstances(t *testing.T) {
+	pool1 := NewNodeShardPool(NodeBlockPoolConfig{MaxBlocks: 2})
+	pool2 := NewNodeShardPool(NodeBlockPoolConfig{MaxBlocks: 2})
+
+	if err := pool1.PushBlock(newShardBlock(0, 1, 1)); err != nil {
+		t.Fatalf("PushBlock() error = %v", err)
+	}
+	if blocks, _ := pool2.GetBlocks(0, 1); len(blocks) != 0 {
+		t.Errorf("pool2 has %d blocks of pool1", len(blocks))
+	}
+
+	// same block is only kept once
+	if err := pool1.PushBlock(newShardBlock(0, 1, 1)); err != nil {
+		t.Fatalf("PushBlock() error = %v", err)
+	}
+	if err := pool1.PushBlock(newShardBlock(0, 2, 1)); err != nil {
+		t.Fatalf("PushBlock() error = %v", err)
+	}
+	if err := pool1.PushBlock(newShardBlock(0, 3, 1)); err == nil {
+		t.Errorf("PushBlock() to full shard pool should fail")
+	}
+	// capacity is per shard
+	if err := pool1.PushBlock(newShardBlock(1, 3, 1)); err != nil {
+		t.Errorf("PushBlock() to another shard error = %v", err)
+	}
+
+	pool1.RemoveBlocksBelow(0, 1)
+	if blocks, _ := pool1.GetBlocks(0, 1); len(blocks) != 0 {
+		t.Errorf("block below best height is not evicted")
+	}
+	if blocks, _ := pool1.GetBlocks(0, 2); len(blocks) != 1 {
+		t.Errorf("block above best height is evicted")
+	}
+	if err := pool1.PushBlock(newShardBlock(0, 3, 1)); err != nil {
+		t.Errorf("PushBlock() after eviction error = %v", err)
+	}
+}
+
+// a full pool evicts its highest blocks for lower ones
+func TestNodeBlockPoolEvictsHighest(t *testing.T) {
+	shardPool := NewNodeShardPool(NodeBlockPoolConfig{MaxBlocks: 2})
+	shardPool.PushBlock(newShardBlock(0, 5, 1))
+	shardPool.PushBlock(newShardBlock(0, 9, 1))
+	if err := shardPool.PushBlock(newShardBlock(0, 7, 1)); err != nil {
+		t.Fatalf("PushBlock() of a lower block to full pool error = %v", err)
+	}
+	if blocks, _ := shardPool.GetBlocks(0, 9); len(blocks) != 0 {
+		t.Errorf("highest block is not evicted")
+	}
+	if blocks, _ := shardPool.GetBlocks(0, 7); len(blocks) != 1 {
+		t.Errorf("lower block is not kept")
+	}
+	if err := shardPool.PushBlock(newShardBlock(0, 8, 1)); err == nil {
+		t.Errorf("PushBlock() of the highest block to full pool should fail")
+	}
+	// a block is not evicted for another one of the same height
+	shardPool.PushBlock(newShardBlock(0, 6, 1))
+	if err := shardPool.PushBlock(newShardBlock(0, 6, 2)); err == nil {
+		t.Errorf("PushBlock() at the highest height of full pool should fail")
+	}
+	if blocks, _ := shardPool.GetBlocks(0, 6); len(blocks) != 1 || shardPool.count[0] != 2 {
+		t.Errorf("pool should hold 2 blocks, %d at height 6", len(blocks))
+	}
+
+	beaconPool := NewNodeBeaconPool(NodeBlockPoolConfig{MaxBlocks: 1})
+	block := blockchain.BeaconBlock{}
+	block.Header.Height = 9
+	beaconPool.PushBlock(block)
+	block.Header.Height = 4
+	if err := beaconPool.PushBlock(block); err != nil {
+		t.Fatalf("PushBlock() of a lower block to full pool error = %v", err)
+	}
+	if blocks, _ := beaconPool.GetBlocks(9); len(blocks) != 0 || beaconPool.count != 1 {
+		t.Errorf("highest beacon block is not evicted")
+	}
+}
+
+func TestNodeBeaconPoolInstances(t *testing.T) {
+	pool1 := NewNodeBeaconPool(NodeBlockPoolConfig{MaxBlocks: 1})
+	pool2 := NewNodeBeaconPool(NodeBlockPoolConfig{MaxBlocks: 1})
+
+	block := blockchain.BeaconBlock{}
+	block.Header.Height = 5
+	if err := pool1.PushBlock(block); err != nil {
+		t.Fatalf("PushBlock() error = %v", err)
+	}
+	if blocks, _ := pool2.GetBlocks(5); len(blocks) != 0 {
+		t.Errorf("pool2 has %d blocks of pool1", len(blocks))
+	}
+	block.Header.Height = 6
+	if err := pool1.PushBlock(block); err == nil {
+		t.Errorf("PushBlock() to full pool should fail")
+	}
+	pool1.RemoveBlocksBelow(5)
+	if err := pool1.PushBlock(block); err != nil {
+		t.Errorf("PushBlock() after eviction error = %v", err)
+	}
+}
+
+func TestCrossShardPoolRemoveBlock(t *testing.T) {
+	pool1 := NewCrossShardPool(DefaultCrossShardPoolConfig)
+	pool2 := NewCrossShardPool(DefaultCrossShardPoolConfig)
+	for height := uint64(1); height <= 3; height++ {
+		block := blockchain.CrossShardBlock{}
+		block.Header.ShardID = 1
+		block.Header.Height = height
+		if err := pool1.AddCrossShardBlock(block); err != nil {
+			t.Fatalf("AddCrossShardBlock() error = %v", err)
+		}
+	}
+	if state := pool2.GetCrossShardPoolState(); len(state) != 0 {
+		t.Errorf("pool2 has blocks of pool1: %+v", state)
+	}
+
+	pool1.RemoveBlock(map[byte]uint64{1: 2})
+	state := pool1.GetCrossShardPoolState()
+	if len(state[1]) != 1 || state[1][0] != 3 {
+		t.Errorf("GetCrossShardPoolState() = %+v, want only height 3 of shard 1", state)
+	}
+}
+
+func TestShardToBeaconPoolRemovePendingBlock(t *testing.T) {
+	pool1 := NewShardToBeaconPool(DefaultShardToBeaconPoolConfig)
+	pool2 := NewShardToBeaconPool(DefaultShardToBeaconPoolConfig)
+	for height := uint64(1); height <= 2; height++ {
+		block := blockchain.ShardToBeaconBlock{}
+		block.Header.Height = height
+		pool1.pending[0] = append(pool1.pending[0], block)
+	}
+	block := blockchain.ShardToBeaconBlock{}
+	block.Header.Height = 2
+	pool1.queue[0] = map[uint64]blockchain.ShardToBeaconBlock{2: block}
+	if state := pool2.GetShardToBeaconPoolState(); len(state) != 0 {
+		t.Errorf("pool2 has blocks of pool1: %+v", state)
+	}
+
+	// every pending block is at or below best height of shard
+	pool1.RemovePendingBlock(map[byte]uint64{0: 2})
+	if len(pool1.GetFinalBlock()[0]) != 0 {
+		t.Errorf("pending blocks at or below best height are not removed")
+	}
+	if len(pool1.queue[0]) != 0 {
+		t.Errorf("queued blocks at or below best height are not removed")
+	}
+}
+
+func TestOrphanBlockPool(t *testing.T) {
+	pool := NewOrphanBlockPool(OrphanBlockPoolConfig{MaxShardBlocks: 2, MaxCrossShardBlocks: 2, Expiry: time.Hour})
+	parent := newShardBlock(0, 1, 1)
+	parentHash := *parent.Hash()
+	child := newShardBlock(0, 2, 2)
+	child.Header.PrevBlockHash = parentHash
+	child.Header.BeaconHeight = 5
+	pool.AddShardBlock(child)
+
+	// beacon block of child is not inserted yet
+	if blocks := pool.GetShardBlocks(0, parentHash, 4); len(blocks) != 0 {
+		t.Errorf("GetShardBlocks() returned block waiting for beacon height 5")
+	}
+	if blocks := pool.GetShardBlocks(0, common.Hash{}, 5); len(blocks) != 0 {
+		t.Errorf("GetShardBlocks() returned block of another parent")
+	}
+	blocks := pool.GetShardBlocks(0, parentHash, 5)
+	if len(blocks) != 1 || *blocks[0].Hash() != *child.Hash() {
+		t.Fatalf("GetShardBlocks() = %d blocks, want the child", len(blocks))
+	}
+	if shardBlocks, _ := pool.Count(); shardBlocks != 0 {
+		t.Errorf("GetShardBlocks() did not remove returned block")
+	}
+
+	for height := uint64(1); height <= 3; height++ {
+		block := blockchain.CrossShardBlock{}
+		block.Header.ShardID = 1
+		block.Header.Height = height
+		block.Header.Version = blockchain.VERSION
+		pool.AddCrossShardBlock(block)
+	}
+	if _, crossShardBlocks := pool.Count(); crossShardBlocks != 2 {
+		t.Fatalf("Count() = %d cross shard blocks, want 2", crossShardBlocks)
+	}
+	crossShardBlocks := pool.GetCrossShardBlocks(map[byte]uint64{1: 2})
+	if len(crossShardBlocks) != 1 || crossShardBlocks[0].Header.Height != 2 {
+		t.Errorf("GetCrossShardBlocks() = %+v, want block of height 2", crossShardBlocks)
+	}
+}
@@ -1,9 +1,14 @@
 package mempool
 
+import "time"
+
 const (
 	// UnminedHeight is the height used for the "block" height field of the
 	// contextual transaction information provided in a transaction store
 	// when it has not yet been mined into a block.
 	UnminedHeight = 0x7fffffff
 	MaxVersion    = 1
+
+	// DumpTxsInterval is the interval used to dump the pool to file
+	DumpTxsInterval = time.Minute * 5
 )
@@ -2,6 +2,7 @@ package mempool
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"sort"
 	"sync"
@@ -9,16 +10,37 @@ import (
 	"github.com/ninjadotorg/constant/blockchain"
 )
 
-var shardPoolLock sync.RWMutex
+// CrossShardPoolConfig - capacity of pool of cross shard blocks
+type CrossShardPoolConfig struct {
+	// max number of blocks kept for each sender shard
+	MaxBlocks int
+}
 
-var shardPool = map[byte]map[uint64][]blockchain.CrossShardBlock{}
+var DefaultCrossShardPoolConfig = CrossShardPoolConfig{
+	MaxBlocks: 1000,
+}
 
-type CrossShardPool struct{}
+type CrossShardPool struct {
+	config CrossShardPoolConfig
+	mtx    sync.RWMutex
+	pool   map[byte]map[uint64][]blockchain.CrossShardBlock
+	count  map[byte]int
+}
+
+func NewCrossShardPool(config CrossShardPoolConfig) *CrossShardPool {
+	return &CrossShardPool{
+		config: config,
+		pool:   make(map[byte]map[uint64][]blockchain.CrossShardBlock),
+		count:  make(map[byte]int),
+	}
+}
 
 func (pool *CrossShardPool) GetBlock(bestStateInfos map[byte]uint64) map[byte][]blockchain.CrossShardBlock {
 	results := map[byte][]blockchain.CrossShardBlock{}
 
-	for ShardId, shardItems := range shardPool {
+	pool.mtx.RLock()
+	defer pool.mtx.RUnlock()
+	for ShardId, shardItems := range pool.pool {
 		if shardItems == nil || len(shardItems) <= 0 {
 			continue
 		}
@@ -45,28 +67,28 @@ func (pool *CrossShardPool) GetBlock(bestStateInfos map[byte]uint64) map[byte][]
 	return results
 }
 
+// RemoveBlock drops blocks of each shard up to the given height
 func (pool *CrossShardPool) RemoveBlock(blockItems map[byte]uint64) error {
 	if len(blockItems) <= 0 {
 		log.Println("Block items empty")
 		return nil
 	}
 
-	shardPoolLock.Lock()
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
 	for shardID, blockHeight := range blockItems {
-		shardItems, ok := shardPool[shardID]
+		shardItems, ok := pool.pool[shardID]
 		if !ok || len(shardItems) <= 0 {
-			log.Println("Shard is not exist")
 			continue
 		}
 
-		items := map[uint64][]blockchain.CrossShardBlock{}
-		for i := blockHeight + 1; i < uint64(len(shardItems)); i++ {
-			items[i] = shardItems[i]
+		for height, blocks := range shardItems {
+			if height <= blockHeight {
+				pool.count[shardID] -= len(blocks)
+				delete(shardItems, height)
+			}
 		}
-
-		shardPool[shardID] = items
 	}
-	shardPoolLock.Unlock()
 	return nil
 }
 
@@ -80,52 +102,41 @@ func (pool *CrossShardPool) AddCrossShardBlock(newBlock blockchain.CrossShardBlo
 		return errors.New("Invalid Block Heght")
 	}
 
-	shardPoolLock.Lock()
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
 
-	shardItems, ok := shardPool[ShardID]
+	shardItems, ok := pool.pool[ShardID]
 	if shardItems == nil || !ok {
 		shardItems = map[uint64][]blockchain.CrossShardBlock{}
+		pool.pool[ShardID] = shardItems
 	}
 
-	items, ok := shardItems[Height]
-	if len(items) <= 0 || !ok {
-		items = []blockchain.CrossShardBlock{}
+	for _, block := range shardItems[Height] {
+		if block.Header.Hash() == newBlock.Header.Hash() {
+			return nil
+		}
 	}
-	items = append(items, newBlock)
-
-	shardItems[Height] = items
-	shardPool[ShardID] = shardItems
-
-	shardPoolLock.Unlock()
+	if pool.config.MaxBlocks > 0 && pool.count[ShardID] >= pool.config.MaxBlocks {
+		return fmt.Errorf("Cross shard pool of shard %+v reach limit %+v", ShardID, pool.config.MaxBlocks)
+	}
+	shardItems[Height] = append(shardItems[Height], newBlock)
+	pool.count[ShardID]++
 
 	return nil
 }
 
-func GetCrossShardPoolState() map[byte][]uint64 {
-	// map[byte]map[uint64][]blockchain.CrossShardBlock{}
+func (pool *CrossShardPool) GetCrossShardPoolState() map[byte][]uint64 {
 	result := map[byte][]uint64{}
 
-	poolState := map[byte]map[uint64]bool{}
-
-	for k, val := range shardPool {
-		items, ok := poolState[k]
-		if !ok || len(items) <= 0 {
-			items = map[uint64]bool{}
-		}
-
+	pool.mtx.RLock()
+	defer pool.mtx.RUnlock()
+	for k, val := range pool.pool {
 		if len(val) <= 0 {
 			continue
 		}
 
-		for h, _ := range val {
-			items[h] = true
-		}
-		poolState[k] = items
-	}
-
-	for k, val := range poolState {
 		items := []uint64{}
-		for h, _ := range val {
+		for h := range val {
 			items = append(items, h)
 		}
 		sort.Slice(items, func(i, j int) bool {
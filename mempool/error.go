@@ -16,6 +16,12 @@ const (
 	CanNotCheckDoubleSpend
 	DatabaseError
 	ShardToBeaconBoolError
+	RejectMempoolFull
+	RejectTxSize
+	RejectReplacementFee
+	RejectUntrackedShard
+	RejectNoShardState
+	RejectOrphanTx
 )
 
 var ErrCodeMessage = map[int]struct {
@@ -31,6 +37,12 @@ var ErrCodeMessage = map[int]struct {
 	CanNotCheckDoubleSpend: {-1006, "Can not check double spend"},
 	DatabaseError:          {-1007, "Database Error"},
 	ShardToBeaconBoolError: {-1007, "ShardToBeaconBool Error"},
+	RejectMempoolFull:      {-1008, "Reject tx because mempool is full"},
+	RejectTxSize:           {-1009, "Reject tx which is too large"},
+	RejectReplacementFee:   {-1010, "Reject replacement tx with not enough fee"},
+	RejectUntrackedShard:   {-1011, "Reject tx of a shard not tracked by node"},
+	RejectNoShardState:     {-1012, "Reject tx of a shard without best state"},
+	RejectOrphanTx:         {-1013, "Reject tx spending unknown commitments"},
 }
 
 type MempoolTxError struct {
@@ -51,3 +63,13 @@ func (e *MempoolTxError) Init(key int, err error) {
 	e.message = ErrCodeMessage[key].message
 	e.err = errors.Wrap(err, e.message)
 }
+
+/*
+IsMalleableReject returns true if err rejects a tx for its signature, proof or
+data which tx hash does not commit to, so another copy of the tx with the same
+hash may be accepted
+*/
+func IsMalleableReject(err error) bool {
+	mempoolErr, ok := err.(MempoolTxError)
+	return ok && (mempoolErr.code == ErrCodeMessage[RejectInvalidTx].code || mempoolErr.code == ErrCodeMessage[RejectSansityTx].code)
+}
@@ -18,7 +18,7 @@ import (
 
 	"github.com/ninjadotorg/constant/blockchain"
 	"github.com/ninjadotorg/constant/common"
-	"github.com/ninjadotorg/constant/transaction"
+	"github.com/ninjadotorg/constant/metadata"
 )
 
 const (
@@ -75,6 +75,9 @@ type observedTransaction struct {
 	// The height of the block in which it was mined.
 	// If the transaction has not yet been mined, it is zero.
 	mined uint64
+
+	// The class of the transaction, fees are estimated by class.
+	class TxClass
 }
 
 func (o *observedTransaction) Serialize(w io.Writer) {
@@ -82,6 +85,7 @@ func (o *observedTransaction) Serialize(w io.Writer) {
 	binary.Write(w, binary.BigEndian, o.feeRate)
 	binary.Write(w, binary.BigEndian, o.observed)
 	binary.Write(w, binary.BigEndian, o.mined)
+	binary.Write(w, binary.BigEndian, o.class)
 }
 
 func deserializeObservedTransaction(r io.Reader) (*observedTransaction, error) {
@@ -97,6 +101,12 @@ func deserializeObservedTransaction(r io.Reader) (*observedTransaction, error) {
 	binary.Read(r, binary.BigEndian, &ot.observed)
 	binary.Read(r, binary.BigEndian, &ot.mined)
 
+	// And the class.
+	binary.Read(r, binary.BigEndian, &ot.class)
+	if ot.class >= numTxClasses {
+		return nil, fmt.Errorf("Invalid transaction class %d", ot.class)
+	}
+
 	return &ot, nil
 }
 
@@ -140,10 +150,10 @@ type FeeEstimator struct {
 
 	mtx      sync.RWMutex
 	observed map[common.Hash]*observedTransaction
-	bin      [estimateFeeDepth][]*observedTransaction
+	bin      [numTxClasses][estimateFeeDepth][]*observedTransaction
 
-	// The cached estimates.
-	cached []CoinPerKilobyte
+	// The cached estimates of each class.
+	cached [numTxClasses][]CoinPerKilobyte
 
 	// Transactions that have been removed from the bins. This allows us to
 	// revert in case of an orphaned block.
@@ -184,17 +194,29 @@ func (ef *FeeEstimator) ObserveTransaction(t *TxDesc) {
 			feeRate:  NewCoinPerKilobyte(uint64(t.Desc.Fee), size),
 			observed: t.Desc.Height,
 			mined:    UnminedHeight,
+			class:    GetTxClass(t.Desc.Tx),
 		}
 	}
 }
 
+// RemoveTransaction forgets an observed transaction which left the mempool
+// without being mined, e.g. replaced by a transaction paying a higher fee.
+func (ef *FeeEstimator) RemoveTransaction(hash *common.Hash) {
+	ef.mtx.Lock()
+	defer ef.mtx.Unlock()
+
+	if o, ok := ef.observed[*hash]; ok && o.mined == UnminedHeight {
+		delete(ef.observed, *hash)
+	}
+}
+
 // RegisterBlock informs the fee estimator of a new block to take into account.
 func (ef *FeeEstimator) RegisterBlock(block *blockchain.ShardBlock) error {
 	ef.mtx.Lock()
 	defer ef.mtx.Unlock()
 
-	// The previous sorted list is invalid, so delete it.
-	ef.cached = nil
+	// The previous sorted lists are invalid, so delete them.
+	ef.cached = [numTxClasses][]CoinPerKilobyte{}
 
 	height := block.Header.Height
 	if height != ef.lastKnownHeight+1 && ef.lastKnownHeight != UnminedHeight {
@@ -207,19 +229,14 @@ func (ef *FeeEstimator) RegisterBlock(block *blockchain.ShardBlock) error {
 	ef.numBlocksRegistered++
 
 	// Randomly order txs in block.
-	transactions := make(map[*transaction.Tx]struct{})
+	transactions := make(map[metadata.Transaction]struct{})
 	for _, t := range block.Body.Transactions {
-		switch t.GetType() {
-		case common.TxNormalType, common.TxSalaryType:
-			{
-				transactions[t.(*transaction.Tx)] = struct{}{}
-			}
-		}
+		transactions[t] = struct{}{}
 	}
 
 	// Count the number of replacements we make per bin so that we don't
 	// replace too many.
-	var replacementCounts [estimateFeeDepth]int
+	var replacementCounts [numTxClasses][estimateFeeDepth]int
 
 	// Keep track of which txs were dropped in case of an orphan block.
 	dropped := &registeredBlock{
@@ -254,20 +271,20 @@ func (ef *FeeEstimator) RegisterBlock(block *blockchain.ShardBlock) error {
 		}
 
 		// Make sure we do not replace too many transactions per min.
-		if replacementCounts[blocksToConfirm] == int(ef.maxReplacements) {
+		if replacementCounts[o.class][blocksToConfirm] == int(ef.maxReplacements) {
 			continue
 		}
 
 		o.mined = height
 
-		replacementCounts[blocksToConfirm]++
+		replacementCounts[o.class][blocksToConfirm]++
 
-		bin := ef.bin[blocksToConfirm]
+		bin := ef.bin[o.class][blocksToConfirm]
 
 		// Remove a random element and replace it with this new tx.
 		if len(bin) == int(ef.binSize) {
 			// Don't drop transactions we have just added from this same block.
-			l := int(ef.binSize) - replacementCounts[blocksToConfirm]
+			l := int(ef.binSize) - replacementCounts[o.class][blocksToConfirm]
 			drop := rand.Intn(l)
 			dropped.transactions = append(dropped.transactions, bin[drop])
 
@@ -276,7 +293,7 @@ func (ef *FeeEstimator) RegisterBlock(block *blockchain.ShardBlock) error {
 		} else {
 			bin = append(bin, o)
 		}
-		ef.bin[blocksToConfirm] = bin
+		ef.bin[o.class][blocksToConfirm] = bin
 	}
 
 	// Go through the mempool for txs that have been in too long.
@@ -343,8 +360,8 @@ func (ef *FeeEstimator) Rollback(hash *common.Hash) error {
 // rollback rolls back the effect of the last block in the stack
 // of registered blocks.
 func (ef *FeeEstimator) rollback() {
-	// The previous sorted list is invalid, so delete it.
-	ef.cached = nil
+	// The previous sorted lists are invalid, so delete them.
+	ef.cached = [numTxClasses][]CoinPerKilobyte{}
 
 	// pop the last list of dropped txs from the stack.
 	last := len(ef.dropped) - 1
@@ -358,16 +375,16 @@ func (ef *FeeEstimator) rollback() {
 	dropped := ef.dropped[last]
 
 	// where we are in each bin as we replace txs?
-	var replacementCounters [estimateFeeDepth]int
+	var replacementCounters [numTxClasses][estimateFeeDepth]int
 
 	// Go through the txs in the dropped block.
 	for _, o := range dropped.transactions {
 		// Which bin was this tx in?
 		blocksToConfirm := o.mined - o.observed - 1
 
-		bin := ef.bin[blocksToConfirm]
+		bin := ef.bin[o.class][blocksToConfirm]
 
-		var counter = replacementCounters[blocksToConfirm]
+		var counter = replacementCounters[o.class][blocksToConfirm]
 
 		// Continue to go through that bin where we left off.
 		for {
@@ -390,32 +407,34 @@ func (ef *FeeEstimator) rollback() {
 			counter++
 		}
 
-		replacementCounters[blocksToConfirm] = counter
+		replacementCounters[o.class][blocksToConfirm] = counter
 	}
 
 	// Continue going through bins to find other txs to remove
 	// which did not replace any other when they were entered.
-	for i, j := range replacementCounters {
-		for {
-			l := len(ef.bin[i])
-			if j >= l {
-				break
-			}
+	for c := range replacementCounters {
+		for i, j := range replacementCounters[c] {
+			for {
+				l := len(ef.bin[c][i])
+				if j >= l {
+					break
+				}
 
-			prev := ef.bin[i][j]
+				prev := ef.bin[c][i][j]
 
-			if prev.mined == ef.lastKnownHeight {
-				prev.mined = UnminedHeight
+				if prev.mined == ef.lastKnownHeight {
+					prev.mined = UnminedHeight
 
-				newBin := append(ef.bin[i][0:j], ef.bin[i][j+1:l]...)
-				// leak but it causes a panic when it is uncommented.
-				// ef.bin[i][j] = nil
-				ef.bin[i] = newBin
+					newBin := append(ef.bin[c][i][0:j], ef.bin[c][i][j+1:l]...)
+					// leak but it causes a panic when it is uncommented.
+					// ef.bin[c][i][j] = nil
+					ef.bin[c][i] = newBin
 
-				continue
-			}
+					continue
+				}
 
-			j++
+				j++
+			}
 		}
 	}
 
@@ -479,12 +498,12 @@ func (b *estimateFeeSet) estimateFee(confirmations int) CoinPerKilobyte {
 }
 
 // newEstimateFeeSet creates a temporary data structure that
-// can be used to find all fee estimates.
-func (ef *FeeEstimator) newEstimateFeeSet() *estimateFeeSet {
+// can be used to find all fee estimates of a class.
+func (ef *FeeEstimator) newEstimateFeeSet(txClass TxClass) *estimateFeeSet {
 	set := &estimateFeeSet{}
 
 	capacity := 0
-	for i, b := range ef.bin {
+	for i, b := range ef.bin[txClass] {
 		l := len(b)
 		set.bin[i] = uint32(l)
 		capacity += l
@@ -493,7 +512,7 @@ func (ef *FeeEstimator) newEstimateFeeSet() *estimateFeeSet {
 	set.feeRate = make([]CoinPerKilobyte, capacity)
 
 	i := 0
-	for _, b := range ef.bin {
+	for _, b := range ef.bin[txClass] {
 		for _, o := range b {
 			set.feeRate[i] = o.feeRate
 			i++
@@ -505,10 +524,10 @@ func (ef *FeeEstimator) newEstimateFeeSet() *estimateFeeSet {
 	return set
 }
 
-// estimates returns the set of all fee estimates from 1 to estimateFeeDepth
-// confirmations from now.
-func (ef *FeeEstimator) estimates() []CoinPerKilobyte {
-	set := ef.newEstimateFeeSet()
+// estimates returns the set of all fee estimates of a class from 1 to
+// estimateFeeDepth confirmations from now.
+func (ef *FeeEstimator) estimates(txClass TxClass) []CoinPerKilobyte {
+	set := ef.newEstimateFeeSet(txClass)
 
 	estimates := make([]CoinPerKilobyte, estimateFeeDepth)
 	for i := 0; i < estimateFeeDepth; i++ {
@@ -518,9 +537,9 @@ func (ef *FeeEstimator) estimates() []CoinPerKilobyte {
 	return estimates
 }
 
-// EstimateFee estimates the fee per byte to have a tx confirmed a given
-// number of blocks from now.
-func (ef *FeeEstimator) EstimateFee(numBlocks uint64) (CoinPerKilobyte, error) {
+// EstimateFee estimates the fee per byte to have a tx of a class confirmed a
+// given number of blocks from now.
+func (ef *FeeEstimator) EstimateFee(numBlocks uint64, txClass TxClass) (CoinPerKilobyte, error) {
 	ef.mtx.Lock()
 	defer ef.mtx.Unlock()
 
@@ -540,12 +559,16 @@ func (ef *FeeEstimator) EstimateFee(numBlocks uint64) (CoinPerKilobyte, error) {
 			estimateFeeBinSize)
 	}
 
+	if txClass >= numTxClasses {
+		return 0, fmt.Errorf("unknown tx class %d", txClass)
+	}
+
 	// If there are no cached results, generate them.
-	if ef.cached == nil {
-		ef.cached = ef.estimates()
+	if ef.cached[txClass] == nil {
+		ef.cached[txClass] = ef.estimates(txClass)
 	}
 
-	result := ef.cached[int(numBlocks)-1]
+	result := ef.cached[txClass][int(numBlocks)-1]
 	return result, nil
 }
 
@@ -553,7 +576,7 @@ func (ef *FeeEstimator) EstimateFee(numBlocks uint64) (CoinPerKilobyte, error) {
 // we use a version number. If the version number changes, it does not make
 // sense to try to upgrade a previous version to a new version. Instead, just
 // start fee estimation over.
-const estimateFeeSaveVersion = 1
+const estimateFeeSaveVersion = 2
 
 func deserializeRegisteredBlock(r io.Reader, txs map[uint32]*observedTransaction) (*registeredBlock, error) {
 	var lenTransactions uint32
@@ -629,13 +652,15 @@ func (ef *FeeEstimator) Save() FeeEstimatorState {
 		txCount++
 	}
 
-	// Save all the right bins.
-	for _, list := range ef.bin {
+	// Save all the right bins of every class.
+	for _, bins := range ef.bin {
+		for _, list := range bins {
 
-		binary.Write(w, binary.BigEndian, uint32(len(list)))
+			binary.Write(w, binary.BigEndian, uint32(len(list)))
 
-		for _, o := range list {
-			binary.Write(w, binary.BigEndian, observed[o])
+			for _, o := range list {
+				binary.Write(w, binary.BigEndian, observed[o])
+			}
 		}
 	}
 
@@ -689,22 +714,24 @@ func RestoreFeeEstimator(data FeeEstimatorState) (*FeeEstimator, error) {
 		ef.observed[ot.hash] = ot
 	}
 
-	// Read bins.
-	for i := 0; i < estimateFeeDepth; i++ {
-		var numTransactions uint32
-		binary.Read(r, binary.BigEndian, &numTransactions)
-		bin := make([]*observedTransaction, numTransactions)
-		for j := uint32(0); j < numTransactions; j++ {
-			var index uint32
-			binary.Read(r, binary.BigEndian, &index)
-
-			var exists bool
-			bin[j], exists = observed[index]
-			if !exists {
-				return nil, fmt.Errorf("Invalid transaction reference %d", index)
+	// Read bins of every class.
+	for c := TxClass(0); c < numTxClasses; c++ {
+		for i := 0; i < estimateFeeDepth; i++ {
+			var numTransactions uint32
+			binary.Read(r, binary.BigEndian, &numTransactions)
+			bin := make([]*observedTransaction, numTransactions)
+			for j := uint32(0); j < numTransactions; j++ {
+				var index uint32
+				binary.Read(r, binary.BigEndian, &index)
+
+				var exists bool
+				bin[j], exists = observed[index]
+				if !exists {
+					return nil, fmt.Errorf("Invalid transaction reference %d", index)
+				}
 			}
+			ef.bin[c][i] = bin
 		}
-		ef.bin[i] = bin
 	}
 
 	// Read dropped transactions.
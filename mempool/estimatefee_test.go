@@ -0,0 +1,59 @@
+package mempool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+func TestEstimateFeeByClass(t *testing.T) {
+	ef := NewFeeEstimator(DefaultEstimateFeeMaxRollback, 1)
+	ef.lastKnownHeight = 0
+
+	normalTx := newTestTx(1, 10)
+	loanTx := &transaction.Tx{Type: common.TxNormalType, LockTime: 2, Fee: 90, Metadata: &metadata.LoanRequest{
+		CollateralAmount: big.NewInt(1),
+		ReceiveAddress:   &privacy.PaymentAddress{},
+		MetadataBase:     metadata.MetadataBase{Type: metadata.LoanRequestMeta},
+	}}
+	if GetTxClass(normalTx) != TxClassNormal || GetTxClass(loanTx) != TxClassLoan {
+		t.Fatalf("GetTxClass() = %s, %s", GetTxClass(normalTx), GetTxClass(loanTx))
+	}
+	for _, tx := range []metadata.Transaction{normalTx, loanTx} {
+		ef.ObserveTransaction(&TxDesc{Desc: metadata.TxDesc{Tx: tx, Fee: tx.GetTxFee()}})
+	}
+	block := &blockchain.ShardBlock{}
+	block.Header.Height = 1
+	block.Body.Transactions = []metadata.Transaction{normalTx, loanTx}
+	if err := ef.RegisterBlock(block); err != nil {
+		t.Fatalf("RegisterBlock() error = %v", err)
+	}
+
+	check := func(ef *FeeEstimator) {
+		for txClass, want := range map[TxClass]CoinPerKilobyte{TxClassNormal: 10, TxClassLoan: 90, TxClassCustomToken: 0} {
+			fee, err := ef.EstimateFee(1, txClass)
+			if err != nil || fee != want {
+				t.Errorf("EstimateFee(1, %s) = %d, %v, want %d", txClass, fee, err, want)
+			}
+		}
+	}
+	check(ef)
+
+	restored, err := RestoreFeeEstimator(ef.Save())
+	if err != nil {
+		t.Fatalf("RestoreFeeEstimator() error = %v", err)
+	}
+	check(restored)
+
+	if txClass, err := ParseTxClass("privacytoken"); err != nil || txClass != TxClassPrivacyToken {
+		t.Errorf("ParseTxClass() = %s, %v", txClass, err)
+	}
+	if _, err := ParseTxClass("foo"); err == nil {
+		t.Errorf("ParseTxClass() accepts unknown class")
+	}
+}
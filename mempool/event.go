@@ -0,0 +1,130 @@
+package mempool
+
+import (
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// EventType - kind of change in mempool sent to subscribers
+type EventType int
+
+const (
+	EventTxAdded EventType = iota
+	EventTxMined
+	EventTxEvicted
+	EventTxRejected
+)
+
+var eventTypeStrings = map[EventType]string{
+	EventTxAdded:    "added",
+	EventTxMined:    "mined",
+	EventTxEvicted:  "evicted",
+	EventTxRejected: "rejected",
+}
+
+func (eventType EventType) String() string {
+	if s, ok := eventTypeStrings[eventType]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// Event - a tx added to or removed from mempool, or rejected by it
+type Event struct {
+	Type   EventType
+	TxHash common.Hash
+	Tx     metadata.Transaction
+	Fee    uint64
+	Time   time.Time
+
+	// code and reason of rejection, only set for EventTxRejected
+	RejectCode int
+	Err        error
+}
+
+// DefaultEventBufferSize is the number of events kept for a subscriber which
+// does not read them fast enough, next events are dropped for it
+const DefaultEventBufferSize = 1000
+
+// Subscription - events of mempool sent to one subscriber
+type Subscription struct {
+	id      uint64
+	tp      *TxPool
+	cEvents chan *Event
+}
+
+// Events returns the channel of events, it is closed on unsubscribe
+func (sub *Subscription) Events() <-chan *Event {
+	return sub.cEvents
+}
+
+// Unsubscribe stops sending events to the subscription
+func (sub *Subscription) Unsubscribe() {
+	sub.tp.subMtx.Lock()
+	defer sub.tp.subMtx.Unlock()
+	if _, ok := sub.tp.subscriptions[sub.id]; ok {
+		delete(sub.tp.subscriptions, sub.id)
+		close(sub.cEvents)
+	}
+}
+
+/*
+Subscribe - receive events of txs added, mined, evicted or rejected by
+mempool. Sending never blocks mempool, events which do not fit in the
+buffer of a slow subscriber are dropped
+*/
+func (tp *TxPool) Subscribe(bufferSize int) *Subscription {
+	if bufferSize <= 0 {
+		bufferSize = DefaultEventBufferSize
+	}
+	tp.subMtx.Lock()
+	defer tp.subMtx.Unlock()
+	sub := &Subscription{
+		id:      tp.nextSubID,
+		tp:      tp,
+		cEvents: make(chan *Event, bufferSize),
+	}
+	tp.nextSubID++
+	tp.subscriptions[sub.id] = sub
+	return sub
+}
+
+func (tp *TxPool) notify(event *Event) {
+	tp.subMtx.RLock()
+	defer tp.subMtx.RUnlock()
+	for _, sub := range tp.subscriptions {
+		select {
+		case sub.cEvents <- event:
+		default:
+			Logger.log.Warnf("Drop mempool event %s of tx %+v, subscriber is too slow", event.Type, event.TxHash.String())
+		}
+	}
+}
+
+func (tp *TxPool) notifyTx(eventType EventType, txDesc *TxDesc) {
+	tp.notify(&Event{
+		Type:   eventType,
+		TxHash: *txDesc.Desc.Tx.Hash(),
+		Tx:     txDesc.Desc.Tx,
+		Fee:    txDesc.Desc.Fee,
+		Time:   time.Now(),
+	})
+}
+
+func (tp *TxPool) notifyRejected(tx metadata.Transaction, err error) {
+	code := ErrCodeMessage[RejectInvalidTx].code
+	if mempoolErr, ok := err.(MempoolTxError); ok {
+		code = mempoolErr.code
+	}
+	tp.notify(&Event{
+		Type:       EventTxRejected,
+		TxHash:     *tx.Hash(),
+		Tx:         tx,
+		Fee:        tx.GetTxFee(),
+		Time:       time.Now(),
+		RejectCode: code,
+		Err:        err,
+	})
+}
@@ -0,0 +1,211 @@
+package mempool
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+// calcFeePerKb returns fee per KB of a tx, size is in KB
+func calcFeePerKb(fee uint64, size uint64) int {
+	if size == 0 {
+		size = 1
+	}
+	return int(fee / size)
+}
+
+// isExpired checks age of tx by time and by best height of its shard
+func (tp *TxPool) isExpired(txDesc *TxDesc, now time.Time) bool {
+	if tp.config.MaxAge > 0 && now.Sub(txDesc.Desc.Added) > tp.config.MaxAge {
+		return true
+	}
+	if tp.config.MaxAgeBlocks > 0 {
+		bestState, ok := tp.config.BlockChain.BestState.Shard[txDesc.shardID]
+		if ok && bestState.BestShardBlock != nil && bestState.BestShardBlock.Header.Height > txDesc.Desc.Height+tp.config.MaxAgeBlocks {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+expireTxs - remove expired txs from pool. Txs of a shard are added in order of
+time and best height of the shard, so only the oldest ones are checked up to
+the first one which does not expire
+This function MUST be called with the mempool lock held (for writes).
+*/
+func (tp *TxPool) expireTxs(now time.Time) {
+	if tp.config.MaxAge == 0 && tp.config.MaxAgeBlocks == 0 {
+		return
+	}
+	for _, shardTxPool := range tp.shardTxPools {
+		for element := shardTxPool.byArrival.Front(); element != nil; element = shardTxPool.byArrival.Front() {
+			txDesc := element.Value.(*TxDesc)
+			if !tp.isExpired(txDesc, now) {
+				break
+			}
+			Logger.log.Infof("Expire tx %+v from mempool", txDesc.Desc.Tx.Hash().String())
+			tp.removeTx(&txDesc.Desc.Tx)
+			tp.notifyTx(EventTxEvicted, txDesc)
+		}
+	}
+}
+
+/*
+feeRateHeap - txs of a shard pool, the one with the lowest fee per KB on top.
+The tx added first is on top when paying the same
+*/
+type feeRateHeap []*TxDesc
+
+func (h feeRateHeap) Len() int {
+	return len(h)
+}
+
+func (h feeRateHeap) Less(i, j int) bool {
+	if h[i].StartingPriority != h[j].StartingPriority {
+		return h[i].StartingPriority < h[j].StartingPriority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h feeRateHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *feeRateHeap) Push(x interface{}) {
+	txDesc := x.(*TxDesc)
+	txDesc.heapIndex = len(*h)
+	*h = append(*h, txDesc)
+}
+
+func (h *feeRateHeap) Pop() interface{} {
+	old := *h
+	txDesc := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	txDesc.heapIndex = -1
+	return txDesc
+}
+
+/*
+walk - call f on txs from the cheapest one until f returns false. The heap is
+not changed, only children of visited txs are looked at so walking k txs costs
+O(k log k) whatever the size of the pool
+*/
+func (h feeRateHeap) walk(f func(txDesc *TxDesc) bool) {
+	if len(h) == 0 {
+		return
+	}
+	frontier := &heapFrontier{txs: h, indexes: []int{0}}
+	for frontier.Len() > 0 {
+		index := heap.Pop(frontier).(int)
+		if !f(h[index]) {
+			return
+		}
+		for _, child := range []int{2*index + 1, 2*index + 2} {
+			if child < len(h) {
+				heap.Push(frontier, child)
+			}
+		}
+	}
+}
+
+// heapFrontier - indexes of txs of a feeRateHeap which walk visits next
+type heapFrontier struct {
+	txs     feeRateHeap
+	indexes []int
+}
+
+func (f heapFrontier) Len() int {
+	return len(f.indexes)
+}
+
+func (f heapFrontier) Less(i, j int) bool {
+	return f.txs.Less(f.indexes[i], f.indexes[j])
+}
+
+func (f heapFrontier) Swap(i, j int) {
+	f.indexes[i], f.indexes[j] = f.indexes[j], f.indexes[i]
+}
+
+func (f *heapFrontier) Push(x interface{}) {
+	f.indexes = append(f.indexes, x.(int))
+}
+
+func (f *heapFrontier) Pop() interface{} {
+	index := f.indexes[len(f.indexes)-1]
+	f.indexes = f.indexes[:len(f.indexes)-1]
+	return index
+}
+
+// isOverLimit checks if pool with count txs of total size exceeds config
+func (tp *TxPool) isOverLimit(count int, size uint64) bool {
+	return (tp.config.MaxTxs > 0 && count > tp.config.MaxTxs) || (tp.config.MaxSize > 0 && size > tp.config.MaxSize)
+}
+
+/*
+selectEvictions - return txs with the lowest fee per KB which must be evicted
+so a new tx of size and fee fits in limits of the pool of its shard. Only txs
+of that shard paying strictly less per KB than the new tx can be evicted,
+otherwise the new tx is rejected. Txs replaced by the new tx are counted as
+already removed
+*/
+func (tp *TxPool) selectEvictions(txHash *common.Hash, shardID byte, size uint64, fee uint64, replaced []*TxDesc) ([]*TxDesc, error) {
+	shardTxPool := tp.getShardTxPool(shardID)
+	count := len(shardTxPool.txs) + 1
+	totalSize := shardTxPool.size + size
+	excluded := make(map[*TxDesc]bool)
+	for _, txDesc := range replaced {
+		if txDesc.shardID != shardID {
+			continue
+		}
+		excluded[txDesc] = true
+		count--
+		totalSize -= txDesc.size
+	}
+	if !tp.isOverLimit(count, totalSize) {
+		return nil, nil
+	}
+	priority := calcFeePerKb(fee, size)
+	evicted := []*TxDesc{}
+	// cheapest first, older tx first when paying the same
+	shardTxPool.byFeeRate.walk(func(txDesc *TxDesc) bool {
+		if !tp.isOverLimit(count, totalSize) || txDesc.StartingPriority >= priority {
+			return false
+		}
+		if !excluded[txDesc] {
+			evicted = append(evicted, txDesc)
+			count--
+			totalSize -= txDesc.size
+		}
+		return true
+	})
+	if tp.isOverLimit(count, totalSize) {
+		err := MempoolTxError{}
+		err.Init(RejectMempoolFull, fmt.Errorf("transaction %+v with %d fee per KB can not replace any tx in full mempool of shard %d", txHash.String(), priority, shardID))
+		return nil, err
+	}
+	return evicted, nil
+}
+
+/*
+makeRoom - evict txs chosen by selectEvictions, nothing is removed when the
+new tx is rejected
+This function MUST be called with the mempool lock held (for writes).
+*/
+func (tp *TxPool) makeRoom(txHash *common.Hash, shardID byte, size uint64, fee uint64) error {
+	evicted, err := tp.selectEvictions(txHash, shardID, size, fee, nil)
+	if err != nil {
+		return err
+	}
+	for _, txDesc := range evicted {
+		Logger.log.Infof("Evict tx %+v with %d fee per KB from mempool", txDesc.Desc.Tx.Hash().String(), txDesc.StartingPriority)
+		tp.removeTx(&txDesc.Desc.Tx)
+		tp.notifyTx(EventTxEvicted, txDesc)
+	}
+	return nil
+}
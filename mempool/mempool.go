@@ -1,17 +1,20 @@
 package mempool
 
 import (
+	"container/heap"
+	"container/list"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/ninjadotorg/constant/metadata"
-	"github.com/ninjadotorg/constant/transaction"
 )
 
 // TODO: 0xsirrush need to optimize with some features:
@@ -31,6 +34,41 @@ type Config struct {
 	// FeeEstimatator provides a feeEstimator. If it is not nil, the mempool
 	// records all new transactions it observes into the feeEstimator.
 	FeeEstimator map[byte]*FeeEstimator
+
+	// only txs sent from RelayShards, or from the shard of the user key
+	// when node runs in shard or auto mode, are accepted. A relay node
+	// without RelayShards accepts txs of every shard
+	RelayShards []byte
+	NodeMode    string
+	UserKeySet  *cashec.KeySet
+
+	// Limits of pool of each shard, zero value disables the limit. When the
+	// pool of a shard is full its txs with the lowest fee per KB are evicted
+	// for a better paying tx
+	MaxTxs int
+	// MaxSize is in the unit of Size(), sum of GetTxActualSize (KB)
+	MaxSize uint64
+	// txs older than MaxAge or added MaxAgeBlocks before the best height
+	// of their shard are expired
+	MaxAge       time.Duration
+	MaxAgeBlocks uint64
+
+	// txs spending unknown commitments are kept up to MaxOrphanTxs for
+	// OrphanTxTTL by ProcessTransaction, zero MaxOrphanTxs disables it
+	MaxOrphanTxs int
+	OrphanTxTTL  time.Duration
+
+	// MinReplaceFeeBump is the percent a tx must pay over the total fee of
+	// txs it replaces, a replacement always pays strictly more
+	MinReplaceFeeBump uint64
+
+	// MetadataPriority maps metadata type to selection level, MiningDescs
+	// returns txs of higher level first regardless of their fee
+	MetadataPriority map[int]int
+
+	// PersistFile is where the pool is dumped to survive restarts, empty
+	// value disables persistence
+	PersistFile string
 }
 
 // TxDesc is transaction message in mempool
@@ -38,7 +76,20 @@ type TxDesc struct {
 	// transaction details
 	Desc metadata.TxDesc
 
+	// fee per KB of tx, used to choose which tx is evicted first
 	StartingPriority int
+
+	// actual size of tx, cached for pool size accounting
+	size uint64
+
+	// shard the tx is sent from
+	shardID byte
+
+	// position of tx in byFeeRate and byArrival of its shard pool, seq
+	// orders txs added with the same fee per KB
+	heapIndex int
+	arrival   *list.Element
+	seq       uint64
 }
 
 // TxPool is transaction pool
@@ -50,10 +101,28 @@ type TxPool struct {
 	config            Config
 	pool              map[common.Hash]*TxDesc
 	poolSerialNumbers map[common.Hash][][]byte
+	size              uint64 // sum of actual size of txs in pool
+	shardTxPools      map[byte]*shardTxPool
+	nextSeq           uint64
+
+	// txs waiting for the commitments they spend
+	orphans map[common.Hash]*orphanTx
 
 	txCoinHashHPool map[common.Hash][]common.Hash
 	coinHashHPool   map[common.Hash]bool
 	cMtx            sync.RWMutex
+
+	// persistence of pool
+	started   int32
+	shutdown  int32
+	cQuit     chan struct{}
+	waitGroup sync.WaitGroup
+
+	// subscribers to events of pool, guarded by their own lock so events
+	// can be sent while the mempool lock is held
+	subMtx        sync.RWMutex
+	subscriptions map[uint64]*Subscription
+	nextSubID     uint64
 }
 
 /*
@@ -63,10 +132,14 @@ func (tp *TxPool) Init(cfg *Config) {
 	tp.config = *cfg
 	tp.pool = make(map[common.Hash]*TxDesc)
 	tp.poolSerialNumbers = make(map[common.Hash][][]byte)
+	tp.shardTxPools = make(map[byte]*shardTxPool)
+	tp.orphans = make(map[common.Hash]*orphanTx)
 
 	tp.txCoinHashHPool = make(map[common.Hash][]common.Hash)
 	tp.coinHashHPool = make(map[common.Hash]bool)
 	tp.cMtx = sync.RWMutex{}
+	tp.cQuit = make(chan struct{})
+	tp.subscriptions = make(map[uint64]*Subscription)
 }
 
 // ----------- transaction.MempoolRetriever's implementation -----------------
@@ -95,7 +168,8 @@ func (tp *TxPool) isTxInPool(hash *common.Hash) bool {
 /*
 // add transaction into pool
 */
-func (tp *TxPool) addTx(tx metadata.Transaction, height uint64, fee uint64) *TxDesc {
+func (tp *TxPool) addTx(tx metadata.Transaction, shardID byte, height uint64, fee uint64) *TxDesc {
+	size := tx.GetTxActualSize()
 	txD := &TxDesc{
 		Desc: metadata.TxDesc{
 			Tx:     tx,
@@ -103,27 +177,35 @@ func (tp *TxPool) addTx(tx metadata.Transaction, height uint64, fee uint64) *TxD
 			Height: height,
 			Fee:    fee,
 		},
-		StartingPriority: 1, //@todo we will apply calc function for it.
+		StartingPriority: calcFeePerKb(fee, size),
+		size:             size,
+		shardID:          shardID,
+		seq:              tp.nextSeq,
 	}
+	tp.nextSeq++
 	Logger.log.Info(tx.Hash().String())
 	tp.pool[*tx.Hash()] = txD
 	tp.poolSerialNumbers[*tx.Hash()] = txD.Desc.Tx.ListNullifiers()
+	tp.size += size
+	shardTxPool := tp.getShardTxPool(shardID)
+	shardTxPool.txs[*tx.Hash()] = txD
+	shardTxPool.size += size
+	heap.Push(&shardTxPool.byFeeRate, txD)
+	txD.arrival = shardTxPool.byArrival.PushBack(txD)
 	atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
 
-	// Record this tx for fee estimation if enabled. only apply for normal tx
-	if tx.GetType() == common.TxNormalType {
-		if tp.config.FeeEstimator != nil {
-			shardID := common.GetShardIDFromLastByte(tx.(*transaction.Tx).PubKeyLastByteSender)
-			if temp, ok := tp.config.FeeEstimator[shardID]; ok {
-				temp.ObserveTransaction(txD)
-			}
-
+	// Record this tx for fee estimation if enabled, fee of each class of tx
+	// is estimated apart
+	if tp.config.FeeEstimator != nil {
+		if temp, ok := tp.config.FeeEstimator[shardID]; ok {
+			temp.ObserveTransaction(txD)
 		}
 	}
 	txHash := tx.Hash()
 	if txHash != nil {
 		tp.addTxCoinHashH(*txHash)
 	}
+	tp.notifyTx(EventTxAdded, txD)
 	return txD
 }
 
@@ -141,11 +223,34 @@ func (tp *TxPool) maybeAcceptTransaction(tx metadata.Transaction) (*common.Hash,
 	var shardID byte
 	var err error
 
-	// get shardID of tx
+	// get shardID of tx, only txs of shards tracked by node are pooled
 	shardID = common.GetShardIDFromLastByte(tx.GetSenderAddrLastByte())
+	if err := tp.checkShard(txHash, shardID); err != nil {
+		return nil, nil, err
+	}
 
 	bestHeight := tp.config.BlockChain.BestState.Shard[shardID].BestShardBlock.Header.Height
 	// nextBlockHeight := bestHeight + 1
+
+	// cheap checks go first, a tx which fails them never reaches proof
+	// verification
+
+	// Don't accept the transaction if it already exists in the pool.
+	if tp.isTxInPool(txHash) {
+		str := fmt.Sprintf("already have transaction %+v", txHash.String())
+		err := MempoolTxError{}
+		err.Init(RejectDuplicateTx, errors.New(str))
+		return nil, nil, err
+	}
+
+	// A standalone transaction must not be a salary transaction.
+	// if tp.config.BlockChain.IsSalaryTx(tx) {
+	if tx.IsSalaryTx() {
+		err := MempoolTxError{}
+		err.Init(RejectSalaryTx, fmt.Errorf("%+v is salary tx", txHash.String()))
+		return nil, nil, err
+	}
+
 	// check version
 	ok := tx.CheckTxVersion(MaxVersion)
 	if !ok {
@@ -154,43 +259,73 @@ func (tp *TxPool) maybeAcceptTransaction(tx metadata.Transaction) (*common.Hash,
 		return nil, nil, err
 	}
 
+	ok = tx.ValidateType()
+	if !ok {
+		err := MempoolTxError{}
+		err.Init(RejectInvalidTx, fmt.Errorf("%+v has wrong tx type %+v", txHash.String(), tx.GetType()))
+		return nil, nil, err
+	}
+
+	// a tx bigger than a block can never be mined
+	txSize := tx.GetTxActualSize()
+	if txSize > common.MaxBlockSize/1024 {
+		err := MempoolTxError{}
+		err.Init(RejectTxSize, fmt.Errorf("transaction %+v has size %d KB which is over block size", txHash.String(), txSize))
+		return nil, nil, err
+	}
+
 	// check fee of tx
 	minFeePerKbTx := tp.config.BlockChain.GetFeePerKbTx()
 	txFee := tx.GetTxFee()
 	ok = tx.CheckTransactionFee(minFeePerKbTx)
 	if !ok {
 		err := MempoolTxError{}
-		err.Init(RejectVersion, fmt.Errorf("transaction %+v has %d fees which is under the required amount of %d", tx.Hash().String(), txFee, minFeePerKbTx))
+		err.Init(RejectInvalidFee, fmt.Errorf("transaction %+v has %d fees which is under the required amount of %d", tx.Hash().String(), txFee, minFeePerKbTx))
 		return nil, nil, err
 	}
 	// end check with policy
 
-	ok = tx.ValidateType()
-	if !ok {
-		fmt.Printf("Type: %s\n", (tx.(*transaction.Tx).Type))
-		return nil, nil, errors.New("wrong tx type")
+	// a tx spending serial numbers of txs in pool replaces them if it pays
+	// enough more, they are ignored when checking double spend in pool
+	replaced := tp.findReplacedTxs(tx)
+	if len(replaced) > 0 {
+		if err := tp.checkReplacement(txHash, txFee, replaced); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// check tx with all txs in current mempool
-	err = tx.ValidateTxWithCurrentMempool(tp)
+	err = tx.ValidateTxWithCurrentMempool(newMempoolView(tp, replaced))
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// drop expired txs and check a full pool has cheaper txs to evict
+	tp.expireTxs(time.Now())
+	if _, err := tp.selectEvictions(txHash, shardID, txSize, txFee, replaced); err != nil {
+		return nil, nil, err
+	}
+
 	// sanity data
 	// if validate, errS := tp.ValidateSanityData(tx); !validate {
 	if validated, errS := tx.ValidateSanityData(tp.config.BlockChain); !validated {
 		err := MempoolTxError{}
-		err.Init(RejectSansityTx, fmt.Errorf("transaction's sansity %v is error %v", txHash.String(), errS.Error()))
+		err.Init(RejectSansityTx, fmt.Errorf("transaction's sansity %v is error %v", txHash.String(), errS))
 		return nil, nil, err
 	}
 
-	// ValidateTransaction tx by it self
-	// validate := tp.ValidateTxByItSelf(tx)
-	validated := tx.ValidateTxByItself(tx.IsPrivacy(), tp.config.BlockChain.GetDatabase(), tp.config.BlockChain, shardID)
-	if !validated {
+	// coins spent by tx may come from a cross shard block which is not
+	// inserted yet
+	if tp.hasMissingCommitments(tx, shardID) {
+		// proof can not be verified without the commitments, signature is
+		// checked so a forged tx is not kept as orphan
+		if validated, errS := tx.VerifySigTx(); !validated {
+			err := MempoolTxError{}
+			err.Init(RejectInvalidTx, fmt.Errorf("transaction's signature %v is invalid %v", txHash.String(), errS))
+			return nil, nil, err
+		}
 		err := MempoolTxError{}
-		err.Init(RejectInvalidTx, errors.New("invalid tx"))
+		err.Init(RejectOrphanTx, fmt.Errorf("transaction %+v spends commitments which are not known by shard %d", txHash.String(), shardID))
 		return nil, nil, err
 	}
 
@@ -201,36 +336,45 @@ func (tp *TxPool) maybeAcceptTransaction(tx metadata.Transaction) (*common.Hash,
 		return nil, nil, err
 	}
 
-	// Don't accept the transaction if it already exists in the pool.
-	if tp.isTxInPool(txHash) {
-		str := fmt.Sprintf("already have transaction %+v", txHash.String())
+	// ValidateTransaction tx by it self, the most expensive check as it
+	// verifies signature and proof, result is cached for block validation
+	validated := tp.config.BlockChain.ValidateTxByItself(tx, shardID)
+	if !validated {
 		err := MempoolTxError{}
-		err.Init(RejectDuplicateTx, errors.New(str))
+		err.Init(RejectInvalidTx, errors.New("invalid tx"))
 		return nil, nil, err
 	}
 
-	// A standalone transaction must not be a salary transaction.
-	// if tp.config.BlockChain.IsSalaryTx(tx) {
-	if tx.IsSalaryTx() {
-		err := MempoolTxError{}
-		err.Init(RejectSalaryTx, fmt.Errorf("%+v is salary tx", txHash.String()))
+	tp.replaceTxs(txHash, replaced)
+	err = tp.makeRoom(txHash, shardID, txSize, txFee)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	txD := tp.addTx(tx, bestHeight, txFee)
+	txD := tp.addTx(tx, shardID, bestHeight, txFee)
 	return tx.Hash(), txD, nil
 }
 
-// remove transaction for pool
+// remove transaction for pool, its serial numbers and coin hashes are
+// released together with it
 func (tp *TxPool) removeTx(tx *metadata.Transaction) error {
-	Logger.log.Infof((*tx).Hash().String())
-	if _, exists := tp.pool[*(*tx).Hash()]; exists {
-		delete(tp.pool, *(*tx).Hash())
-		atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
-		return nil
-	} else {
+	txHash := (*tx).Hash()
+	Logger.log.Infof(txHash.String())
+	// coin hashes are pre pooled by rpc before the tx is accepted
+	tp.removeTxCoinHashH(*txHash)
+	txDesc, exists := tp.pool[*txHash]
+	if !exists {
 		return errors.New("not exist tx in pool")
 	}
+	delete(tp.pool, *txHash)
+	delete(tp.poolSerialNumbers, *txHash)
+	tp.size -= txDesc.size
+	shardTxPool := tp.getShardTxPool(txDesc.shardID)
+	delete(shardTxPool.txs, *txHash)
+	shardTxPool.size -= txDesc.size
+	heap.Remove(&shardTxPool.byFeeRate, txDesc.heapIndex)
+	shardTxPool.byArrival.Remove(txDesc.arrival)
+	atomic.StoreInt64(&tp.lastUpdated, time.Now().Unix())
 	return nil
 }
 
@@ -239,29 +383,36 @@ func (tp *TxPool) removeTx(tx *metadata.Transaction) error {
 // such as rejecting duplicate transactions, ensuring transactions follow all
 // rules, detecting orphan transactions, and insertion into the memory pool.
 //
-// If the transaction is an orphan (missing parent transactions), the
-// transaction is NOT added to the orphan pool, but each unknown referenced
-// parent is returned.  Use ProcessTransaction instead if new orphans should
-// be added to the orphan pool.
+// If the transaction is an orphan (spending commitments which are not known
+// yet), the transaction is NOT added to the orphan pool but rejected with
+// RejectOrphanTx.  Use ProcessTransaction instead if new orphans should be
+// added to the orphan pool.
 //
 // This function is safe for concurrent access.
 func (tp *TxPool) MaybeAcceptTransaction(tx metadata.Transaction) (*common.Hash, *TxDesc, error) {
 	tp.mtx.Lock()
 	hash, txDesc, err := tp.maybeAcceptTransaction(tx)
+	if err != nil {
+		tp.notifyRejected(tx, err)
+	}
 	tp.mtx.Unlock()
 	return hash, txDesc, err
 }
 
-// RemoveTx safe remove transaction for pool
-func (tp *TxPool) RemoveTx(tx metadata.Transaction) error {
+// RemoveTx safe remove transaction for pool, isInBlock tells whether it
+// leaves the pool because it is mined or because it is no longer valid
+func (tp *TxPool) RemoveTx(tx metadata.Transaction, isInBlock bool) error {
 	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	txDesc, exists := tp.pool[*tx.Hash()]
 	err := tp.removeTx(&tx)
-	// remove tx coin hash from pool
-	txHash := tx.Hash()
-	if txHash != nil {
-		tp.removeTxCoinHashH(*txHash)
+	if exists {
+		if isInBlock {
+			tp.notifyTx(EventTxMined, txDesc)
+		} else {
+			tp.notifyTx(EventTxEvicted, txDesc)
+		}
 	}
-	tp.mtx.Unlock()
 	return err
 }
 
@@ -278,16 +429,36 @@ func (tp *TxPool) GetTx(txHash *common.Hash) (metadata.Transaction, error) {
 	return nil, errors.New("transaction is not in the pool")
 }
 
-// // MiningDescs returns a slice of mining descriptors for all the transactions
-// // in the pool.
-func (tp *TxPool) MiningDescs() []*metadata.TxDesc {
-	descs := []*metadata.TxDesc{}
+// MiningDescs returns a slice of mining descriptors for all the transactions
+// sent from shard in the pool, ordered by metadata priority level, then fee
+// per KB, then arrival time
+func (tp *TxPool) MiningDescs(shardID byte) []*metadata.TxDesc {
 	tp.mtx.Lock()
-	for _, desc := range tp.pool {
-		descs = append(descs, &desc.Desc)
+	shardTxPool := tp.getShardTxPool(shardID)
+	txDescs := make([]*TxDesc, 0, len(shardTxPool.txs))
+	for _, desc := range shardTxPool.txs {
+		txDescs = append(txDescs, desc)
 	}
 	tp.mtx.Unlock()
 
+	levels := make(map[*TxDesc]int, len(txDescs))
+	for _, desc := range txDescs {
+		levels[desc] = tp.config.MetadataPriority[desc.Desc.Tx.GetMetadataType()]
+	}
+	sort.Slice(txDescs, func(i, j int) bool {
+		if levels[txDescs[i]] != levels[txDescs[j]] {
+			return levels[txDescs[i]] > levels[txDescs[j]]
+		}
+		if txDescs[i].StartingPriority != txDescs[j].StartingPriority {
+			return txDescs[i].StartingPriority > txDescs[j].StartingPriority
+		}
+		return txDescs[i].Desc.Added.Before(txDescs[j].Desc.Added)
+	})
+
+	descs := make([]*metadata.TxDesc, 0, len(txDescs))
+	for _, desc := range txDescs {
+		descs = append(descs, &desc.Desc)
+	}
 	return descs
 }
 
@@ -302,10 +473,7 @@ Sum of all transactions sizes
 */
 func (tp *TxPool) Size() uint64 {
 	tp.mtx.RLock()
-	size := uint64(0)
-	for _, tx := range tp.pool {
-		size += tx.Desc.Tx.GetTxActualSize()
-	}
+	size := tp.size
 	tp.mtx.RUnlock()
 
 	return size
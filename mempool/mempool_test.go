@@ -0,0 +1,433 @@
+package mempool
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/ninjadotorg/constant/privacy"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+func init() {
+	Logger.Init(common.NewBackend(ioutil.Discard).Logger("test"))
+}
+
+func newTestTx(lockTime int64, fee uint64) metadata.Transaction {
+	return &transaction.Tx{Type: common.TxNormalType, LockTime: lockTime, Fee: fee}
+}
+
+func newTestPool(cfg Config) *TxPool {
+	tp := &TxPool{}
+	tp.Init(&cfg)
+	return tp
+}
+
+func TestMakeRoomEvictsLowestFee(t *testing.T) {
+	tp := newTestPool(Config{MaxTxs: 3})
+	txs := []metadata.Transaction{newTestTx(1, 30), newTestTx(2, 10), newTestTx(3, 20)}
+	for _, tx := range txs {
+		tp.PrePoolTxCoinHashH(*tx.Hash(), []common.Hash{common.HashH(tx.Hash()[:])})
+		tp.addTx(tx, 0, 1, tx.GetTxFee())
+	}
+
+	newTx := newTestTx(4, 15)
+	if err := tp.makeRoom(newTx.Hash(), 0, newTx.GetTxActualSize(), newTx.GetTxFee()); err != nil {
+		t.Fatalf("makeRoom() error = %v", err)
+	}
+	if tp.isTxInPool(txs[1].Hash()) {
+		t.Errorf("tx with the lowest fee is not evicted")
+	}
+	if _, ok := tp.poolSerialNumbers[*txs[1].Hash()]; ok {
+		t.Errorf("serial numbers of evicted tx are kept")
+	}
+	if err := tp.ValidateCoinHashH(common.HashH(txs[1].Hash()[:])); err != nil {
+		t.Errorf("coin hashes of evicted tx are kept")
+	}
+	if tp.Count() != 2 || tp.Size() != 2 {
+		t.Errorf("Count() = %d, Size() = %d, want 2, 2", tp.Count(), tp.Size())
+	}
+	tp.addTx(newTx, 0, 1, newTx.GetTxFee())
+
+	cheapTx := newTestTx(5, 15)
+	err := tp.makeRoom(cheapTx.Hash(), 0, cheapTx.GetTxActualSize(), cheapTx.GetTxFee())
+	if mempoolErr, ok := err.(MempoolTxError); !ok || mempoolErr.code != ErrCodeMessage[RejectMempoolFull].code {
+		t.Errorf("makeRoom() error = %v, want RejectMempoolFull", err)
+	}
+	if tp.Count() != 3 {
+		t.Errorf("rejected tx evicted txs from pool, Count() = %d", tp.Count())
+	}
+}
+
+func TestMakeRoomMaxSize(t *testing.T) {
+	tp := newTestPool(Config{MaxSize: 2})
+	tp.addTx(newTestTx(1, 10), 0, 1, 10)
+	tp.addTx(newTestTx(2, 20), 0, 1, 20)
+
+	newTx := newTestTx(3, 30)
+	if err := tp.makeRoom(newTx.Hash(), 0, 3, newTx.GetTxFee()); err == nil {
+		t.Errorf("makeRoom() accepted tx larger than MaxSize")
+	}
+	if tp.Count() != 2 {
+		t.Errorf("Count() = %d, want 2", tp.Count())
+	}
+	if err := tp.makeRoom(newTx.Hash(), 0, 1, newTx.GetTxFee()); err != nil {
+		t.Fatalf("makeRoom() error = %v", err)
+	}
+	if tp.Count() != 1 || tp.Size() != 1 {
+		t.Errorf("Count() = %d, Size() = %d, want 1, 1", tp.Count(), tp.Size())
+	}
+}
+
+func TestExpireTxs(t *testing.T) {
+	tp := newTestPool(Config{MaxAge: time.Hour})
+	oldTx := newTestTx(1, 10)
+	tp.addTx(oldTx, 0, 1, 10).Desc.Added = time.Now().Add(-2 * time.Hour)
+	newTx := newTestTx(2, 10)
+	tp.addTx(newTx, 0, 1, 10)
+
+	tp.expireTxs(time.Now())
+	if tp.isTxInPool(oldTx.Hash()) || !tp.isTxInPool(newTx.Hash()) {
+		t.Errorf("expireTxs() kept old tx or removed new tx")
+	}
+	if tp.Size() != 1 {
+		t.Errorf("Size() = %d, want 1", tp.Size())
+	}
+}
+
+// expiry stops at the first tx of a shard which does not expire
+func TestExpireTxsOldestFirst(t *testing.T) {
+	tp := newTestPool(Config{MaxAge: time.Hour})
+	now := time.Now()
+	txs := []metadata.Transaction{newTestTx(1, 10), newTestTx(2, 10), newTestTx(3, 10)}
+	tp.addTx(txs[0], 0, 1, 10).Desc.Added = now.Add(-3 * time.Hour)
+	tp.addTx(txs[1], 0, 1, 10).Desc.Added = now.Add(-2 * time.Hour)
+	tp.addTx(txs[2], 0, 1, 10).Desc.Added = now
+	otherShardTx := newTestTx(4, 10)
+	tp.addTx(otherShardTx, 1, 1, 10).Desc.Added = now.Add(-2 * time.Hour)
+
+	tp.expireTxs(now)
+	if tp.Count() != 1 || !tp.isTxInPool(txs[2].Hash()) {
+		t.Errorf("expireTxs() should keep only the new tx, Count() = %d", tp.Count())
+	}
+	if tp.shardTxPools[0].byArrival.Len() != 1 || tp.shardTxPools[1].byArrival.Len() != 0 {
+		t.Errorf("expired txs are kept in arrival order of shard pools")
+	}
+}
+
+func TestFeeRateHeapWalk(t *testing.T) {
+	tp := newTestPool(Config{})
+	fees := []uint64{50, 10, 40, 10, 30, 20, 60}
+	for i, fee := range fees {
+		tp.addTx(newTestTx(int64(i), fee), 0, 1, fee)
+	}
+	// removed txs leave the heap
+	tp.removeTx(&tp.shardTxPools[0].byFeeRate[0].Desc.Tx)
+
+	visited := []*TxDesc{}
+	tp.shardTxPools[0].byFeeRate.walk(func(txDesc *TxDesc) bool {
+		visited = append(visited, txDesc)
+		return true
+	})
+	expected := []uint64{10, 20, 30, 40, 50, 60}
+	if len(visited) != len(expected) {
+		t.Fatalf("walk() visited %d txs, want %d", len(visited), len(expected))
+	}
+	for i, txDesc := range visited {
+		if txDesc.Desc.Fee != expected[i] {
+			t.Fatalf("walk() visited fee %d at %d, want %d", txDesc.Desc.Fee, i, expected[i])
+		}
+	}
+	// the tx added first goes first when paying the same
+	if visited[0].Desc.Tx.GetLockTime() != 3 {
+		t.Errorf("walk() should start with the remaining tx paying 10")
+	}
+
+	count := 0
+	tp.shardTxPools[0].byFeeRate.walk(func(txDesc *TxDesc) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("walk() should stop when f returns false, visited %d", count)
+	}
+}
+
+func TestMakeRoomEvictsCheapestOfMany(t *testing.T) {
+	tp := newTestPool(Config{MaxTxs: 100})
+	for i := 0; i < 100; i++ {
+		fee := uint64(1000 - i)
+		tp.addTx(newTestTx(int64(i), fee), 0, 1, fee)
+	}
+	newTx := newTestTx(1000, 950)
+	if err := tp.makeRoom(newTx.Hash(), 0, newTx.GetTxActualSize(), newTx.GetTxFee()); err != nil {
+		t.Fatalf("makeRoom() error = %v", err)
+	}
+	if tp.Count() != 99 || tp.isTxInPool(newTestTx(99, 901).Hash()) {
+		t.Errorf("makeRoom() should evict only the cheapest tx, Count() = %d", tp.Count())
+	}
+	if top := tp.shardTxPools[0].byFeeRate[0]; top.Desc.Fee != 902 {
+		t.Errorf("cheapest tx left is %d, want 902", top.Desc.Fee)
+	}
+}
+
+func TestMiningDescsOrder(t *testing.T) {
+	tp := newTestPool(Config{MetadataPriority: map[int]int{metadata.LoanRequestMeta: 1}})
+	cheapTx := newTestTx(1, 10)
+	richTx := newTestTx(2, 50)
+	loanRequest := &metadata.LoanRequest{
+		CollateralAmount: big.NewInt(1),
+		ReceiveAddress:   &privacy.PaymentAddress{},
+		MetadataBase:     metadata.MetadataBase{Type: metadata.LoanRequestMeta},
+	}
+	loanTx := &transaction.Tx{Type: common.TxNormalType, LockTime: 3, Fee: 5, Metadata: loanRequest}
+	firstTx := newTestTx(4, 10)
+	now := time.Now()
+	tp.addTx(cheapTx, 0, 1, 10).Desc.Added = now
+	tp.addTx(richTx, 0, 1, 50).Desc.Added = now
+	tp.addTx(loanTx, 0, 1, 5).Desc.Added = now
+	tp.addTx(firstTx, 0, 1, 10).Desc.Added = now.Add(-time.Minute)
+
+	want := []metadata.Transaction{loanTx, richTx, firstTx, cheapTx}
+	descs := tp.MiningDescs(0)
+	if len(descs) != len(want) {
+		t.Fatalf("MiningDescs() returns %d txs, want %d", len(descs), len(want))
+	}
+	for i, desc := range descs {
+		if !desc.Tx.Hash().IsEqual(want[i].Hash()) {
+			t.Errorf("MiningDescs()[%d] = %+v, want %+v", i, desc.Tx.Hash(), want[i].Hash())
+		}
+	}
+}
+
+func TestParseMetadataPriority(t *testing.T) {
+	priority, err := ParseMetadataPriority([]string{"1:2", "45:1"})
+	if err != nil {
+		t.Fatalf("ParseMetadataPriority() error = %v", err)
+	}
+	if len(priority) != 2 || priority[1] != 2 || priority[45] != 1 {
+		t.Errorf("ParseMetadataPriority() = %+v", priority)
+	}
+	for _, value := range []string{"1", "a:1", "1:b", "1:2:3"} {
+		if _, err := ParseMetadataPriority([]string{value}); err == nil {
+			t.Errorf("ParseMetadataPriority(%+v) accepts invalid value", value)
+		}
+	}
+}
+
+func TestSaveTxs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mempool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "mempool.dat")
+
+	tp := newTestPool(Config{})
+	if accepted, err := tp.LoadTxs(filePath); accepted != 0 || err != nil {
+		t.Errorf("LoadTxs() of missing file = %d, %v", accepted, err)
+	}
+	txs := map[common.Hash]int64{}
+	for i := int64(1); i <= 3; i++ {
+		tx := newTestTx(i, 10)
+		txs[*tx.Hash()] = tp.addTx(tx, 0, 1, 10).Desc.Added.UnixNano()
+	}
+	if err := tp.SaveTxs(filePath); err != nil {
+		t.Fatalf("SaveTxs() error = %v", err)
+	}
+
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stp := serializedTxPool{}
+	if err := codec.Unmarshal(data, &stp); err != nil {
+		t.Fatalf("decode saved pool error = %v", err)
+	}
+	if stp.Version != persistVersion || len(stp.Txs) != len(txs) {
+		t.Fatalf("saved pool has version %d and %d txs", stp.Version, len(stp.Txs))
+	}
+	for _, txData := range stp.Txs {
+		stx := serializedTxDesc{}
+		if err := codec.Unmarshal(txData, &stx); err != nil {
+			t.Fatalf("decode saved tx error = %v", err)
+		}
+		if added, ok := txs[*stx.Tx.Hash()]; !ok || added != stx.Added {
+			t.Errorf("saved tx %+v is not in pool or has wrong arrival time", stx.Tx.Hash())
+		}
+	}
+
+	if err := ioutil.WriteFile(filePath, []byte("corrupt"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tp.LoadTxs(filePath); err == nil {
+		t.Errorf("LoadTxs() accepts corrupt file")
+	}
+}
+
+func TestReplaceTxs(t *testing.T) {
+	fe := NewFeeEstimator(DefaultEstimateFeeMaxRollback, DefaultEstimateFeeMinRegisteredBlocks)
+	fe.lastKnownHeight = 1
+	tp := newTestPool(Config{MinReplaceFeeBump: 10, FeeEstimator: map[byte]*FeeEstimator{0: fe}})
+	oldTx := newTestTx(1, 100)
+	tp.addTx(oldTx, 0, 1, oldTx.GetTxFee())
+	tp.poolSerialNumbers[*oldTx.Hash()] = [][]byte{{1}}
+	replaced := []*TxDesc{tp.pool[*oldTx.Hash()]}
+
+	newTx := newTestTx(2, 105)
+	if err := tp.checkReplacement(newTx.Hash(), newTx.GetTxFee(), replaced); err == nil {
+		t.Errorf("checkReplacement() accepts a fee bump below the min")
+	} else if err.(MempoolTxError).code != ErrCodeMessage[RejectReplacementFee].code {
+		t.Errorf("checkReplacement() error = %v", err)
+	}
+	newTx = newTestTx(2, 110)
+	if err := tp.checkReplacement(newTx.Hash(), newTx.GetTxFee(), replaced); err != nil {
+		t.Errorf("checkReplacement() error = %v", err)
+	}
+
+	view := newMempoolView(tp, replaced)
+	if len(view.GetSerialNumbers()) != 0 || len(view.GetTxsInMem()) != 0 {
+		t.Errorf("replaced tx is seen by the replacement")
+	}
+
+	tp.replaceTxs(newTx.Hash(), replaced)
+	if tp.isTxInPool(oldTx.Hash()) {
+		t.Errorf("replaced tx is kept")
+	}
+	if _, ok := tp.poolSerialNumbers[*oldTx.Hash()]; ok {
+		t.Errorf("serial numbers of replaced tx are kept")
+	}
+	if _, ok := fe.observed[*oldTx.Hash()]; ok {
+		t.Errorf("replaced tx is kept by fee estimator")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	tp := newTestPool(Config{MaxTxs: 1})
+	sub := tp.Subscribe(10)
+	nextEvent := func(eventType EventType, tx metadata.Transaction) *Event {
+		select {
+		case event := <-sub.Events():
+			if event.Type != eventType || event.TxHash != *tx.Hash() {
+				t.Errorf("got event %s of tx %+v, want %s of tx %+v", event.Type, event.TxHash.String(), eventType, tx.Hash().String())
+			}
+			return event
+		default:
+			t.Fatalf("no event %s", eventType)
+		}
+		return nil
+	}
+
+	tx1 := newTestTx(1, 10)
+	tp.addTx(tx1, 0, 1, tx1.GetTxFee())
+	nextEvent(EventTxAdded, tx1)
+
+	tx2 := newTestTx(2, 20)
+	tp.makeRoom(tx2.Hash(), 0, tx2.GetTxActualSize(), tx2.GetTxFee())
+	nextEvent(EventTxEvicted, tx1)
+	tp.addTx(tx2, 0, 1, tx2.GetTxFee())
+	nextEvent(EventTxAdded, tx2)
+	tp.RemoveTx(tx2, true)
+	nextEvent(EventTxMined, tx2)
+
+	rejectErr := MempoolTxError{}
+	rejectErr.Init(RejectDuplicateTx, errors.New("duplicate"))
+	tp.notifyRejected(tx1, rejectErr)
+	if event := nextEvent(EventTxRejected, tx1); event.RejectCode != ErrCodeMessage[RejectDuplicateTx].code {
+		t.Errorf("reject code = %d", event.RejectCode)
+	}
+
+	sub.Unsubscribe()
+	if _, ok := <-sub.Events(); ok {
+		t.Errorf("events are sent after unsubscribe")
+	}
+	tp.addTx(tx1, 0, 1, tx1.GetTxFee())
+}
+
+func TestShardPools(t *testing.T) {
+	tp := newTestPool(Config{MaxTxs: 1, RelayShards: []byte{1}})
+	if tp.isShardTracked(0) || !tp.isShardTracked(1) {
+		t.Errorf("isShardTracked() does not follow RelayShards")
+	}
+	relayPool := newTestPool(Config{NodeMode: "relay"})
+	if !relayPool.isShardTracked(0) || !relayPool.isShardTracked(3) {
+		t.Errorf("relay node without RelayShards should track every shard")
+	}
+	if newTestPool(Config{NodeMode: "relay", RelayShards: []byte{1}}).isShardTracked(0) {
+		t.Errorf("relay node with RelayShards should only track them")
+	}
+
+	tx0 := newTestTx(1, 10)
+	tx1 := newTestTx(2, 10)
+	tp.addTx(tx0, 0, 1, tx0.GetTxFee())
+	tp.addTx(tx1, 1, 1, tx1.GetTxFee())
+	stats := tp.ShardStats()
+	if len(stats) != 2 || stats[0].Count != 1 || stats[1].Count != 1 {
+		t.Errorf("ShardStats() = %+v", stats)
+	}
+	if descs := tp.MiningDescs(1); len(descs) != 1 || descs[0].Tx != tx1 {
+		t.Errorf("MiningDescs(1) returns txs of other shards")
+	}
+
+	// a full shard only evicts its own txs
+	newTx := newTestTx(3, 20)
+	if err := tp.makeRoom(newTx.Hash(), 1, newTx.GetTxActualSize(), newTx.GetTxFee()); err != nil {
+		t.Fatalf("makeRoom() error = %v", err)
+	}
+	if !tp.isTxInPool(tx0.Hash()) || tp.isTxInPool(tx1.Hash()) {
+		t.Errorf("makeRoom() evicts tx of another shard")
+	}
+}
+
+func TestAddOrphan(t *testing.T) {
+	tp := newTestPool(Config{MaxOrphanTxs: 2, OrphanTxTTL: time.Hour})
+	txs := []metadata.Transaction{newTestTx(1, 10), newTestTx(2, 10), newTestTx(3, 10)}
+	for _, tx := range txs {
+		if err := tp.addOrphan(tx, 0); err != nil {
+			t.Fatalf("addOrphan() error = %v", err)
+		}
+	}
+	if tp.OrphanCount() != 2 {
+		t.Fatalf("OrphanCount() = %d, want 2", tp.OrphanCount())
+	}
+	if _, ok := tp.orphans[*txs[0].Hash()]; ok {
+		t.Errorf("addOrphan() to full pool kept the oldest orphan")
+	}
+
+	tp.orphans[*txs[1].Hash()].expiration = time.Now().Add(-time.Minute)
+	tp.expireOrphans()
+	if _, ok := tp.orphans[*txs[1].Hash()]; ok || tp.OrphanCount() != 1 {
+		t.Errorf("expireOrphans() kept expired orphan")
+	}
+
+	disabled := newTestPool(Config{})
+	if err := disabled.addOrphan(txs[0], 0); err == nil {
+		t.Errorf("addOrphan() with orphan pool disabled should fail")
+	}
+}
+
+func TestIsMalleableReject(t *testing.T) {
+	for key, malleable := range map[int]bool{
+		RejectInvalidTx:   true,
+		RejectSansityTx:   true,
+		RejectDuplicateTx: false,
+		RejectInvalidFee:  false,
+		RejectMempoolFull: false,
+	} {
+		err := MempoolTxError{}
+		err.Init(key, errors.New("test"))
+		if IsMalleableReject(err) != malleable {
+			t.Errorf("IsMalleableReject(%s) = %v, want %v", ErrCodeMessage[key].message, !malleable, malleable)
+		}
+	}
+	if IsMalleableReject(errors.New("test")) {
+		t.Errorf("IsMalleableReject() of an error not from mempool should be false")
+	}
+}
@@ -2,18 +2,38 @@ package mempool
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/ninjadotorg/constant/blockchain"
 )
 
-var nodeBeaconPoolLock sync.RWMutex
-var nodeShardPoolLock sync.RWMutex
+// NodeBlockPoolConfig - capacity of pools of blocks received ahead of the
+// best state of a chain
+type NodeBlockPoolConfig struct {
+	// max number of blocks kept for a chain, a full chain evicts its highest
+	// blocks for lower ones, which are inserted first
+	MaxBlocks int
+}
 
-var nodeShardPool = map[byte]map[uint64][]blockchain.ShardBlock{}
-var nodeBeaconPool = map[uint64][]blockchain.BeaconBlock{}
+var DefaultNodeBlockPoolConfig = NodeBlockPoolConfig{
+	MaxBlocks: 1000,
+}
 
-type NodeShardPool struct{}
+type NodeShardPool struct {
+	config NodeBlockPoolConfig
+	mtx    sync.RWMutex
+	pool   map[byte]map[uint64][]blockchain.ShardBlock
+	count  map[byte]int
+}
+
+func NewNodeShardPool(config NodeBlockPoolConfig) *NodeShardPool {
+	return &NodeShardPool{
+		config: config,
+		pool:   make(map[byte]map[uint64][]blockchain.ShardBlock),
+		count:  make(map[byte]int),
+	}
+}
 
 func (pool *NodeShardPool) PushBlock(block blockchain.ShardBlock) error {
 
@@ -24,43 +44,100 @@ func (pool *NodeShardPool) PushBlock(block blockchain.ShardBlock) error {
 		return errors.New("Invalid Block Heght")
 	}
 
-	nodeShardPoolLock.Lock()
-	shardItems := nodeShardPool[shardID]
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	shardItems := pool.pool[shardID]
 	if shardItems == nil {
 		shardItems = make(map[uint64][]blockchain.ShardBlock)
+		pool.pool[shardID] = shardItems
+	}
+	for _, poolblk := range shardItems[height] {
+		if poolblk.Header.Hash() == block.Header.Hash() {
+			return nil
+		}
+	}
+	if pool.config.MaxBlocks > 0 && pool.count[shardID] >= pool.config.MaxBlocks && !pool.evictHighest(shardID, height) {
+		return fmt.Errorf("Node shard pool of shard %+v reach limit %+v", shardID, pool.config.MaxBlocks)
 	}
 	shardItems[height] = append(shardItems[height], block)
-	nodeShardPool[shardID] = shardItems
-	nodeShardPoolLock.Unlock()
-
+	pool.count[shardID]++
 	return nil
 }
 
+// evictHighest drops a block of the highest height of shard if it is above
+// height, the lock must be held
+func (pool *NodeShardPool) evictHighest(shardID byte, height uint64) bool {
+	shardItems := pool.pool[shardID]
+	highest := uint64(0)
+	for blockHeight := range shardItems {
+		if blockHeight > highest {
+			highest = blockHeight
+		}
+	}
+	if highest <= height {
+		return false
+	}
+	if blocks := shardItems[highest]; len(blocks) > 1 {
+		shardItems[highest] = blocks[:len(blocks)-1]
+	} else {
+		delete(shardItems, highest)
+	}
+	pool.count[shardID]--
+	return true
+}
+
 func (pool *NodeShardPool) GetBlocks(shardID byte, blockHeight uint64) ([]blockchain.ShardBlock, error) {
 
 	if blockHeight == 0 {
 		return []blockchain.ShardBlock{}, errors.New("Invalid ShardId or block Height")
 	}
-	shardItems := nodeShardPool[shardID]
+	pool.mtx.RLock()
+	defer pool.mtx.RUnlock()
+	blocks := pool.pool[shardID][blockHeight]
 
-	return shardItems[blockHeight], nil
+	return append([]blockchain.ShardBlock{}, blocks...), nil
 }
 
 func (pool *NodeShardPool) RemoveBlocks(shardID byte, blockHeight uint64) error {
-	if shardID <= 0 || blockHeight == 0 {
+	if blockHeight == 0 {
 		return errors.New("Invalid ShardId or block Height")
 	}
 
-	nodeShardPoolLock.Lock()
-	shardItems := nodeShardPool[shardID]
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	shardItems := pool.pool[shardID]
+	pool.count[shardID] -= len(shardItems[blockHeight])
 	delete(shardItems, blockHeight)
-	nodeShardPool[shardID] = shardItems
-	nodeShardPoolLock.Unlock()
 
 	return nil
 }
 
-type NodeBeaconPool struct{}
+// RemoveBlocksBelow evicts blocks of shard up to best height of the shard
+func (pool *NodeShardPool) RemoveBlocksBelow(shardID byte, bestHeight uint64) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	shardItems := pool.pool[shardID]
+	for height, blocks := range shardItems {
+		if height <= bestHeight {
+			pool.count[shardID] -= len(blocks)
+			delete(shardItems, height)
+		}
+	}
+}
+
+type NodeBeaconPool struct {
+	config NodeBlockPoolConfig
+	mtx    sync.RWMutex
+	pool   map[uint64][]blockchain.BeaconBlock
+	count  int
+}
+
+func NewNodeBeaconPool(config NodeBlockPoolConfig) *NodeBeaconPool {
+	return &NodeBeaconPool{
+		config: config,
+		pool:   make(map[uint64][]blockchain.BeaconBlock),
+	}
+}
 
 func (pool *NodeBeaconPool) PushBlock(block blockchain.BeaconBlock) error {
 
@@ -70,30 +147,64 @@ func (pool *NodeBeaconPool) PushBlock(block blockchain.BeaconBlock) error {
 		return errors.New("Invalid Block Heght")
 	}
 
-	nodeBeaconPoolLock.Lock()
-	defer nodeBeaconPoolLock.Unlock()
-	if _, ok := nodeBeaconPool[height]; ok {
-		isNew := true
-		for _, poolblk := range nodeBeaconPool[height] {
-			if poolblk.Hash() == block.Hash() {
-				isNew = false
-				return nil
-			}
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	for _, poolblk := range pool.pool[height] {
+		if poolblk.Hash() == block.Hash() {
+			return nil
 		}
-		if isNew {
-			nodeBeaconPool[height] = append(nodeBeaconPool[height], block)
+	}
+	if pool.config.MaxBlocks > 0 && pool.count >= pool.config.MaxBlocks && !pool.evictHighest(height) {
+		return fmt.Errorf("Node beacon pool reach limit %+v", pool.config.MaxBlocks)
+	}
+	pool.pool[height] = append(pool.pool[height], block)
+	pool.count++
+	return nil
+}
+
+// evictHighest drops a block of the highest height if it is above height, the
+// lock must be held
+func (pool *NodeBeaconPool) evictHighest(height uint64) bool {
+	highest := uint64(0)
+	for blockHeight := range pool.pool {
+		if blockHeight > highest {
+			highest = blockHeight
 		}
+	}
+	if highest <= height {
+		return false
+	}
+	if blocks := pool.pool[highest]; len(blocks) > 1 {
+		pool.pool[highest] = blocks[:len(blocks)-1]
 	} else {
-		nodeBeaconPool[height] = append(nodeBeaconPool[height], block)
+		delete(pool.pool, highest)
 	}
-	return nil
+	pool.count--
+	return true
 }
 
 func (pool *NodeBeaconPool) GetBlocks(blockHeight uint64) ([]blockchain.BeaconBlock, error) {
-	return nodeBeaconPool[blockHeight], nil
+	pool.mtx.RLock()
+	defer pool.mtx.RUnlock()
+	return append([]blockchain.BeaconBlock{}, pool.pool[blockHeight]...), nil
 }
 
 func (pool *NodeBeaconPool) RemoveBlocks(blockHeight uint64) error {
-	delete(nodeBeaconPool, blockHeight)
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	pool.count -= len(pool.pool[blockHeight])
+	delete(pool.pool, blockHeight)
 	return nil
 }
+
+// RemoveBlocksBelow evicts blocks up to best height of beacon chain
+func (pool *NodeBeaconPool) RemoveBlocksBelow(bestHeight uint64) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	for height, blocks := range pool.pool {
+		if height <= bestHeight {
+			pool.count -= len(blocks)
+			delete(pool.pool, height)
+		}
+	}
+}
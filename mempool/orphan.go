@@ -0,0 +1,169 @@
+package mempool
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// orphanTx - tx spending coins whose commitments are not known by its shard
+// yet, usually output coins of a cross shard block which is not inserted
+type orphanTx struct {
+	tx         metadata.Transaction
+	shardID    byte
+	expiration time.Time
+}
+
+/*
+hasMissingCommitments - tx spends a commitment which is not stored in db of its
+shard. Txs with privacy refer to commitments by index, txs without privacy
+carry the commitments of their input coins
+*/
+func (tp *TxPool) hasMissingCommitments(tx metadata.Transaction, shardID byte) bool {
+	proof := tx.GetProof()
+	if proof == nil || len(proof.InputCoins) == 0 {
+		return false
+	}
+	db := tp.config.BlockChain.GetDatabase()
+	tokenID := &common.Hash{}
+	tokenID.SetBytes(common.ConstantID[:])
+	if tx.IsPrivacy() {
+		length, err := db.GetCommitmentLength(tokenID, shardID)
+		if err != nil {
+			return len(proof.CommitmentIndices) > 0
+		}
+		for _, index := range proof.CommitmentIndices {
+			if length.Uint64() <= index {
+				return true
+			}
+		}
+		return false
+	}
+	for _, inputCoin := range proof.InputCoins {
+		if inputCoin.CoinDetails == nil || inputCoin.CoinDetails.CoinCommitment == nil {
+			continue
+		}
+		ok, err := db.HasCommitment(tokenID, inputCoin.CoinDetails.CoinCommitment.Compress(), shardID)
+		if err == nil && !ok {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+addOrphan - keep tx until the commitments it spends are inserted, the orphan
+closest to expire is dropped when the orphan pool is full
+This function MUST be called with the mempool lock held (for writes).
+*/
+func (tp *TxPool) addOrphan(tx metadata.Transaction, shardID byte) error {
+	if tp.config.MaxOrphanTxs <= 0 {
+		err := MempoolTxError{}
+		err.Init(RejectOrphanTx, fmt.Errorf("transaction %+v spends unknown commitments and orphan pool is disabled", tx.Hash().String()))
+		return err
+	}
+	tp.expireOrphans()
+	txHash := *tx.Hash()
+	if _, ok := tp.orphans[txHash]; ok {
+		return nil
+	}
+	if len(tp.orphans) >= tp.config.MaxOrphanTxs {
+		var oldest *orphanTx
+		for _, orphan := range tp.orphans {
+			if oldest == nil || orphan.expiration.Before(oldest.expiration) {
+				oldest = orphan
+			}
+		}
+		Logger.log.Infof("Orphan pool is full, drop orphan tx %+v", oldest.tx.Hash().String())
+		delete(tp.orphans, *oldest.tx.Hash())
+	}
+	tp.orphans[txHash] = &orphanTx{
+		tx:         tx,
+		shardID:    shardID,
+		expiration: time.Now().Add(tp.config.OrphanTxTTL),
+	}
+	Logger.log.Infof("Add orphan tx %+v of shard %d, total orphans %d", txHash.String(), shardID, len(tp.orphans))
+	return nil
+}
+
+/*
+expireOrphans - drop orphans which wait for their commitments longer than TTL
+This function MUST be called with the mempool lock held (for writes).
+*/
+func (tp *TxPool) expireOrphans() {
+	if tp.config.OrphanTxTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	for txHash, orphan := range tp.orphans {
+		if now.After(orphan.expiration) {
+			Logger.log.Infof("Expire orphan tx %+v", txHash.String())
+			delete(tp.orphans, txHash)
+		}
+	}
+}
+
+/*
+ProcessTransaction - same as MaybeAcceptTransaction, but a tx spending
+commitments which are not known yet is kept in the orphan pool instead of
+being rejected, then the returned TxDesc is nil. It is accepted by
+ProcessOrphans once they are inserted
+
+This function is safe for concurrent access.
+*/
+func (tp *TxPool) ProcessTransaction(tx metadata.Transaction) (*common.Hash, *TxDesc, error) {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	hash, txDesc, err := tp.maybeAcceptTransaction(tx)
+	if err != nil {
+		if mempoolErr, ok := err.(MempoolTxError); ok && mempoolErr.code == ErrCodeMessage[RejectOrphanTx].code {
+			if err := tp.addOrphan(tx, common.GetShardIDFromLastByte(tx.GetSenderAddrLastByte())); err != nil {
+				tp.notifyRejected(tx, err)
+				return nil, nil, err
+			}
+			return tx.Hash(), nil, nil
+		}
+		tp.notifyRejected(tx, err)
+	}
+	return hash, txDesc, err
+}
+
+/*
+ProcessOrphans - try again orphan txs of shard, called after a block of the
+shard is inserted. Orphans which still miss commitments stay in the pool,
+orphans which are invalid for another reason are dropped. Returns the accepted
+txs, which are not relayed yet
+*/
+func (tp *TxPool) ProcessOrphans(shardID byte) []metadata.Transaction {
+	tp.mtx.Lock()
+	defer tp.mtx.Unlock()
+	tp.expireOrphans()
+	accepted := []metadata.Transaction{}
+	for txHash, orphan := range tp.orphans {
+		if orphan.shardID != shardID {
+			continue
+		}
+		_, _, err := tp.maybeAcceptTransaction(orphan.tx)
+		if err != nil {
+			if mempoolErr, ok := err.(MempoolTxError); ok && mempoolErr.code == ErrCodeMessage[RejectOrphanTx].code {
+				continue
+			}
+			Logger.log.Infof("Drop orphan tx %+v, %+v", txHash.String(), err)
+			tp.notifyRejected(orphan.tx, err)
+		} else {
+			Logger.log.Infof("Accept orphan tx %+v", txHash.String())
+			accepted = append(accepted, orphan.tx)
+		}
+		delete(tp.orphans, txHash)
+	}
+	return accepted
+}
+
+// OrphanCount returns number of orphan txs waiting for their commitments
+func (tp *TxPool) OrphanCount() int {
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+	return len(tp.orphans)
+}
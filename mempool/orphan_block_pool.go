@@ -0,0 +1,190 @@
+package mempool
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
+)
+
+// OrphanBlockPoolConfig - bounds of pool of blocks whose dependencies are not
+// inserted yet
+type OrphanBlockPoolConfig struct {
+	MaxShardBlocks      int
+	MaxCrossShardBlocks int
+	// orphans are dropped after Expiry whether their dependencies come or not
+	Expiry time.Duration
+}
+
+var DefaultOrphanBlockPoolConfig = OrphanBlockPoolConfig{
+	MaxShardBlocks:      100,
+	MaxCrossShardBlocks: 100,
+	Expiry:              15 * time.Minute,
+}
+
+type orphanShardBlock struct {
+	block      blockchain.ShardBlock
+	expiration time.Time
+}
+
+type orphanCrossShardBlock struct {
+	block      blockchain.CrossShardBlock
+	expiration time.Time
+}
+
+/*
+OrphanBlockPool - shard blocks waiting for their parent block or the beacon
+block they refer to, and cross shard blocks waiting for beacon to accept their
+shard block. The chain takes them back when the missing block is inserted
+*/
+type OrphanBlockPool struct {
+	config           OrphanBlockPoolConfig
+	mtx              sync.Mutex
+	shardBlocks      map[common.Hash]*orphanShardBlock
+	crossShardBlocks map[common.Hash]*orphanCrossShardBlock
+}
+
+func NewOrphanBlockPool(config OrphanBlockPoolConfig) *OrphanBlockPool {
+	return &OrphanBlockPool{
+		config:           config,
+		shardBlocks:      make(map[common.Hash]*orphanShardBlock),
+		crossShardBlocks: make(map[common.Hash]*orphanCrossShardBlock),
+	}
+}
+
+// AddShardBlock keeps block, the orphan closest to expire is dropped when the
+// pool is full
+func (pool *OrphanBlockPool) AddShardBlock(block blockchain.ShardBlock) error {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	pool.expire()
+	blockHash := *block.Hash()
+	if _, ok := pool.shardBlocks[blockHash]; ok {
+		return nil
+	}
+	if pool.config.MaxShardBlocks <= 0 {
+		return nil
+	}
+	if len(pool.shardBlocks) >= pool.config.MaxShardBlocks {
+		var oldestHash common.Hash
+		var oldest *orphanShardBlock
+		for hash, orphan := range pool.shardBlocks {
+			if oldest == nil || orphan.expiration.Before(oldest.expiration) {
+				oldestHash, oldest = hash, orphan
+			}
+		}
+		Logger.log.Infof("Orphan block pool is full, drop shard block %+v", oldestHash.String())
+		delete(pool.shardBlocks, oldestHash)
+	}
+	pool.shardBlocks[blockHash] = &orphanShardBlock{
+		block:      block,
+		expiration: time.Now().Add(pool.config.Expiry),
+	}
+	return nil
+}
+
+// AddCrossShardBlock keeps block, the orphan closest to expire is dropped when
+// the pool is full
+func (pool *OrphanBlockPool) AddCrossShardBlock(block blockchain.CrossShardBlock) error {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	pool.expire()
+	blockHash := *block.Hash()
+	if _, ok := pool.crossShardBlocks[blockHash]; ok {
+		return nil
+	}
+	if pool.config.MaxCrossShardBlocks <= 0 {
+		return nil
+	}
+	if len(pool.crossShardBlocks) >= pool.config.MaxCrossShardBlocks {
+		var oldestHash common.Hash
+		var oldest *orphanCrossShardBlock
+		for hash, orphan := range pool.crossShardBlocks {
+			if oldest == nil || orphan.expiration.Before(oldest.expiration) {
+				oldestHash, oldest = hash, orphan
+			}
+		}
+		Logger.log.Infof("Orphan block pool is full, drop cross shard block %+v", oldestHash.String())
+		delete(pool.crossShardBlocks, oldestHash)
+	}
+	pool.crossShardBlocks[blockHash] = &orphanCrossShardBlock{
+		block:      block,
+		expiration: time.Now().Add(pool.config.Expiry),
+	}
+	return nil
+}
+
+/*
+GetShardBlocks - remove and return orphans of shard which are children of
+parentHash and refer to a beacon height up to beaconHeight, ordered by arrival
+*/
+func (pool *OrphanBlockPool) GetShardBlocks(shardID byte, parentHash common.Hash, beaconHeight uint64) []blockchain.ShardBlock {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	pool.expire()
+	orphans := []*orphanShardBlock{}
+	for hash, orphan := range pool.shardBlocks {
+		header := orphan.block.Header
+		if header.ShardID == shardID && header.PrevBlockHash == parentHash && header.BeaconHeight <= beaconHeight {
+			orphans = append(orphans, orphan)
+			delete(pool.shardBlocks, hash)
+		}
+	}
+	sort.Slice(orphans, func(i, j int) bool {
+		return orphans[i].expiration.Before(orphans[j].expiration)
+	})
+	blocks := make([]blockchain.ShardBlock, 0, len(orphans))
+	for _, orphan := range orphans {
+		blocks = append(blocks, orphan.block)
+	}
+	return blocks
+}
+
+/*
+GetCrossShardBlocks - remove and return cross shard blocks whose shard block is
+accepted by beacon, bestShardHeight is the best height of each shard in beacon
+best state
+*/
+func (pool *OrphanBlockPool) GetCrossShardBlocks(bestShardHeight map[byte]uint64) []blockchain.CrossShardBlock {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	pool.expire()
+	blocks := []blockchain.CrossShardBlock{}
+	for hash, orphan := range pool.crossShardBlocks {
+		if orphan.block.Header.Height <= bestShardHeight[orphan.block.Header.ShardID] {
+			blocks = append(blocks, orphan.block)
+			delete(pool.crossShardBlocks, hash)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Header.Height < blocks[j].Header.Height
+	})
+	return blocks
+}
+
+// Count returns number of orphan shard blocks and cross shard blocks
+func (pool *OrphanBlockPool) Count() (int, int) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	return len(pool.shardBlocks), len(pool.crossShardBlocks)
+}
+
+// expire drops orphans older than Expiry, the lock must be held
+func (pool *OrphanBlockPool) expire() {
+	if pool.config.Expiry <= 0 {
+		return
+	}
+	now := time.Now()
+	for hash, orphan := range pool.shardBlocks {
+		if now.After(orphan.expiration) {
+			delete(pool.shardBlocks, hash)
+		}
+	}
+	for hash, orphan := range pool.crossShardBlocks {
+		if now.After(orphan.expiration) {
+			delete(pool.crossShardBlocks, hash)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package mempool
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/pkg/errors"
+)
+
+// version of the mempool dump file
+const persistVersion = 1
+
+type serializedTxDesc struct {
+	Tx    metadata.Transaction
+	Added int64 // unix nano
+}
+
+// each tx is encoded on its own so a tx which can not be encoded does not
+// prevent the others from being saved
+type serializedTxPool struct {
+	Version uint32
+	Txs     [][]byte
+}
+
+/*
+Start - restore txs dumped by a previous run then dump the pool periodically,
+nothing is done if Config.PersistFile is not set
+*/
+func (tp *TxPool) Start() {
+	if tp.config.PersistFile == "" {
+		return
+	}
+	// Already started?
+	if atomic.AddInt32(&tp.started, 1) != 1 {
+		return
+	}
+	Logger.log.Info("Starting mempool")
+	tp.loadTxs()
+	tp.waitGroup.Add(1)
+	go tp.persistHandler()
+}
+
+/*
+Stop - stop dumping the pool, the pool is dumped a last time before return
+*/
+func (tp *TxPool) Stop() {
+	if atomic.LoadInt32(&tp.started) == 0 {
+		return
+	}
+	if atomic.AddInt32(&tp.shutdown, 1) != 1 {
+		Logger.log.Errorf("Mempool is already in the process of shutting down")
+		return
+	}
+	Logger.log.Info("Mempool shutting down")
+	close(tp.cQuit)
+	tp.waitGroup.Wait()
+}
+
+// persistHandler dumps the pool every DumpTxsInterval and on quit, it must be
+// run as a goroutine
+func (tp *TxPool) persistHandler() {
+	dumpTxsTicker := time.NewTicker(DumpTxsInterval)
+	defer dumpTxsTicker.Stop()
+out:
+	for {
+		select {
+		case <-dumpTxsTicker.C:
+			if err := tp.SaveTxs(tp.config.PersistFile); err != nil {
+				Logger.log.Error(err)
+			}
+		case <-tp.cQuit:
+			break out
+		}
+	}
+	if err := tp.SaveTxs(tp.config.PersistFile); err != nil {
+		Logger.log.Error(err)
+	}
+	tp.waitGroup.Done()
+	Logger.log.Info("Mempool persist handler done")
+}
+
+/*
+SaveTxs - dump all txs in pool to file, the file is replaced atomically
+*/
+func (tp *TxPool) SaveTxs(filePath string) error {
+	stp := serializedTxPool{Version: persistVersion}
+	tp.mtx.RLock()
+	for _, txDesc := range tp.pool {
+		data, err := codec.Marshal(serializedTxDesc{
+			Tx:    txDesc.Desc.Tx,
+			Added: txDesc.Desc.Added.UnixNano(),
+		})
+		if err != nil {
+			Logger.log.Errorf("Can't save tx %+v of mempool: %+v", txDesc.Desc.Tx.Hash().String(), err)
+			continue
+		}
+		stp.Txs = append(stp.Txs, data)
+	}
+	tp.mtx.RUnlock()
+
+	data, err := codec.Marshal(stp)
+	if err != nil {
+		return errors.Wrap(err, "encode mempool")
+	}
+	tmpFile := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, data, 0600); err != nil {
+		return errors.Wrapf(err, "write mempool file %s", tmpFile)
+	}
+	if err := os.Rename(tmpFile, filePath); err != nil {
+		return errors.Wrapf(err, "write mempool file %s", filePath)
+	}
+	Logger.log.Infof("Saved %d txs of mempool to file '%s'", len(stp.Txs), filePath)
+	return nil
+}
+
+/*
+LoadTxs - re-validate txs dumped by SaveTxs through MaybeAcceptTransaction in
+their arrival order, txs which are now mined, double spent or invalid are
+dropped. It returns number of accepted txs
+*/
+func (tp *TxPool) LoadTxs(filePath string) (int, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "read mempool file %s", filePath)
+	}
+	stp := serializedTxPool{}
+	if err := codec.Unmarshal(data, &stp); err != nil {
+		return 0, errors.Wrapf(err, "decode mempool file %s", filePath)
+	}
+	if stp.Version != persistVersion {
+		return 0, errors.Errorf("mempool file %s has version %d, expect %d", filePath, stp.Version, persistVersion)
+	}
+	stxs := make([]serializedTxDesc, 0, len(stp.Txs))
+	for _, data := range stp.Txs {
+		stx := serializedTxDesc{}
+		if err := codec.Unmarshal(data, &stx); err != nil || stx.Tx == nil {
+			Logger.log.Errorf("Can't decode tx restored from mempool file: %+v", err)
+			continue
+		}
+		stxs = append(stxs, stx)
+	}
+	sort.SliceStable(stxs, func(i, j int) bool {
+		return stxs[i].Added < stxs[j].Added
+	})
+	accepted := 0
+	for _, stx := range stxs {
+		_, txDesc, err := tp.MaybeAcceptTransaction(stx.Tx)
+		if err != nil {
+			Logger.log.Debugf("Drop tx %+v restored from mempool file: %+v", stx.Tx.Hash().String(), err)
+			continue
+		}
+		// keep arrival time so expiry and ordering survive restarts
+		tp.mtx.Lock()
+		txDesc.Desc.Added = time.Unix(0, stx.Added)
+		tp.mtx.Unlock()
+		accepted++
+	}
+	return accepted, nil
+}
+
+// loadTxs restores the pool from Config.PersistFile, a file which can not be
+// read is removed so the node starts with an empty pool
+func (tp *TxPool) loadTxs() {
+	filePath := tp.config.PersistFile
+	accepted, err := tp.LoadTxs(filePath)
+	if err != nil {
+		Logger.log.Errorf("Failed to load mempool file %s: %+v", filePath, err)
+		if err := os.Remove(filePath); err != nil {
+			Logger.log.Errorf("Failed to remove corrupt mempool file %s: %+v", filePath, err)
+		}
+		return
+	}
+	Logger.log.Infof("Loaded %d txs to mempool from file '%s'", accepted, filePath)
+}
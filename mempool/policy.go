@@ -2,10 +2,13 @@ package mempool
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"errors"
 
 	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/transaction"
 )
 
@@ -72,3 +75,61 @@ func (self *Policy) CheckCustomTokenTransactionFee(tx *transaction.TxCustomToken
 	}
 	return nil
 }
+
+/*
+DefaultMetadataPriority - selection priority of txs by metadata type used
+when node is not configured: loan, oracle and vote txs are put into blocks
+before other txs
+*/
+func DefaultMetadataPriority() map[int]int {
+	priority := make(map[int]int)
+	for _, metaType := range []int{
+		metadata.LoanRequestMeta,
+		metadata.LoanResponseMeta,
+		metadata.LoanWithdrawMeta,
+		metadata.LoanUnlockMeta,
+		metadata.LoanPaymentMeta,
+		metadata.OracleFeedMeta,
+		metadata.OracleRewardMeta,
+		metadata.UpdatingOracleBoardMeta,
+		metadata.VoteDCBBoardMeta,
+		metadata.VoteGOVBoardMeta,
+		metadata.SealedLv1DCBVoteProposalMeta,
+		metadata.SealedLv2DCBVoteProposalMeta,
+		metadata.SealedLv3DCBVoteProposalMeta,
+		metadata.NormalDCBVoteProposalFromSealerMeta,
+		metadata.NormalDCBVoteProposalFromOwnerMeta,
+		metadata.SealedLv1GOVVoteProposalMeta,
+		metadata.SealedLv2GOVVoteProposalMeta,
+		metadata.SealedLv3GOVVoteProposalMeta,
+		metadata.NormalGOVVoteProposalFromSealerMeta,
+		metadata.NormalGOVVoteProposalFromOwnerMeta,
+	} {
+		priority[metaType] = 1
+	}
+	return priority
+}
+
+/*
+ParseMetadataPriority - parse selection priority from "<metatype>:<level>"
+values, txs whose metadata type is not listed have level 0
+*/
+func ParseMetadataPriority(values []string) (map[int]int, error) {
+	priority := make(map[int]int)
+	for _, value := range values {
+		parts := strings.Split(value, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid metadata priority %+v, format is <metatype>:<level>", value)
+		}
+		metaType, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid metadata type of priority %+v", value)
+		}
+		level, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid level of priority %+v", value)
+		}
+		priority[metaType] = level
+	}
+	return priority, nil
+}
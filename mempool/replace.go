@@ -0,0 +1,112 @@
+package mempool
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// mempoolView is the pool seen by a replacement tx, the txs it replaces are
+// left out so they do not count as double spend
+type mempoolView struct {
+	tp       *TxPool
+	excluded map[common.Hash]bool
+}
+
+func newMempoolView(tp *TxPool, replaced []*TxDesc) metadata.MempoolRetriever {
+	if len(replaced) == 0 {
+		return tp
+	}
+	view := &mempoolView{tp: tp, excluded: make(map[common.Hash]bool)}
+	for _, txDesc := range replaced {
+		view.excluded[*txDesc.Desc.Tx.Hash()] = true
+	}
+	return view
+}
+
+func (view *mempoolView) GetSerialNumbers() map[common.Hash][][]byte {
+	serialNumbers := make(map[common.Hash][][]byte)
+	for txHash, txSerialNumbers := range view.tp.GetSerialNumbers() {
+		if !view.excluded[txHash] {
+			serialNumbers[txHash] = txSerialNumbers
+		}
+	}
+	return serialNumbers
+}
+
+func (view *mempoolView) GetTxsInMem() map[common.Hash]metadata.TxDesc {
+	txsInMem := view.tp.GetTxsInMem()
+	for txHash := range view.excluded {
+		delete(txsInMem, txHash)
+	}
+	return txsInMem
+}
+
+/*
+findReplacedTxs - return txs in pool spending any serial number of tx
+This function MUST be called with the mempool lock held (for reads).
+*/
+func (tp *TxPool) findReplacedTxs(tx metadata.Transaction) []*TxDesc {
+	serialNumbers := tx.ListNullifiers()
+	if len(serialNumbers) == 0 {
+		return nil
+	}
+	replaced := []*TxDesc{}
+	for txHash, poolSerialNumbers := range tp.poolSerialNumbers {
+		if hasCommonSerialNumber(serialNumbers, poolSerialNumbers) {
+			replaced = append(replaced, tp.pool[txHash])
+		}
+	}
+	return replaced
+}
+
+func hasCommonSerialNumber(serialNumbers1 [][]byte, serialNumbers2 [][]byte) bool {
+	for _, serialNumber1 := range serialNumbers1 {
+		for _, serialNumber2 := range serialNumbers2 {
+			if bytes.Equal(serialNumber1, serialNumber2) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+checkReplacement - a tx replaces the txs sharing its serial numbers only if
+it pays strictly more than all of them together, increased by at least
+Config.MinReplaceFeeBump percent
+*/
+func (tp *TxPool) checkReplacement(txHash *common.Hash, fee uint64, replaced []*TxDesc) error {
+	replacedFee := uint64(0)
+	for _, txDesc := range replaced {
+		replacedFee += txDesc.Desc.Fee
+	}
+	minFee := replacedFee + replacedFee*tp.config.MinReplaceFeeBump/100
+	if fee <= replacedFee || fee < minFee {
+		err := MempoolTxError{}
+		err.Init(RejectReplacementFee, fmt.Errorf("transaction %+v has %d fees which is not enough to replace %d txs paying %d, at least %d is required", txHash.String(), fee, len(replaced), replacedFee, minFee))
+		return err
+	}
+	return nil
+}
+
+/*
+replaceTxs - remove txs replaced by a higher fee tx, they are also forgotten
+by the fee estimator as they will never be mined
+This function MUST be called with the mempool lock held (for writes).
+*/
+func (tp *TxPool) replaceTxs(newTxHash *common.Hash, replaced []*TxDesc) {
+	for _, txDesc := range replaced {
+		tx := txDesc.Desc.Tx
+		Logger.log.Infof("Replace tx %+v by %+v in mempool", tx.Hash().String(), newTxHash.String())
+		tp.removeTx(&tx)
+		tp.notifyTx(EventTxEvicted, txDesc)
+		if tp.config.FeeEstimator != nil {
+			if feeEstimator, ok := tp.config.FeeEstimator[txDesc.shardID]; ok {
+				feeEstimator.RemoveTransaction(tx.Hash())
+			}
+		}
+	}
+}
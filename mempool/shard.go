@@ -0,0 +1,105 @@
+package mempool
+
+import (
+	"container/list"
+	"fmt"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+// shardTxPool - txs of pool sent from one shard, limits of pool apply to each
+// shard so a busy shard can not evict txs of other shards
+type shardTxPool struct {
+	txs  map[common.Hash]*TxDesc
+	size uint64 // sum of actual size of txs in shard pool
+	// txs by fee per KB for eviction and by arrival for expiry, see
+	// eviction.go
+	byFeeRate feeRateHeap
+	byArrival *list.List
+}
+
+// ShardPoolStats - number, size and max fee of txs in pool of a shard
+type ShardPoolStats struct {
+	Count  int
+	Size   uint64
+	MaxFee uint64
+}
+
+// getShardTxPool returns pool of shard, it is created on first use
+func (tp *TxPool) getShardTxPool(shardID byte) *shardTxPool {
+	pool, ok := tp.shardTxPools[shardID]
+	if !ok {
+		pool = &shardTxPool{
+			txs:       make(map[common.Hash]*TxDesc),
+			byArrival: list.New(),
+		}
+		tp.shardTxPools[shardID] = pool
+	}
+	return pool
+}
+
+/*
+isShardTracked - a node only pools txs of shards it relays or of the shard it
+is currently a producer/validator of. A relay node without RelayShards tracks
+every shard, checkShard still requires a best state of the shard
+*/
+func (tp *TxPool) isShardTracked(shardID byte) bool {
+	if tp.config.NodeMode == "relay" && len(tp.config.RelayShards) == 0 {
+		return true
+	}
+	for _, relayShardID := range tp.config.RelayShards {
+		if relayShardID == shardID {
+			return true
+		}
+	}
+	if tp.config.NodeMode != "shard" && tp.config.NodeMode != "auto" {
+		return false
+	}
+	if tp.config.UserKeySet == nil || tp.config.BlockChain == nil || tp.config.BlockChain.BestState.Beacon == nil {
+		return false
+	}
+	role, roleShardID := tp.config.BlockChain.BestState.Beacon.GetPubkeyRole(tp.config.UserKeySet.GetPublicKeyB58())
+	return role == "shard" && roleShardID == shardID
+}
+
+/*
+checkShard - reject tx of a shard not tracked by node or without best state
+This function MUST be called with the mempool lock held (for reads).
+*/
+func (tp *TxPool) checkShard(txHash *common.Hash, shardID byte) error {
+	if !tp.isShardTracked(shardID) {
+		err := MempoolTxError{}
+		err.Init(RejectUntrackedShard, fmt.Errorf("transaction %+v is sent from shard %d which is not tracked by node", txHash.String(), shardID))
+		return err
+	}
+	if bestState, ok := tp.config.BlockChain.BestState.Shard[shardID]; !ok || bestState == nil || bestState.BestShardBlock == nil {
+		err := MempoolTxError{}
+		err.Init(RejectNoShardState, fmt.Errorf("transaction %+v is sent from shard %d which has no best state", txHash.String(), shardID))
+		return err
+	}
+	return nil
+}
+
+// ShardStats returns stats of pool of every shard which has txs
+func (tp *TxPool) ShardStats() map[byte]ShardPoolStats {
+	tp.mtx.RLock()
+	defer tp.mtx.RUnlock()
+	stats := make(map[byte]ShardPoolStats)
+	for shardID, pool := range tp.shardTxPools {
+		if len(pool.txs) == 0 {
+			continue
+		}
+		maxFee := uint64(0)
+		for _, txDesc := range pool.txs {
+			if txDesc.Desc.Fee > maxFee {
+				maxFee = txDesc.Desc.Fee
+			}
+		}
+		stats[shardID] = ShardPoolStats{
+			Count:  len(pool.txs),
+			Size:   pool.size,
+			MaxFee: maxFee,
+		}
+	}
+	return stats
+}
@@ -54,23 +54,15 @@ var DefaultShardToBeaconPoolConfig = ShardToBeaconPoolConfig{
 	LifeTime:   10 * time.Hour,
 }
 
-var shardToBeaconPool = &ShardToBeaconPool{}
-
-func InitShardToBeaconPool(shardToBeaconPoolConfig ShardToBeaconPoolConfig) *ShardToBeaconPool {
-	if shardToBeaconPool != nil {
-		return shardToBeaconPool
-	}
-	// return &ShardToBeaconPool{
-	// 	config:  shardToBeaconPoolConfig,
-	// 	pending: make(map[byte][]blockchain.ShardToBeaconBlock),
-	// 	queue:   make(map[byte]map[uint64]blockchain.ShardToBeaconBlock),
-	// }
-	shardToBeaconPool = &ShardToBeaconPool{
-		config:  shardToBeaconPoolConfig,
-		pending: make(map[byte][]blockchain.ShardToBeaconBlock),
-		queue:   make(map[byte]map[uint64]blockchain.ShardToBeaconBlock),
+// NewShardToBeaconPool returns a pool of its own, pools of different nodes
+// running in one process do not share state
+func NewShardToBeaconPool(shardToBeaconPoolConfig ShardToBeaconPoolConfig) *ShardToBeaconPool {
+	return &ShardToBeaconPool{
+		config:     shardToBeaconPoolConfig,
+		pending:    make(map[byte][]blockchain.ShardToBeaconBlock),
+		queue:      make(map[byte]map[uint64]blockchain.ShardToBeaconBlock),
+		shardState: make(map[byte]uint64),
 	}
-	return shardToBeaconPool
 }
 func (pool *ShardToBeaconPool) SetDatabase(db database.DatabaseInterface) {
 	beaconBestState := blockchain.BestStateBeacon{}
@@ -85,6 +77,8 @@ func (pool *ShardToBeaconPool) SetDatabase(db database.DatabaseInterface) {
 			panic("Can't Unmarshal beacon beststate")
 		}
 	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
 	if len(beaconBestState.BestShardHeight) == 0 {
 		pool.shardState = make(map[byte]uint64)
 	} else {
@@ -94,35 +88,40 @@ func (pool *ShardToBeaconPool) SetDatabase(db database.DatabaseInterface) {
 }
 func (pool *ShardToBeaconPool) GetFinalBlock() map[byte][]blockchain.ShardToBeaconBlock {
 	results := map[byte][]blockchain.ShardToBeaconBlock{}
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
 	for shardID, shardItems := range pool.pending {
-		results[shardID] = shardItems
+		results[shardID] = append([]blockchain.ShardToBeaconBlock{}, shardItems...)
 	}
 	return results
 }
 
+/*
+RemovePendingBlock - drop blocks of each shard up to the given height, which is
+the best height of shard in beacon best state, from pending and queue
+*/
 func (pool *ShardToBeaconPool) RemovePendingBlock(blockItems map[byte]uint64) error {
 	if len(blockItems) <= 0 {
 		Logger.log.Infof("ShardToBeaconPool: Remove Block items but got empty")
 		return nil
 	}
 	pool.mu.Lock()
+	defer pool.mu.Unlock()
 	for shardID, blockHeight := range blockItems {
-		shardItems, ok := pool.pending[shardID]
-		if !ok || len(shardItems) <= 0 {
-			Logger.log.Infof("ShardToBeaconPool: No pending block in shard %+v exsit", shardID)
-			continue
+		pending := []blockchain.ShardToBeaconBlock{}
+		for _, block := range pool.pending[shardID] {
+			if block.Header.Height > blockHeight {
+				pending = append(pending, block)
+			}
 		}
-		for index, block := range pool.pending[shardID] {
-			if block.Header.Height <= blockHeight {
-				continue
-			} else {
-				pool.pending[shardID] = pool.pending[shardID][index:]
-				break
+		pool.pending[shardID] = pending
+		for height := range pool.queue[shardID] {
+			if height <= blockHeight {
+				delete(pool.queue[shardID], height)
 			}
 		}
 		pool.shardState[shardID] = blockHeight
 	}
-	pool.mu.Unlock()
 	return nil
 }
 
@@ -138,6 +137,8 @@ func (pool *ShardToBeaconPool) RemovePendingBlock(blockItems map[byte]uint64) er
 */
 
 func (pool *ShardToBeaconPool) ValidateShardToBeaconBlock(block blockchain.ShardToBeaconBlock) error {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
 	blkHash := block.Header.Hash()
 	err := cashec.ValidateDataB58(block.Header.Producer, block.ProducerSig, blkHash.GetBytes())
 	if err != nil {
@@ -203,7 +204,7 @@ func contains2(s map[uint64]blockchain.ShardToBeaconBlock, e uint64) (blockchain
 	}
 	return blockchain.ShardToBeaconBlock{}, false
 }
-func (pool *ShardToBeaconPool) PromoteExecutable(block blockchain.ShardToBeaconBlock, committees []string) error {
+func (pool *ShardToBeaconPool) promoteExecutable(block blockchain.ShardToBeaconBlock, committees []string) error {
 	shardID := block.Header.ShardID
 	lastBlockHeight := pool.pending[shardID][len(pool.pending[shardID])-1].Header.Height
 	for {
@@ -211,7 +212,7 @@ func (pool *ShardToBeaconPool) PromoteExecutable(block blockchain.ShardToBeaconB
 		if isHas {
 			err := blockchain.ValidateAggSignature(newBlock.ValidatorsIdx, committees, newBlock.AggregatedSig, newBlock.R, newBlock.Hash())
 			if err == nil {
-				if err := pool.IsEnough(true, shardID); err == nil {
+				if err := pool.isEnough(true, shardID); err == nil {
 					pool.pending[shardID] = append(pool.pending[shardID], newBlock)
 					delete(pool.queue[shardID], lastBlockHeight+1)
 				} else {
@@ -228,12 +229,12 @@ func (pool *ShardToBeaconPool) PromoteExecutable(block blockchain.ShardToBeaconB
 	return nil
 }
 func (pool *ShardToBeaconPool) AddShardBeaconBlock(block blockchain.ShardToBeaconBlock, committees []string) error {
-	Logger.log.Debugf("Current pending shard to beacon block %+v \n", pool.pending)
 	blockHeight := block.Header.Height
 	shardID := block.Header.ShardID
 
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
+	Logger.log.Debugf("Current pending shard to beacon block %+v \n", pool.pending)
 	//Get pending shard block
 	pendingShardBlocks, ok := pool.pending[shardID]
 	if pendingShardBlocks == nil || !ok {
@@ -250,14 +251,14 @@ func (pool *ShardToBeaconPool) AddShardBeaconBlock(block blockchain.ShardToBeaco
 		if blockHeight-pool.shardState[shardID] == 1 {
 			pendingShardBlocks = append(pendingShardBlocks, block)
 			pool.pending[shardID] = pendingShardBlocks
-			pool.PromoteExecutable(block, committees)
+			pool.promoteExecutable(block, committees)
 		} else {
 			if len(pool.pending[shardID]) > 0 {
 				if pool.pending[shardID][len(pool.pending[shardID])-1].Header.Height == blockHeight {
-					if err := pool.IsEnough(true, shardID); err == nil {
+					if err := pool.isEnough(true, shardID); err == nil {
 						pendingShardBlocks = append(pendingShardBlocks, block)
 						pool.pending[shardID] = pendingShardBlocks
-						pool.PromoteExecutable(block, committees)
+						pool.promoteExecutable(block, committees)
 					} else {
 						Logger.log.Error(ShardToBeaconBoolError, err)
 						err := MempoolTxError{}
@@ -267,7 +268,7 @@ func (pool *ShardToBeaconPool) AddShardBeaconBlock(block blockchain.ShardToBeaco
 				}
 			} else {
 				pool.pending[shardID] = append(pool.pending[shardID], block)
-				pool.PromoteExecutable(block, committees)
+				pool.promoteExecutable(block, committees)
 			}
 		}
 	} else {
@@ -275,7 +276,7 @@ func (pool *ShardToBeaconPool) AddShardBeaconBlock(block blockchain.ShardToBeaco
 		if queueShardBlocks == nil || !ok {
 			queueShardBlocks = make(map[uint64]blockchain.ShardToBeaconBlock)
 		}
-		if err := pool.IsEnough(false, shardID); err == nil {
+		if err := pool.isEnough(false, shardID); err == nil {
 			queueShardBlocks[block.Header.Height] = block
 			pool.queue[shardID] = queueShardBlocks
 		} else {
@@ -304,7 +305,7 @@ func (pool *ShardToBeaconPool) GetPendingBlockHashes() map[byte][]common.Hash {
 	return pendingBlockHashes
 }
 
-func (pool *ShardToBeaconPool) IsEnough(isPending bool, shardID byte) error {
+func (pool *ShardToBeaconPool) isEnough(isPending bool, shardID byte) error {
 	if isPending {
 		if uint(len(pool.pending[shardID])) < pool.config.MaxPending {
 			return nil
@@ -320,11 +321,10 @@ func (pool *ShardToBeaconPool) IsEnough(isPending bool, shardID byte) error {
 	}
 }
 
-func GetShardToBeaconPoolInstance() *ShardToBeaconPool {
-	return shardToBeaconPool
-}
 func (pool *ShardToBeaconPool) GetShardToBeaconPoolState() map[byte][]uint64 {
 	result := map[byte][]uint64{}
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
 	pending := pool.pending
 	queue := pool.queue
 
@@ -0,0 +1,114 @@
+package mempool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/metadata"
+)
+
+// TxClass - kind of txs which have their fee estimated together, txs of
+// different kinds do not compete for the same block space price
+type TxClass byte
+
+const (
+	TxClassNormal TxClass = iota
+	TxClassCustomToken
+	TxClassPrivacyToken
+	TxClassLoan
+	TxClassCrowdsale
+	TxClassCMB
+	TxClassGOVToken
+	TxClassOracle
+	TxClassMultiSigs
+	TxClassVote
+	TxClassStaking
+	TxClassOtherMeta
+
+	numTxClasses
+)
+
+var txClassNames = map[TxClass]string{
+	TxClassNormal:       "normal",
+	TxClassCustomToken:  "customtoken",
+	TxClassPrivacyToken: "privacytoken",
+	TxClassLoan:         "loan",
+	TxClassCrowdsale:    "crowdsale",
+	TxClassCMB:          "cmb",
+	TxClassGOVToken:     "govtoken",
+	TxClassOracle:       "oracle",
+	TxClassMultiSigs:    "multisigs",
+	TxClassVote:         "vote",
+	TxClassStaking:      "staking",
+	TxClassOtherMeta:    "othermeta",
+}
+
+func (txClass TxClass) String() string {
+	if s, ok := txClassNames[txClass]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// ParseTxClass returns class of name, empty name is the normal class
+func ParseTxClass(name string) (TxClass, error) {
+	if name == "" {
+		return TxClassNormal, nil
+	}
+	for txClass, s := range txClassNames {
+		if strings.EqualFold(s, name) {
+			return txClass, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tx class %+v", name)
+}
+
+/*
+TxClassOf - class of a tx of type carrying metadata of metaType, a tx with
+metadata is estimated with its metadata family whatever its type
+*/
+func TxClassOf(txType string, metaType int) TxClass {
+	switch metaType {
+	case metadata.InvalidMeta:
+	case metadata.LoanRequestMeta, metadata.LoanResponseMeta, metadata.LoanWithdrawMeta,
+		metadata.LoanUnlockMeta, metadata.LoanPaymentMeta, metadata.DividendMeta:
+		return TxClassLoan
+	case metadata.CrowdsaleRequestMeta, metadata.CrowdsalePaymentMeta,
+		metadata.ReserveRequestMeta, metadata.ReserveResponseMeta, metadata.ReservePaymentMeta:
+		return TxClassCrowdsale
+	case metadata.CMBInitRequestMeta, metadata.CMBInitResponseMeta, metadata.CMBInitRefundMeta,
+		metadata.CMBDepositContractMeta, metadata.CMBDepositSendMeta, metadata.CMBWithdrawRequestMeta,
+		metadata.CMBWithdrawResponseMeta, metadata.CMBLoanContractMeta:
+		return TxClassCMB
+	case metadata.BuyFromGOVRequestMeta, metadata.BuyFromGOVResponseMeta, metadata.BuyBackRequestMeta,
+		metadata.BuyBackResponseMeta, metadata.IssuingRequestMeta, metadata.IssuingResponseMeta,
+		metadata.ContractingRequestMeta, metadata.BuyGOVTokenRequestMeta:
+		return TxClassGOVToken
+	case metadata.OracleFeedMeta, metadata.OracleRewardMeta, metadata.UpdatingOracleBoardMeta:
+		return TxClassOracle
+	case metadata.MultiSigsRegistrationMeta, metadata.MultiSigsSpendingMeta:
+		return TxClassMultiSigs
+	case metadata.ShardStakingMeta, metadata.BeaconStakingMeta:
+		return TxClassStaking
+	default:
+		if metaType >= metadata.SubmitDCBProposalMeta && metaType <= metadata.PunishGOVDecryptMeta {
+			return TxClassVote
+		}
+		return TxClassOtherMeta
+	}
+
+	switch txType {
+	case common.TxCustomTokenType:
+		return TxClassCustomToken
+	case common.TxCustomTokenPrivacyType:
+		return TxClassPrivacyToken
+	default:
+		return TxClassNormal
+	}
+}
+
+// GetTxClass returns class of tx
+func GetTxClass(tx metadata.Transaction) TxClass {
+	return TxClassOf(tx.GetType(), tx.GetMetadataType())
+}
@@ -0,0 +1,24 @@
+package metadata
+
+import (
+	"strconv"
+
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// metadata are tagged with their type, as ParseMetadata does for json
+	codec.RegisterInterface((*Metadata)(nil), func(value interface{}) (string, error) {
+		return strconv.Itoa(value.(Metadata).GetType()), nil
+	}, func(tag string) (interface{}, error) {
+		metaType, err := strconv.Atoi(tag)
+		if err != nil {
+			return nil, err
+		}
+		if strconv.Itoa(metaType) != tag {
+			return nil, errors.Errorf("invalid metadata tag %s", tag)
+		}
+		return NewMetadata(metaType)
+	})
+}
@@ -20,8 +20,22 @@ func ParseMetadata(meta interface{}) (Metadata, error) {
 	if err != nil {
 		return nil, err
 	}
+	md, err := NewMetadata(int(mtTemp["Type"].(float64)))
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(metaInBytes, &md)
+	if err != nil {
+		return nil, err
+	}
+	return md, nil
+}
+
+// NewMetadata returns an empty metadata of the concrete type used for metaType
+func NewMetadata(metaType int) (Metadata, error) {
 	var md Metadata
-	switch int(mtTemp["Type"].(float64)) {
+	switch metaType {
 	case BuyFromGOVRequestMeta:
 		md = &BuySellRequest{}
 
@@ -79,6 +93,41 @@ func ParseMetadata(meta interface{}) (Metadata, error) {
 	case LoanUnlockMeta:
 		md = &LoanUnlock{}
 
+	case DividendMeta:
+		md = &Dividend{}
+
+	case CrowdsaleRequestMeta:
+		md = &CrowdsaleRequest{}
+	case CrowdsalePaymentMeta:
+		md = &CrowdsalePayment{}
+
+	case ReserveRequestMeta:
+		md = &ReserveRequest{}
+	case ReserveResponseMeta:
+		md = &ReserveResponse{}
+	case ReservePaymentMeta:
+		md = &ReservePayment{}
+
+	case CMBInitRequestMeta:
+		md = &CMBInitRequest{}
+	case CMBInitResponseMeta:
+		md = &CMBInitResponse{}
+	case CMBInitRefundMeta:
+		md = &CMBInitRefund{}
+	case CMBDepositContractMeta:
+		md = &CMBDepositContract{}
+	case CMBDepositSendMeta:
+		md = &CMBDepositSend{}
+	case CMBWithdrawRequestMeta:
+		md = &CMBWithdrawRequest{}
+	case CMBWithdrawResponseMeta:
+		md = &CMBWithdrawResponse{}
+	case CMBLoanContractMeta:
+		md = &CMBLoanContract{}
+
+	case BuyGOVTokenRequestMeta:
+		md = &BuyGOVTokenRequest{}
+
 	case SubmitDCBProposalMeta:
 		md = &SubmitDCBProposalMetadata{}
 	case VoteDCBBoardMeta:
@@ -142,12 +191,7 @@ func ParseMetadata(meta interface{}) (Metadata, error) {
 		md = &StakingMetadata{}
 
 	default:
-		return nil, errors.Errorf("Could not parse metadata with type: %d", int(mtTemp["Type"].(float64)))
-	}
-
-	err = json.Unmarshal(metaInBytes, &md)
-	if err != nil {
-		return nil, err
+		return nil, errors.Errorf("Could not parse metadata with type: %d", metaType)
 	}
 	return md, nil
 }
@@ -152,6 +152,7 @@ type Transaction interface {
 	ValidateTxWithBlockChain(BlockchainRetriever, byte, database.DatabaseInterface) error
 	ValidateSanityData(BlockchainRetriever) (bool, error)
 	ValidateTxByItself(bool, database.DatabaseInterface, BlockchainRetriever, byte) bool
+	VerifySigTx() (bool, error)
 	ValidateType() bool
 	GetMetadata() Metadata
 	SetMetadata(Metadata)
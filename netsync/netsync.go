@@ -7,22 +7,40 @@ import (
 
 	libp2p "github.com/libp2p/go-libp2p-peer"
 	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/mempool"
 	"github.com/ninjadotorg/constant/peer"
 	"github.com/ninjadotorg/constant/wire"
 )
 
+const (
+	// workers handling messages of peers, txs have their own workers so a tx
+	// flood does not delay blocks and consensus messages
+	DefaultMessageWorkers = 16
+	DefaultTxWorkers      = 8
+	// messages waiting for a free worker, queueing blocks when it is full
+	messageQueueSize = 1000
+)
+
 type NetSync struct {
 	started   int32
 	shutdown  int32
 	waitgroup sync.WaitGroup
 
 	cMessage chan interface{}
+	cTx      chan interface{}
 	cQuit    chan struct{}
 
 	config *NetSyncConfig
 }
 
+// peerMessage - block message queued with the peer which sent it, so the peer
+// is blamed when the block is rejected
+type peerMessage struct {
+	msg    wire.Message
+	peerID libp2p.ID
+}
+
 type NetSyncConfig struct {
 	BlockChain *blockchain.BlockChain
 	ChainParam *blockchain.Params
@@ -31,16 +49,21 @@ type NetSyncConfig struct {
 		// list functions callback which are assigned from Server struct
 		PushMessageToPeer(wire.Message, libp2p.ID) error
 		PushMessageToAll(wire.Message) error
+		OnTxRejected(txHash common.Hash)
 	}
 	Consensus interface {
 		OnBFTMsg(wire.Message)
 	}
+	// number of workers, defaults are used when they are not positive
+	MessageWorkers int
+	TxWorkers      int
 }
 
 func (netSync NetSync) New(cfg *NetSyncConfig) *NetSync {
 	netSync.config = cfg
 	netSync.cQuit = make(chan struct{})
-	netSync.cMessage = make(chan interface{})
+	netSync.cMessage = make(chan interface{}, messageQueueSize)
+	netSync.cTx = make(chan interface{}, messageQueueSize)
 	return &netSync
 }
 
@@ -50,8 +73,21 @@ func (netSync *NetSync) Start() {
 		return
 	}
 	Logger.log.Info("Starting sync manager")
-	netSync.waitgroup.Add(1)
-	go netSync.messageHandler()
+	messageWorkers := netSync.config.MessageWorkers
+	if messageWorkers <= 0 {
+		messageWorkers = DefaultMessageWorkers
+	}
+	txWorkers := netSync.config.TxWorkers
+	if txWorkers <= 0 {
+		txWorkers = DefaultTxWorkers
+	}
+	netSync.waitgroup.Add(messageWorkers + txWorkers)
+	for i := 0; i < messageWorkers; i++ {
+		go netSync.messageHandler(netSync.cMessage)
+	}
+	for i := 0; i < txWorkers; i++ {
+		go netSync.messageHandler(netSync.cTx)
+	}
 }
 
 // Stop gracefully shuts down the sync manager by stopping all asynchronous
@@ -65,128 +101,126 @@ func (netSync *NetSync) Stop() {
 	close(netSync.cQuit)
 }
 
-// messageHandler is the main handler for the sync manager.  It must be run as a
-// goroutine.  It processes block and inv messages in a separate goroutine
-// from the peer handlers so the block (MsgBlock) messages are handled by a
-// single thread without needing to lock memory data structures.  This is
-// important because the sync manager controls which blocks are needed and how
-// the fetching should proceed.
-func (netSync *NetSync) messageHandler() {
+// messageHandler is a worker of the sync manager, messages of cMessage are
+// handled by a bounded number of workers instead of a goroutine per message so
+// a flood of messages is queued instead of exhausting the node. It must be run
+// as a goroutine.
+func (netSync *NetSync) messageHandler(cMessage <-chan interface{}) {
 out:
 	for {
 		select {
-		case msgChan := <-netSync.cMessage:
-			{
-				go func(msgC interface{}) {
-					switch msg := msgC.(type) {
-					case *wire.MessageTx:
-						{
-							netSync.HandleMessageTx(msg)
-						}
-						//case *wire.MessageRegistration:
-						//	{
-						//		netSync.HandleMessageRegisteration(msg)
-						//	}
-					case *wire.MessageBFTPropose:
-						{
-							netSync.HandleMessageBFTMsg(msg)
-						}
-					case *wire.MessageBFTPrepare:
-						{
-							netSync.HandleMessageBFTMsg(msg)
-						}
-					case *wire.MessageBFTCommit:
-						{
-							netSync.HandleMessageBFTMsg(msg)
-						}
-					case *wire.MessageBFTReady:
-						{
-							netSync.HandleMessageBFTMsg(msg)
-						}
-					case *wire.MessageBlockBeacon:
-						{
-							netSync.HandleMessageBlockBeacon(msg)
-						}
-					case *wire.MessageBlockShard:
-						{
-							netSync.HandleMessageBlockShard(msg)
-						}
-					case *wire.MessageGetCrossShard:
-						{
-							netSync.HandleMessageGetCrossShard(msg)
-						}
-					case *wire.MessageCrossShard:
-						{
-							netSync.HandleMessageCrossShard(msg)
-						}
-					case *wire.MessageGetShardToBeacon:
-						{
-							netSync.HandleMessageGetShardToBeacon(msg)
-						}
-					case *wire.MessageGetShardToBeacons:
-						{
-							netSync.HandleMessageGetShardToBeacons(msg)
-						}
-					case *wire.MessageShardToBeacon:
-						{
-							netSync.HandleMessageShardToBeacon(msg)
-						}
-					case *wire.MessageGetBlockBeacon:
-						{
-							netSync.HandleMessageGetBlockBeacon(msg)
-						}
-					case *wire.MessageGetBlockShard:
-						{
-							netSync.HandleMessageGetBlockShard(msg)
-						}
-
-					// case *wire.MessageInvalidBlock:
-					// 	{
-					// 		netSync.HandleMessageInvalidBlock(msg)
-					// 	}
-					case *wire.MessageGetBeaconState:
-						{
-							netSync.HandleMessageGetBeaconState(msg)
-						}
-					case *wire.MessageBeaconState:
-						{
-							netSync.HandleMessageBeaconState(msg)
-						}
-					case *wire.MessageGetShardState:
-						{
-							netSync.HandleMessageGetShardState(msg)
-						}
-					case *wire.MessageShardState:
-						{
-							netSync.HandleMessageShardState(msg)
-						}
-					// case *wire.MessageSwapRequest:
-					// 	{
-					// 		netSync.HandleMessageSwapRequest(msg)
-					// 	}
-					// case *wire.MessageSwapSig:
-					// 	{
-					// 		netSync.HandleMessageSwapSig(msg)
-					// 	}
-					// case *wire.MessageSwapUpdate:
-					// 	{
-					// 		netSync.HandleMessageSwapUpdate(msg)
-					// 	}
-					default:
-						Logger.log.Infof("Invalid message type in block "+"handler: %T", msg)
-					}
-				}(msgChan)
-			}
-		case msgChan := <-netSync.cQuit:
-			{
-				Logger.log.Warn(msgChan)
-				break out
-			}
+		case msg := <-cMessage:
+			netSync.handleMessage(msg)
+		case <-netSync.cQuit:
+			break out
 		}
 	}
 
 	netSync.waitgroup.Done()
-	Logger.log.Info("Block handler done")
+	Logger.log.Info("Sync manager worker done")
+}
+
+func (netSync *NetSync) handleMessage(msgC interface{}) {
+	switch msg := msgC.(type) {
+	case *wire.MessageTx:
+		{
+			netSync.HandleMessageTx(msg)
+		}
+		//case *wire.MessageRegistration:
+		//	{
+		//		netSync.HandleMessageRegisteration(msg)
+		//	}
+	case *wire.MessageBFTPropose:
+		{
+			netSync.HandleMessageBFTMsg(msg)
+		}
+	case *wire.MessageBFTPrepare:
+		{
+			netSync.HandleMessageBFTMsg(msg)
+		}
+	case *wire.MessageBFTCommit:
+		{
+			netSync.HandleMessageBFTMsg(msg)
+		}
+	case *wire.MessageBFTReady:
+		{
+			netSync.HandleMessageBFTMsg(msg)
+		}
+	case *peerMessage:
+		{
+			switch blockMsg := msg.msg.(type) {
+			case *wire.MessageBlockBeacon:
+				netSync.HandleMessageBlockBeacon(blockMsg, msg.peerID)
+			case *wire.MessageBlockShard:
+				netSync.HandleMessageBlockShard(blockMsg, msg.peerID)
+			default:
+				netSync.handleMessage(msg.msg)
+			}
+		}
+	case *wire.MessageGetCrossShard:
+		{
+			netSync.HandleMessageGetCrossShard(msg)
+		}
+	case *wire.MessageCrossShard:
+		{
+			netSync.HandleMessageCrossShard(msg)
+		}
+	case *wire.MessageGetShardToBeacon:
+		{
+			netSync.HandleMessageGetShardToBeacon(msg)
+		}
+	case *wire.MessageGetShardToBeacons:
+		{
+			netSync.HandleMessageGetShardToBeacons(msg)
+		}
+	case *wire.MessageShardToBeacon:
+		{
+			netSync.HandleMessageShardToBeacon(msg)
+		}
+	case *wire.MessageGetBlockBeacon:
+		{
+			netSync.HandleMessageGetBlockBeacon(msg)
+		}
+	case *wire.MessageGetBlockShard:
+		{
+			netSync.HandleMessageGetBlockShard(msg)
+		}
+
+	// case *wire.MessageInvalidBlock:
+	// 	{
+	// 		netSync.HandleMessageInvalidBlock(msg)
+	// 	}
+	case *wire.MessageGetBeaconState:
+		{
+			netSync.HandleMessageGetBeaconState(msg)
+		}
+	case *wire.MessageBeaconState:
+		{
+			netSync.HandleMessageBeaconState(msg)
+		}
+	case *wire.MessageGetShardState:
+		{
+			netSync.HandleMessageGetShardState(msg)
+		}
+	case *wire.MessageShardState:
+		{
+			netSync.HandleMessageShardState(msg)
+		}
+	// case *wire.MessageSwapRequest:
+	// 	{
+	// 		netSync.HandleMessageSwapRequest(msg)
+	// 	}
+	// case *wire.MessageSwapSig:
+	// 	{
+	// 		netSync.HandleMessageSwapSig(msg)
+	// 	}
+	// case *wire.MessageSwapUpdate:
+	// 	{
+	// 		netSync.HandleMessageSwapUpdate(msg)
+	// 	}
+	default:
+		Logger.log.Infof("Invalid message type in block "+"handler: %T", msg)
+	}
 }
 
 // QueueTx adds the passed transaction message and peer to the block handling
@@ -207,16 +241,22 @@ func (netSync *NetSync) QueueTx(peer *peer.Peer, msg *wire.MessageTx, done chan
 		done <- struct{}{}
 		return
 	}
-	netSync.cMessage <- msg
+	netSync.cTx <- msg
 }
 
 // handleTxMsg handles transaction messages from all peers.
 func (netSync *NetSync) HandleMessageTx(msg *wire.MessageTx) {
 	Logger.log.Info("Handling new message tx")
-	hash, txDesc, err := netSync.config.MemTxPool.MaybeAcceptTransaction(msg.Transaction)
+	hash, txDesc, err := netSync.config.MemTxPool.ProcessTransaction(msg.Transaction)
 
 	if err != nil {
 		Logger.log.Error(err)
+		if mempool.IsMalleableReject(err) {
+			netSync.config.Server.OnTxRejected(*msg.Transaction.Hash())
+		}
+	} else if txDesc == nil {
+		// orphan tx is not relayed until its commitments are known
+		Logger.log.Infof("transaction %s is kept as orphan", hash.String())
 	} else {
 		Logger.log.Infof("there is hash of transaction %s", hash.String())
 		Logger.log.Infof("there is priority of transaction in pool: %d", txDesc.StartingPriority)
@@ -251,13 +291,17 @@ func (netSync *NetSync) HandleMessageTx(msg *wire.MessageTx) {
 // QueueBlock adds the passed block message and peer to the block handling
 // queue. Responds to the done channel argument after the block message is
 // processed.
-func (netSync *NetSync) QueueBlock(_ *peer.Peer, msg wire.Message, done chan struct{}) {
+func (netSync *NetSync) QueueBlock(peerConn *peer.PeerConn, msg wire.Message, done chan struct{}) {
 	// Don't accept more transactions if we're shutting down.
 	if atomic.LoadInt32(&netSync.shutdown) != 0 {
 		done <- struct{}{}
 		return
 	}
-	netSync.cMessage <- msg
+	blockMsg := &peerMessage{msg: msg}
+	if peerConn != nil {
+		blockMsg.peerID = peerConn.RemotePeerID
+	}
+	netSync.cMessage <- blockMsg
 }
 func (netSync *NetSync) QueueGetBlockShard(peer *peer.Peer, msg *wire.MessageGetBlockShard, done chan struct{}) {
 	// Don't accept more transactions if we're shutting down.
@@ -340,13 +384,13 @@ func (netSync *NetSync) HandleMessageGetBlockBeacon(msg *wire.MessageGetBlockBea
 	}
 }
 
-func (netSync *NetSync) HandleMessageBlockBeacon(msg *wire.MessageBlockBeacon) {
+func (netSync *NetSync) HandleMessageBlockBeacon(msg *wire.MessageBlockBeacon, peerID libp2p.ID) {
 	Logger.log.Info("Handling new message BlockBeacon")
-	netSync.config.BlockChain.OnBlockBeaconReceived(&msg.Block)
+	netSync.config.BlockChain.OnBlockBeaconReceived(&msg.Block, peerID)
 }
-func (netSync *NetSync) HandleMessageBlockShard(msg *wire.MessageBlockShard) {
+func (netSync *NetSync) HandleMessageBlockShard(msg *wire.MessageBlockShard, peerID libp2p.ID) {
 	Logger.log.Info("Handling new message BlockShard")
-	netSync.config.BlockChain.OnBlockShardReceived(&msg.Block)
+	netSync.config.BlockChain.OnBlockShardReceived(&msg.Block, peerID)
 }
 func (netSync *NetSync) HandleMessageCrossShard(msg *wire.MessageCrossShard) {
 	Logger.log.Info("Handling new message CrossShard")
@@ -0,0 +1,130 @@
+package peer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// BanScoreHalfLife - transient ban score is halved every BanScoreHalfLife, so a
+// peer which is sometimes rate limited or sends a stale block is not banned
+// over a long connection
+const BanScoreHalfLife = time.Minute
+
+// MisbehaviorType - offence of a remote peer, each offence adds its score to
+// ban score of the peer connection
+type MisbehaviorType int
+
+const (
+	// message can not be decoded, unzipped or parsed
+	MisbehaviorMalformedMessage MisbehaviorType = iota
+	// message is larger than SPAM_MESSAGE_SIZE
+	MisbehaviorOversizedMessage
+	// message is parsed but fails VerifyMsgSanity
+	MisbehaviorInvalidMessage
+	// block message fails VerifyMsgSanity, ex: wrong producer signature
+	MisbehaviorInvalidBlock
+	// message exceeds rate limit of peer or of its type
+	MisbehaviorRateLimited
+	// block passes VerifyMsgSanity but is rejected by the chain
+	MisbehaviorRejectedBlock
+)
+
+var misbehaviorScore = map[MisbehaviorType]int32{
+	MisbehaviorMalformedMessage: 10,
+	MisbehaviorOversizedMessage: 100,
+	MisbehaviorInvalidMessage:   20,
+	MisbehaviorInvalidBlock:     100,
+	MisbehaviorRateLimited:      1,
+	MisbehaviorRejectedBlock:    20,
+}
+
+// transientMisbehavior - offences which may come from a honest peer, their
+// score decays over time
+var transientMisbehavior = map[MisbehaviorType]bool{
+	MisbehaviorRateLimited:   true,
+	MisbehaviorRejectedBlock: true,
+}
+
+var misbehaviorName = map[MisbehaviorType]string{
+	MisbehaviorMalformedMessage: "malformed message",
+	MisbehaviorOversizedMessage: "oversized message",
+	MisbehaviorInvalidMessage:   "invalid message",
+	MisbehaviorInvalidBlock:     "invalid block",
+	MisbehaviorRateLimited:      "rate limited",
+	MisbehaviorRejectedBlock:    "rejected block",
+}
+
+func (misbehavior MisbehaviorType) String() string {
+	return misbehaviorName[misbehavior]
+}
+
+/*
+banScore - persistent score of offences which a honest peer never does, plus a
+transient score decaying with BanScoreHalfLife
+*/
+type banScore struct {
+	mtx        sync.Mutex
+	persistent float64
+	transient  float64
+	lastDecay  time.Time
+}
+
+// decay must be called with the lock held
+func (score *banScore) decay(now time.Time) {
+	if score.transient > 0 && now.After(score.lastDecay) {
+		score.transient *= math.Exp2(-float64(now.Sub(score.lastDecay)) / float64(BanScoreHalfLife))
+		// drop the tail so an idle score ends at 0
+		if score.transient < 1 {
+			score.transient = 0
+		}
+	}
+	score.lastDecay = now
+}
+
+func (score *banScore) value(now time.Time) int32 {
+	score.mtx.Lock()
+	defer score.mtx.Unlock()
+	score.decay(now)
+	return int32(score.persistent + score.transient)
+}
+
+// increase adds delta to the persistent or transient score, returns the score
+// before and after
+func (score *banScore) increase(delta int32, transient bool, now time.Time) (int32, int32) {
+	score.mtx.Lock()
+	defer score.mtx.Unlock()
+	score.decay(now)
+	before := int32(score.persistent + score.transient)
+	if transient {
+		score.transient += float64(delta)
+	} else {
+		score.persistent += float64(delta)
+	}
+	return before, int32(score.persistent + score.transient)
+}
+
+// BanScore returns current ban score of remote peer
+func (peerConn *PeerConn) BanScore() int32 {
+	return peerConn.banScore.value(time.Now())
+}
+
+/*
+AddBanScore - increase ban score of remote peer by score of offence. When the
+score crosses Config.BanThreshold, HandleBanned is fired and the connection is
+closed. It returns true if the peer is banned by this offence
+*/
+func (peerConn *PeerConn) AddBanScore(misbehavior MisbehaviorType, reason string) bool {
+	before, score := peerConn.banScore.increase(misbehaviorScore[misbehavior], transientMisbehavior[misbehavior], time.Now())
+	Logger.log.Warnf("PEER %s misbehaving (%s): %s, ban score %d", peerConn.RemotePeerID.Pretty(), misbehavior, reason, score)
+	threshold := int32(peerConn.Config.BanThreshold)
+	if threshold <= 0 || score < threshold || before >= threshold {
+		return false
+	}
+	Logger.log.Warnf("PEER %s is banned, ban score %d reaches threshold %d", peerConn.RemotePeerID.Pretty(), score, threshold)
+	if peerConn.HandleBanned != nil {
+		peerConn.HandleBanned(peerConn, misbehavior.String()+": "+reason)
+	}
+	peerConn.ForceClose()
+	return true
+}
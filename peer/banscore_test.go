@@ -0,0 +1,83 @@
+package peer
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+func init() {
+	Logger.Init(common.NewBackend(ioutil.Discard).Logger("test"))
+}
+
+func newTestPeerConn(banThreshold int) (*PeerConn, *int) {
+	banned := 0
+	peerConn := &PeerConn{
+		Config: Config{BanThreshold: banThreshold},
+		HandleBanned: func(peerConn *PeerConn, reason string) {
+			banned++
+		},
+		cClose: make(chan struct{}),
+	}
+	return peerConn, &banned
+}
+
+func TestAddBanScore(t *testing.T) {
+	peerConn, banned := newTestPeerConn(100)
+	if peerConn.AddBanScore(MisbehaviorInvalidMessage, "test") {
+		t.Fatalf("score 20 should not ban")
+	}
+	if peerConn.BanScore() != 20 {
+		t.Fatalf("BanScore() = %d, want 20", peerConn.BanScore())
+	}
+	if !peerConn.AddBanScore(MisbehaviorInvalidBlock, "test") {
+		t.Fatalf("score 120 should ban")
+	}
+	if *banned != 1 || !peerConn.GetIsForceClose() {
+		t.Fatalf("banned peer should be handled once and closed")
+	}
+	// already banned, HandleBanned is not fired again
+	if peerConn.AddBanScore(MisbehaviorInvalidBlock, "test") || *banned != 1 {
+		t.Fatalf("peer should be banned only once")
+	}
+
+	disabled, banned := newTestPeerConn(0)
+	disabled.AddBanScore(MisbehaviorOversizedMessage, "test")
+	disabled.AddBanScore(MisbehaviorOversizedMessage, "test")
+	if *banned != 0 || disabled.GetIsForceClose() {
+		t.Fatalf("peer should not be banned when banning is disabled")
+	}
+}
+
+func TestBanScoreDecay(t *testing.T) {
+	now := time.Now()
+	score := banScore{}
+	score.increase(misbehaviorScore[MisbehaviorInvalidMessage], false, now)
+	score.increase(misbehaviorScore[MisbehaviorRejectedBlock], true, now)
+	if value := score.value(now); value != 40 {
+		t.Fatalf("value() = %d, want 40", value)
+	}
+	if value := score.value(now.Add(BanScoreHalfLife)); value != 30 {
+		t.Fatalf("value() after a half life = %d, want 30", value)
+	}
+	// transient score is gone, persistent score stays
+	if value := score.value(now.Add(20 * BanScoreHalfLife)); value != 20 {
+		t.Fatalf("value() after decay = %d, want 20", value)
+	}
+}
+
+func TestRateLimitedPeerIsNotBanned(t *testing.T) {
+	score := banScore{}
+	now := time.Now()
+	// rate limited every second during an hour, the score converges below the
+	// default threshold of 100
+	for i := 0; i < 3600; i++ {
+		now = now.Add(time.Second)
+		_, value := score.increase(misbehaviorScore[MisbehaviorRateLimited], transientMisbehavior[MisbehaviorRateLimited], now)
+		if value >= 100 {
+			t.Fatalf("ban score %d of peer rate limited every second should stay below 100", value)
+		}
+	}
+}
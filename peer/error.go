@@ -14,6 +14,9 @@ const (
 	OpeningStreamP2PErr
 
 	// PeerConn err
+	OversizedMessageErr
+	MalformedMessageErr
+	InvalidFrameErr
 )
 
 var ErrCodeMessage = map[int]struct {
@@ -28,6 +31,9 @@ var ErrCodeMessage = map[int]struct {
 	OpeningStreamP2PErr:      {-1004, "Fail in opening stream "},
 
 	// -2xxx for peer connection
+	OversizedMessageErr: {-2000, "Message is larger than limit"},
+	MalformedMessageErr: {-2001, "Message can not be decoded"},
+	InvalidFrameErr:     {-2002, "Binary frame is not of this network"},
 }
 
 type PeerError struct {
@@ -0,0 +1,32 @@
+package peer
+
+// MaxMessageHandlers - messages of a peer connection handled at the same time,
+// the reader waits for a free handler when all of them are busy, ex: blocked
+// on a full queue of netsync, so a flooding peer is slowed down by its own
+// stream instead of piling up goroutines
+const MaxMessageHandlers = 8
+
+// handlerPool - bounded number of goroutines handling messages
+type handlerPool struct {
+	slots chan struct{}
+}
+
+func newHandlerPool(size int) *handlerPool {
+	if size <= 0 {
+		size = 1
+	}
+	return &handlerPool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// run waits for a free handler, then runs handler in a goroutine
+func (pool *handlerPool) run(handler func()) {
+	pool.slots <- struct{}{}
+	go func() {
+		defer func() {
+			<-pool.slots
+		}()
+		handler()
+	}()
+}
@@ -0,0 +1,52 @@
+package peer
+
+import (
+	"github.com/ninjadotorg/constant/common"
+)
+
+// MaxKnownInventory - number of tx and block hashes remembered per peer
+// connection, the oldest one is forgotten first
+const MaxKnownInventory = 1000
+
+// GetInvRelay returns true if remote peer announced inv/getdata relay in its
+// version message
+func (peerConn *PeerConn) GetInvRelay() bool {
+	peerConn.invRelayMtx.Lock()
+	defer peerConn.invRelayMtx.Unlock()
+	return peerConn.invRelay
+}
+
+func (peerConn *PeerConn) SetInvRelay(v bool) {
+	peerConn.invRelayMtx.Lock()
+	defer peerConn.invRelayMtx.Unlock()
+	peerConn.invRelay = v
+}
+
+/*
+AddKnownInventory - remember that remote peer has the tx or block of hash, so
+it is neither announced nor sent to the peer again
+*/
+func (peerConn *PeerConn) AddKnownInventory(hash common.Hash) {
+	peerConn.knownInventoryMtx.Lock()
+	defer peerConn.knownInventoryMtx.Unlock()
+	if peerConn.knownInventory == nil {
+		peerConn.knownInventory = make(map[common.Hash]struct{})
+	}
+	if _, ok := peerConn.knownInventory[hash]; ok {
+		return
+	}
+	if len(peerConn.knownInventoryIDs) >= MaxKnownInventory {
+		delete(peerConn.knownInventory, peerConn.knownInventoryIDs[0])
+		peerConn.knownInventoryIDs = peerConn.knownInventoryIDs[1:]
+	}
+	peerConn.knownInventory[hash] = struct{}{}
+	peerConn.knownInventoryIDs = append(peerConn.knownInventoryIDs, hash)
+}
+
+// HasKnownInventory returns true if remote peer has the tx or block of hash
+func (peerConn *PeerConn) HasKnownInventory(hash common.Hash) bool {
+	peerConn.knownInventoryMtx.Lock()
+	defer peerConn.knownInventoryMtx.Unlock()
+	_, ok := peerConn.knownInventory[hash]
+	return ok
+}
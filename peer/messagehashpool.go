@@ -0,0 +1,169 @@
+package peer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/ninjadotorg/constant/wire"
+)
+
+// MessageHashPoolConfig - bounds of the pool of hashes of one message type
+type MessageHashPoolConfig struct {
+	Size int
+	// hashes older than TTL are forgotten even if the pool is not full
+	TTL time.Duration
+}
+
+// DefaultMessageHashPoolType - message type of the pool shared by message
+// types which are not in MessageHashPoolConfigs
+const DefaultMessageHashPoolType = "default"
+
+// DefaultMessageHashPoolConfig - bounds of message types which are not in
+// MessageHashPoolConfigs
+var DefaultMessageHashPoolConfig = MessageHashPoolConfig{
+	Size: MESSAGE_HASH_POOL_SIZE,
+	TTL:  10 * time.Minute,
+}
+
+/*
+MessageHashPoolConfigs - bounds per message type. Txs and BFT messages are
+relayed by every node of a shard or beacon committee, so their pools are
+larger to stop relay storms from amplifying them
+*/
+var MessageHashPoolConfigs = map[string]MessageHashPoolConfig{
+	wire.CmdTx:                 {Size: 10000, TTL: 10 * time.Minute},
+	wire.CmdCustomToken:        {Size: 5000, TTL: 10 * time.Minute},
+	wire.CmdPrivacyCustomToken: {Size: 5000, TTL: 10 * time.Minute},
+	wire.CmdBFTPropose:         {Size: 2000, TTL: 5 * time.Minute},
+	wire.CmdBFTPrepare:         {Size: 5000, TTL: 5 * time.Minute},
+	wire.CmdBFTCommit:          {Size: 5000, TTL: 5 * time.Minute},
+	wire.CmdBFTReady:           {Size: 5000, TTL: 5 * time.Minute},
+	wire.CmdBlockShard:         {Size: 2000, TTL: 30 * time.Minute},
+	wire.CmdBlockBeacon:        {Size: 2000, TTL: 30 * time.Minute},
+	wire.CmdCrossShard:         {Size: 2000, TTL: 30 * time.Minute},
+	wire.CmdBlkShardToBeacon:   {Size: 2000, TTL: 30 * time.Minute},
+	wire.CmdInv:                {Size: 10000, TTL: 5 * time.Minute},
+}
+
+// MessageHashPoolStats - counters of a message hash pool
+type MessageHashPoolStats struct {
+	MessageType string
+	Size        int
+	Capacity    int
+	Hits        uint64
+	Misses      uint64
+	Evicted     uint64
+	Expired     uint64
+}
+
+// HitRate returns part of lookups which find the hash, 0 without lookup
+func (stats MessageHashPoolStats) HitRate() float64 {
+	lookups := stats.Hits + stats.Misses
+	if lookups == 0 {
+		return 0
+	}
+	return float64(stats.Hits) / float64(lookups)
+}
+
+type messageHashEntry struct {
+	hash       string
+	expiration time.Time
+}
+
+/*
+messageHashPool - least recently used set of message hashes with a time to
+live, the least recently seen hash is evicted when the pool is full
+*/
+type messageHashPool struct {
+	config  MessageHashPoolConfig
+	mtx     sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	stats   MessageHashPoolStats
+}
+
+func newMessageHashPool(messageType string, config MessageHashPoolConfig) *messageHashPool {
+	return &messageHashPool{
+		config:  config,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		stats: MessageHashPoolStats{
+			MessageType: messageType,
+			Capacity:    config.Size,
+		},
+	}
+}
+
+// add keeps hash as the most recently seen one and renews its time to live
+func (pool *messageHashPool) add(hash string) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	expiration := time.Now().Add(pool.config.TTL)
+	if element, ok := pool.entries[hash]; ok {
+		element.Value.(*messageHashEntry).expiration = expiration
+		pool.lru.MoveToFront(element)
+		return
+	}
+	if pool.config.Size <= 0 {
+		return
+	}
+	pool.expire()
+	for pool.lru.Len() >= pool.config.Size {
+		pool.remove(pool.lru.Back())
+		pool.stats.Evicted++
+	}
+	pool.entries[hash] = pool.lru.PushFront(&messageHashEntry{
+		hash:       hash,
+		expiration: expiration,
+	})
+}
+
+// contains returns true if hash is seen and does not expire, a hit makes hash
+// the most recently seen one and renews its time to live, so the list stays
+// ordered by expiration
+func (pool *messageHashPool) contains(hash string) bool {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	element, ok := pool.entries[hash]
+	if ok && time.Now().After(element.Value.(*messageHashEntry).expiration) {
+		pool.remove(element)
+		pool.stats.Expired++
+		ok = false
+	}
+	if !ok {
+		pool.stats.Misses++
+		return false
+	}
+	pool.stats.Hits++
+	element.Value.(*messageHashEntry).expiration = time.Now().Add(pool.config.TTL)
+	pool.lru.MoveToFront(element)
+	return true
+}
+
+func (pool *messageHashPool) getStats() MessageHashPoolStats {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	stats := pool.stats
+	stats.Size = pool.lru.Len()
+	return stats
+}
+
+// expire drops hashes older than TTL from the back of the list, the lock must
+// be held
+func (pool *messageHashPool) expire() {
+	now := time.Now()
+	for element := pool.lru.Back(); element != nil; element = pool.lru.Back() {
+		if !now.After(element.Value.(*messageHashEntry).expiration) {
+			return
+		}
+		pool.remove(element)
+		pool.stats.Expired++
+	}
+}
+
+// remove drops element from the pool, the lock must be held
+func (pool *messageHashPool) remove(element *list.Element) {
+	delete(pool.entries, element.Value.(*messageHashEntry).hash)
+	pool.lru.Remove(element)
+}
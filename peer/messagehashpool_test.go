@@ -0,0 +1,38 @@
+package peer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ninjadotorg/constant/wire"
+)
+
+func TestMessageHashPoolOfUnknownTypes(t *testing.T) {
+	peerObj := &Peer{}
+	// cmd types read from headers of a remote peer
+	for i := 0; i < 100; i++ {
+		peerObj.HashToPool(fmt.Sprintf("junk%d", i), fmt.Sprintf("hash%d", i))
+	}
+	peerObj.HashToPool(wire.CmdTx, "tx")
+	stats := peerObj.MessageHashPoolStats()
+	if len(stats) != 2 {
+		t.Fatalf("MessageHashPoolStats() = %+v, want the default pool and the tx pool", stats)
+	}
+	if !peerObj.CheckHashPool("junk5", "hash5") || !peerObj.CheckHashPool("other", "hash5") {
+		t.Fatalf("unknown types should share the default pool")
+	}
+	if peerObj.CheckHashPool(wire.CmdTx, "hash5") {
+		t.Fatalf("known type should not see hashes of the default pool")
+	}
+	if !peerObj.CheckHashPool("", "tx") {
+		t.Fatalf("check without type should look in every pool")
+	}
+	for _, poolStats := range stats {
+		if poolStats.MessageType != DefaultMessageHashPoolType && poolStats.MessageType != wire.CmdTx {
+			t.Fatalf("unexpected pool %s", poolStats.MessageType)
+		}
+		if poolStats.MessageType == DefaultMessageHashPoolType && poolStats.Capacity != DefaultMessageHashPoolConfig.Size {
+			t.Fatalf("default pool capacity %d, want %d", poolStats.Capacity, DefaultMessageHashPoolConfig.Size)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"log"
 	mrand "math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -39,7 +40,8 @@ var MESSAGE_TO_BEACON = byte('b')
 
 // RemotePeer is present for libp2p node data
 type Peer struct {
-	messagePool    map[string]bool
+	// hashes of seen messages per message type, see messagehashpool.go
+	messagePools   map[string]*messageHashPool
 	messagePoolMtx sync.Mutex
 
 	// channel
@@ -69,6 +71,7 @@ type Peer struct {
 	HandleConnected    func(peerConn *PeerConn)
 	HandleDisconnected func(peerConn *PeerConn)
 	HandleFailed       func(peerConn *PeerConn)
+	HandleBanned       func(peerConn *PeerConn, reason string)
 }
 
 type NewPeerMsg struct {
@@ -88,6 +91,12 @@ type Config struct {
 	MaxOutPeers      int
 	MaxInPeers       int
 	MaxPeers         int
+	// ban score of a peer connection to be banned, 0 to disable banning
+	BanThreshold int
+	// network id used as magic of binary frames
+	NetID uint32
+	// do not limit messages of remote peers, see ratelimit.go
+	DisableRateLimit bool
 }
 
 /*
@@ -116,6 +125,8 @@ type MessageListeners struct {
 	OnVerAck            func(p *PeerConn, msg *wire.MessageVerAck)
 	OnGetAddr           func(p *PeerConn, msg *wire.MessageGetAddr)
 	OnAddr              func(p *PeerConn, msg *wire.MessageAddr)
+	OnInv               func(p *PeerConn, msg *wire.MessageInv)
+	OnGetData           func(p *PeerConn, msg *wire.MessageGetData)
 
 	//PBFT
 	OnBFTMsg func(p *PeerConn, msg wire.Message)
@@ -145,34 +156,70 @@ type outMsg struct {
 	//encoding wire.MessageEncoding
 }
 
-func (peerObj *Peer) HashToPool(hash string) {
+/*
+messageHashPool returns pool of hashes of messageType, it is created with
+bounds of MessageHashPoolConfigs on first use. Types which are not in
+MessageHashPoolConfigs, ex: cmd type sent by a remote peer, share one pool so
+that a peer can not create pools
+*/
+func (peerObj *Peer) messageHashPool(messageType string) *messageHashPool {
+	config, ok := MessageHashPoolConfigs[messageType]
+	if !ok {
+		messageType = DefaultMessageHashPoolType
+		config = DefaultMessageHashPoolConfig
+	}
 	peerObj.messagePoolMtx.Lock()
 	defer peerObj.messagePoolMtx.Unlock()
-
-	if peerObj.messagePool == nil {
-		peerObj.messagePool = make(map[string]bool)
+	if peerObj.messagePools == nil {
+		peerObj.messagePools = make(map[string]*messageHashPool)
 	}
-	ok, _ := peerObj.messagePool[hash]
-	if ok {
-		return
+	pool, ok := peerObj.messagePools[messageType]
+	if !ok {
+		pool = newMessageHashPool(messageType, config)
+		peerObj.messagePools[messageType] = pool
 	}
-	if len(peerObj.messagePool) >= MESSAGE_HASH_POOL_SIZE {
-		for k, _ := range peerObj.messagePool {
-			delete(peerObj.messagePool, k)
-			break
+	return pool
+}
+
+func (peerObj *Peer) HashToPool(messageType string, hash string) {
+	peerObj.messageHashPool(messageType).add(hash)
+}
+
+/*
+CheckHashPool - true if message of hash is seen recently. Without messageType,
+ex: msgcheck from peers which do not send it, every pool is checked
+*/
+func (peerObj *Peer) CheckHashPool(messageType string, hash string) bool {
+	if messageType != "" {
+		return peerObj.messageHashPool(messageType).contains(hash)
+	}
+	peerObj.messagePoolMtx.Lock()
+	pools := make([]*messageHashPool, 0, len(peerObj.messagePools))
+	for _, pool := range peerObj.messagePools {
+		pools = append(pools, pool)
+	}
+	peerObj.messagePoolMtx.Unlock()
+	for _, pool := range pools {
+		if pool.contains(hash) {
+			return true
 		}
 	}
-	peerObj.messagePool[hash] = true
+	return false
 }
 
-func (peerObj *Peer) CheckHashPool(hash string) bool {
+// MessageHashPoolStats returns counters of pool of each message type, ordered
+// by message type
+func (peerObj *Peer) MessageHashPoolStats() []MessageHashPoolStats {
 	peerObj.messagePoolMtx.Lock()
 	defer peerObj.messagePoolMtx.Unlock()
-	if peerObj.messagePool == nil {
-		peerObj.messagePool = make(map[string]bool)
+	result := make([]MessageHashPoolStats, 0, len(peerObj.messagePools))
+	for _, pool := range peerObj.messagePools {
+		result = append(result, pool.getStats())
 	}
-	ok := peerObj.messagePool[hash]
-	return ok
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].MessageType < result[j].MessageType
+	})
+	return result
 }
 
 /*
@@ -495,6 +542,7 @@ func (peerObj *Peer) handleConn(peer *Peer, cConn chan *PeerConn) (*PeerConn, er
 		HandleConnected:    peerObj.handleConnected,
 		HandleDisconnected: peerObj.handleDisconnected,
 		HandleFailed:       peerObj.handleFailed,
+		HandleBanned:       peerObj.handleBanned,
 	}
 
 	go peerConn.InMessageHandler(rw)
@@ -581,6 +629,7 @@ func (peerObj *Peer) handleStream(stream net.Stream, cDone chan *PeerConn) {
 		HandleConnected:    peerObj.handleConnected,
 		HandleDisconnected: peerObj.handleDisconnected,
 		HandleFailed:       peerObj.handleFailed,
+		HandleBanned:       peerObj.handleBanned,
 	}
 
 	peerObj.SetPeerConn(&peerConn)
@@ -696,6 +745,17 @@ func (peerObj *Peer) handleFailed(peerConn *PeerConn) {
 	}
 }
 
+/*
+handleBanned - handle when ban score of peer conn crosses ban threshold
+*/
+func (peerObj *Peer) handleBanned(peerConn *PeerConn, reason string) {
+	Logger.log.Infof("handleBanned %s %s", peerConn.RemotePeerID.Pretty(), reason)
+
+	if peerObj.HandleBanned != nil {
+		peerObj.HandleBanned(peerConn, reason)
+	}
+}
+
 /*
 retryPeerConnection - retry to connect to peer when being disconnected
 */
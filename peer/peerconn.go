@@ -4,18 +4,23 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 	"github.com/ninjadotorg/constant/wire"
 )
 
 type PeerConn struct {
+	// traffic of connection, first in struct for 64-bit atomic alignment
+	bytesReceived uint64
+	bytesSent     uint64
+
 	connState      ConnState
 	stateMtx       sync.RWMutex
 	verAckReceived bool
@@ -29,6 +34,7 @@ type PeerConn struct {
 	cMsgHash         map[string]chan bool
 
 	RetryCount int32
+	banScore   banScore
 
 	// remote peer info
 	RemotePeer       *Peer
@@ -44,6 +50,29 @@ type PeerConn struct {
 	isConnected    bool
 	isConnectedMtx sync.Mutex
 
+	// framing of each direction, owned by InMessageHandler and
+	// OutMessageHandler, switched to binary by verack
+	binaryIn  bool
+	binaryOut bool
+	// remote peer reads blocks and txs encoded with common/codec, announced
+	// in its version message
+	binaryCodec    bool
+	binaryCodecMtx sync.Mutex
+
+	// inventory relay, see inventory.go
+	invRelay          bool
+	invRelayMtx       sync.Mutex
+	knownInventory    map[common.Hash]struct{}
+	knownInventoryIDs []common.Hash
+	knownInventoryMtx sync.Mutex
+
+	// hashes of heavy messages which remote peer has
+	knownMessages    *messageHashPool
+	knownMessagesMtx sync.Mutex
+
+	limiter    *rateLimiter
+	limiterMtx sync.Mutex
+
 	Config Config
 
 	ListenerPeer *Peer
@@ -51,6 +80,7 @@ type PeerConn struct {
 	HandleConnected    func(peerConn *PeerConn)
 	HandleDisconnected func(peerConn *PeerConn)
 	HandleFailed       func(peerConn *PeerConn)
+	HandleBanned       func(peerConn *PeerConn, reason string)
 }
 
 func (peerConn *PeerConn) GetIsOutbound() bool {
@@ -89,6 +119,20 @@ func (peerConn *PeerConn) SetIsConnected(v bool) {
 	peerConn.isConnected = v
 }
 
+// GetBinaryCodec returns true if remote peer announced the binary codec in its
+// version message
+func (peerConn *PeerConn) GetBinaryCodec() bool {
+	peerConn.binaryCodecMtx.Lock()
+	defer peerConn.binaryCodecMtx.Unlock()
+	return peerConn.binaryCodec
+}
+
+func (peerConn *PeerConn) SetBinaryCodec(v bool) {
+	peerConn.binaryCodecMtx.Lock()
+	defer peerConn.binaryCodecMtx.Unlock()
+	peerConn.binaryCodec = v
+}
+
 func (peerConn *PeerConn) ReadString(rw *bufio.ReadWriter, delim byte, maxReadBytes int) (string, error) {
 	buf := make([]byte, 0)
 	bufL := 0
@@ -103,7 +147,7 @@ func (peerConn *PeerConn) ReadString(rw *bufio.ReadWriter, delim byte, maxReadBy
 		buf = append(buf, b)
 		bufL++
 		if bufL > maxReadBytes {
-			return "", errors.New("Limit bytes for message")
+			return "", NewPeerError(OversizedMessageErr, errors.New("Limit bytes for message"), nil)
 		}
 	}
 
@@ -115,6 +159,7 @@ Handle all in message
 */
 func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 	peerConn.SetIsConnected(true)
+	handlers := newHandlerPool(MaxMessageHandlers)
 	for {
 		Logger.log.Infof("PEER %s (address: %s) Reading stream", peerConn.RemotePeer.PeerID.Pretty(), peerConn.RemotePeer.RawAddress)
 		//str, errR := rw.ReadString(DelimMessageByte)
@@ -128,52 +173,67 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 		//	close(peerConn.cWrite)
 		//	return
 		//}
-		str, errR := peerConn.ReadString(rw, DelimMessageByte, SPAM_MESSAGE_SIZE)
+		msgBytes, errR := peerConn.readMessage(rw)
 		if errR != nil {
+			if peerErr, ok := errR.(*PeerError); ok && peerErr.code == ErrCodeMessage[MalformedMessageErr].code {
+				// message is skipped, next one is still readable
+				Logger.log.Error(errR)
+				peerConn.AddBanScore(MisbehaviorMalformedMessage, errR.Error())
+				continue
+			}
 			peerConn.SetIsConnected(false)
 			Logger.log.Error("---------------------------------------------------------------------")
 			Logger.log.Errorf("InMessageHandler ERROR %s %s", peerConn.RemotePeerID.Pretty(), peerConn.RemotePeer.RawAddress)
 			Logger.log.Error(errR)
 			Logger.log.Errorf("InMessageHandler QUIT %s %s", peerConn.RemotePeerID.Pretty(), peerConn.RemotePeer.RawAddress)
 			Logger.log.Error("---------------------------------------------------------------------")
+			if peerErr, ok := errR.(*PeerError); ok {
+				switch peerErr.code {
+				case ErrCodeMessage[OversizedMessageErr].code:
+					peerConn.AddBanScore(MisbehaviorOversizedMessage, errR.Error())
+				case ErrCodeMessage[InvalidFrameErr].code:
+					peerConn.AddBanScore(MisbehaviorMalformedMessage, errR.Error())
+				}
+			}
 			close(peerConn.cWrite)
 			return
 		}
 
-		if str != DelimMessageStr {
-			go func(msgStr string) {
-				// Parse Message header from last 24 bytes header message
-				jsonDecodeBytesRaw, _ := hex.DecodeString(msgStr)
+		if msgBytes != nil {
+			// remote peer sends binary frames after a verack announcing it,
+			// so switch before reading the next message
+			if !peerConn.binaryIn && isBinaryFramingVerAck(msgBytes) {
+				Logger.log.Infof("PEER %s switches to binary framing", peerConn.RemotePeerID.Pretty())
+				peerConn.binaryIn = true
+			}
+			if !peerConn.Config.DisableRateLimit && !peerConn.rateLimiter().allowPeer() {
+				peerConn.AddBanScore(MisbehaviorRateLimited, "too many messages")
+				continue
+			}
+			jsonDecodeBytes := msgBytes
+			handlers.run(func() {
+				// Parse Message body
+				messageBody := jsonDecodeBytes[:len(jsonDecodeBytes)-wire.MessageHeaderSize]
+
+				messageHeader := jsonDecodeBytes[len(jsonDecodeBytes)-wire.MessageHeaderSize:]
+
+				// get cmd type in header message
+				commandType := cmdTypeOfHeader(messageHeader)
 
 				// cache message hash S
-				hashMsgRaw := common.HashH(jsonDecodeBytesRaw).String()
-				if peerConn.ListenerPeer.CheckHashPool(hashMsgRaw) {
+				hashMsgRaw := common.HashH(jsonDecodeBytes).String()
+				if len(jsonDecodeBytes) >= HEAVY_MESSAGE_SIZE {
+					// remote peer has it, do not offer it back
+					peerConn.knownMessageHashPool().add(hashMsgRaw)
+				}
+				if peerConn.ListenerPeer.CheckHashPool(commandType, hashMsgRaw) {
 					Logger.log.Infof("InMessageHandler existed raw hash message %s", hashMsgRaw)
 					return
 				}
-				peerConn.ListenerPeer.HashToPool(hashMsgRaw)
+				peerConn.ListenerPeer.HashToPool(commandType, hashMsgRaw)
 				// cache message hash E
 
-				// unzip data before process
-				jsonDecodeBytes, err := common.GZipFromBytes(jsonDecodeBytesRaw)
-				if err != nil {
-					Logger.log.Error("Can not unzip from message")
-					Logger.log.Error(err)
-					return
-				}
-				// disconnect when received spam message
-				//if len(jsonDecodeBytes) >= SPAM_MESSAGE_SIZE {
-				//	Logger.log.Error("InMessageHandler received spam message")
-				//	peerConn.ForceClose()
-				//	return
-				//}
-
 				Logger.log.Infof("In message content : %s", string(jsonDecodeBytes))
-
-				// Parse Message body
-				messageBody := jsonDecodeBytes[:len(jsonDecodeBytes)-wire.MessageHeaderSize]
-
-				messageHeader := jsonDecodeBytes[len(jsonDecodeBytes)-wire.MessageHeaderSize:]
 				// check forward
 				if peerConn.Config.MessageListeners.GetCurrentRoleShard != nil {
 					cRole, cShard := peerConn.Config.MessageListeners.GetCurrentRoleShard()
@@ -183,7 +243,7 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 							fS := messageHeader[wire.MessageCmdTypeSize+1]
 							if *cShard != fS {
 								if peerConn.Config.MessageListeners.PushRawBytesToShard != nil {
-									peerConn.Config.MessageListeners.PushRawBytesToShard(peerConn, &jsonDecodeBytesRaw, *cShard)
+									peerConn.Config.MessageListeners.PushRawBytesToShard(peerConn, &jsonDecodeBytes, *cShard)
 								}
 								return
 							}
@@ -193,28 +253,51 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 						fT := messageHeader[wire.MessageCmdTypeSize]
 						if fT == MESSAGE_TO_BEACON && cRole != "beacon" {
 							if peerConn.Config.MessageListeners.PushRawBytesToBeacon != nil {
-								peerConn.Config.MessageListeners.PushRawBytesToBeacon(peerConn, &jsonDecodeBytesRaw)
+								peerConn.Config.MessageListeners.PushRawBytesToBeacon(peerConn, &jsonDecodeBytes)
 							}
 							return
 						}
 					}
 				}
 
-				// get cmd type in header message
-				commandInHeader := bytes.Trim(messageHeader[:wire.MessageCmdTypeSize], "\x00")
-				commandType := string(messageHeader[:len(commandInHeader)])
+				// cost of messages without a range is known from the header,
+				// limit them before decoding
+				if !peerConn.Config.DisableRateLimit && !rangeMessageTypes[commandType] && !peerConn.rateLimiter().allowMessage(commandType, 1) {
+					peerConn.AddBanScore(MisbehaviorRateLimited, "too many "+commandType+" messages")
+					return
+				}
+
 				// convert to particular message from message cmd type
 				message, err := wire.MakeEmptyMessage(string(commandType))
 				if err != nil {
 					Logger.log.Error("Can not find particular message for message cmd type")
 					Logger.log.Error(err)
+					peerConn.AddBanScore(MisbehaviorMalformedMessage, err.Error())
 					return
 				}
 
-				err = json.Unmarshal(messageBody, &message)
+				err = message.JsonDeserialize(string(messageBody))
 				if err != nil {
-					Logger.log.Error("Can not parse struct from json message")
+					Logger.log.Error("Can not parse struct from message")
 					Logger.log.Error(err)
+					peerConn.AddBanScore(MisbehaviorMalformedMessage, err.Error())
+					return
+				}
+				err = message.VerifyMsgSanity()
+				if err != nil {
+					Logger.log.Error("Message fails sanity check")
+					Logger.log.Error(err)
+					switch message.(type) {
+					case *wire.MessageBlockShard, *wire.MessageBlockBeacon, *wire.MessageShardToBeacon, *wire.MessageCrossShard:
+						peerConn.AddBanScore(MisbehaviorInvalidBlock, err.Error())
+					default:
+						peerConn.AddBanScore(MisbehaviorInvalidMessage, err.Error())
+					}
+					return
+				}
+				clampBlockRange(message)
+				if !peerConn.Config.DisableRateLimit && rangeMessageTypes[commandType] && !peerConn.rateLimiter().allowMessage(commandType, messageCost(message)) {
+					peerConn.AddBanScore(MisbehaviorRateLimited, "too many "+commandType+" messages")
 					return
 				}
 				realType := reflect.TypeOf(message)
@@ -222,11 +305,14 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 
 				// cache message hash S
 				hashMsg := message.Hash()
-				if peerConn.ListenerPeer.CheckHashPool(hashMsg) {
+				if len(jsonDecodeBytes) >= HEAVY_MESSAGE_SIZE {
+					peerConn.knownMessageHashPool().add(hashMsg)
+				}
+				if peerConn.ListenerPeer.CheckHashPool(commandType, hashMsg) {
 					Logger.log.Infof("InMessageHandler existed hash message %s", hashMsg)
 					return
 				}
-				peerConn.ListenerPeer.HashToPool(hashMsg)
+				peerConn.ListenerPeer.HashToPool(commandType, hashMsg)
 				// cache message hash E
 
 				// process message for each of message type
@@ -289,6 +375,14 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 					if peerConn.Config.MessageListeners.OnGetAddr != nil {
 						peerConn.Config.MessageListeners.OnAddr(peerConn, message.(*wire.MessageAddr))
 					}
+				case reflect.TypeOf(&wire.MessageInv{}):
+					if peerConn.Config.MessageListeners.OnInv != nil {
+						peerConn.Config.MessageListeners.OnInv(peerConn, message.(*wire.MessageInv))
+					}
+				case reflect.TypeOf(&wire.MessageGetData{}):
+					if peerConn.Config.MessageListeners.OnGetData != nil {
+						peerConn.Config.MessageListeners.OnGetData(peerConn, message.(*wire.MessageGetData))
+					}
 				case reflect.TypeOf(&wire.MessageBFTPropose{}):
 					if peerConn.Config.MessageListeners.OnBFTMsg != nil {
 						peerConn.Config.MessageListeners.OnBFTMsg(peerConn, message.(*wire.MessageBFTPropose))
@@ -348,9 +442,120 @@ func (peerConn *PeerConn) InMessageHandler(rw *bufio.ReadWriter) {
 				default:
 					Logger.log.Warnf("InMessageHandler Received unhandled message of type % from %v", realType, peerConn)
 				}
-			}(str)
+			})
+		}
+	}
+}
+
+/*
+readMessage - read next message of stream in framing negotiated with remote
+peer, returns body of message followed by its MessageHeaderSize header, or nil
+for an empty line
+*/
+func (peerConn *PeerConn) readMessage(rw *bufio.ReadWriter) ([]byte, error) {
+	var msgBytes []byte
+	if peerConn.binaryIn {
+		messageHeader, payload, err := wire.ReadFrame(rw, peerConn.Config.NetID, SPAM_MESSAGE_SIZE)
+		switch err {
+		case nil:
+			atomic.AddUint64(&peerConn.bytesReceived, uint64(wire.FrameHeaderSize+len(payload)))
+			msgBytes = append(payload, messageHeader...)
+		case wire.ErrFrameTooLarge:
+			return nil, NewPeerError(OversizedMessageErr, err, nil)
+		case wire.ErrFrameMagic:
+			return nil, NewPeerError(InvalidFrameErr, err, nil)
+		case wire.ErrFrameChecksum:
+			return nil, NewPeerError(MalformedMessageErr, err, nil)
+		default:
+			return nil, err
+		}
+	} else {
+		str, err := peerConn.ReadString(rw, DelimMessageByte, SPAM_MESSAGE_SIZE)
+		if err != nil {
+			return nil, err
 		}
+		// line and its delim
+		atomic.AddUint64(&peerConn.bytesReceived, uint64(len(str)+1))
+		if str == common.EmptyString {
+			return nil, nil
+		}
+		jsonDecodeBytesRaw, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, NewPeerError(MalformedMessageErr, err, nil)
+		}
+		// unzip data before process
+		msgBytes, err = common.GZipFromBytes(jsonDecodeBytesRaw)
+		if err != nil {
+			return nil, NewPeerError(MalformedMessageErr, err, nil)
+		}
+	}
+	if len(msgBytes) < wire.MessageHeaderSize {
+		return nil, NewPeerError(MalformedMessageErr, errors.New("message is shorter than header"), nil)
+	}
+	return msgBytes, nil
+}
+
+// knownMessageHashPool returns pool of hashes of heavy messages which remote
+// peer has, they are neither offered by msgcheck nor sent to it again
+func (peerConn *PeerConn) knownMessageHashPool() *messageHashPool {
+	peerConn.knownMessagesMtx.Lock()
+	defer peerConn.knownMessagesMtx.Unlock()
+	if peerConn.knownMessages == nil {
+		peerConn.knownMessages = newMessageHashPool("", DefaultMessageHashPoolConfig)
+	}
+	return peerConn.knownMessages
+}
+
+// rateLimiter returns token buckets limiting messages of remote peer
+func (peerConn *PeerConn) rateLimiter() *rateLimiter {
+	peerConn.limiterMtx.Lock()
+	defer peerConn.limiterMtx.Unlock()
+	if peerConn.limiter == nil {
+		peerConn.limiter = newRateLimiter()
+	}
+	return peerConn.limiter
+}
+
+// cmdTypeOfHeader returns cmd type of message in its MessageHeaderSize header
+func cmdTypeOfHeader(messageHeader []byte) string {
+	return string(bytes.Trim(messageHeader[:wire.MessageCmdTypeSize], "\x00"))
+}
+
+// isBinaryFramingVerAck returns true if msgBytes is a verack announcing binary
+// framing
+func isBinaryFramingVerAck(msgBytes []byte) bool {
+	messageHeader := msgBytes[len(msgBytes)-wire.MessageHeaderSize:]
+	if cmdTypeOfHeader(messageHeader) != wire.CmdVerack {
+		return false
+	}
+	msg := &wire.MessageVerAck{}
+	if err := msg.JsonDeserialize(string(msgBytes[:len(msgBytes)-wire.MessageHeaderSize])); err != nil {
+		return false
 	}
+	return msg.BinaryFraming
+}
+
+/*
+jsonMessageBytes - forwarded bytes are sent as received, a payload encoded with
+the binary codec is re-encoded in json for a peer which does not read it
+*/
+func jsonMessageBytes(messageBytes []byte) ([]byte, error) {
+	headerIndex := len(messageBytes) - wire.MessageHeaderSize
+	if headerIndex < 0 || !codec.IsEncoded(messageBytes[:headerIndex]) {
+		return messageBytes, nil
+	}
+	msg, err := wire.MakeEmptyMessage(cmdTypeOfHeader(messageBytes[headerIndex:]))
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.JsonDeserialize(string(messageBytes[:headerIndex])); err != nil {
+		return nil, err
+	}
+	jsonBytes, err := msg.JsonSerialize()
+	if err != nil {
+		return nil, err
+	}
+	return append(jsonBytes, messageBytes[headerIndex:]...), nil
 }
 
 /*
@@ -364,16 +569,23 @@ func (peerConn *PeerConn) OutMessageHandler(rw *bufio.ReadWriter) {
 		select {
 		case outMsg := <-peerConn.sendMessageQueue:
 			{
-				var sendString string
+				var messageBytes []byte
 				if outMsg.rawBytes != nil && len(*outMsg.rawBytes) > 0 {
 					Logger.log.Infof("OutMessageHandler with raw bytes")
-					message := hex.EncodeToString(*outMsg.rawBytes)
-					message += DelimMessageStr
-					sendString = message
-					Logger.log.Infof("Send a messageHex raw bytes to %s", peerConn.RemotePeer.PeerID.Pretty())
+					messageBytes = *outMsg.rawBytes
+					if !peerConn.GetBinaryCodec() {
+						var err error
+						messageBytes, err = jsonMessageBytes(messageBytes)
+						if err != nil {
+							Logger.log.Error("Can not re-encode raw bytes in json")
+							Logger.log.Error(err)
+							continue
+						}
+					}
 				} else {
 					// Create and send messageHex
-					messageBytes, err := outMsg.message.JsonSerialize()
+					var err error
+					messageBytes, err = wire.SerializeMessage(outMsg.message, peerConn.GetBinaryCodec())
 					if err != nil {
 						Logger.log.Error("Can not serialize json format for messageHex:" + outMsg.message.MessageType())
 						Logger.log.Error(err)
@@ -390,26 +602,32 @@ func (peerConn *PeerConn) OutMessageHandler(rw *bufio.ReadWriter) {
 					}
 					messageBytes = append(messageBytes, headerBytes...)
 					Logger.log.Infof("OutMessageHandler TYPE %s CONTENT %s", cmdType, string(messageBytes))
+				}
 
+				var sendBytes []byte
+				if peerConn.binaryOut {
+					headerIndex := len(messageBytes) - wire.MessageHeaderSize
+					sendBytes = wire.EncodeFrame(peerConn.Config.NetID, messageBytes[headerIndex:], messageBytes[:headerIndex])
+				} else {
 					// zip data before send
-					messageBytes, err = common.GZipToBytes(messageBytes)
+					zipBytes, err := common.GZipToBytes(messageBytes)
 					if err != nil {
-						Logger.log.Error("Can not gzip for messageHex:" + outMsg.message.MessageType())
+						Logger.log.Error("Can not gzip for messageHex")
 						Logger.log.Error(err)
 						continue
 					}
-					messageHex := hex.EncodeToString(messageBytes)
+					messageHex := hex.EncodeToString(zipBytes)
 					//Logger.log.Infof("Content in hex encode: %s", string(messageHex))
 					// add end character to messageHex (delim '\n')
 					messageHex += DelimMessageStr
-
-					// send on p2p stream
-					Logger.log.Infof("Send a messageHex %s to %s", outMsg.message.MessageType(), peerConn.RemotePeer.PeerID.Pretty())
-					sendString = messageHex
+					sendBytes = []byte(messageHex)
 				}
-				_, err := rw.Writer.WriteString(sendString)
+
+				// send on p2p stream
+				Logger.log.Infof("Send a message to %s", peerConn.RemotePeer.PeerID.Pretty())
+				_, err := rw.Writer.Write(sendBytes)
 				if err != nil {
-					Logger.log.Critical("OutMessageHandler WriteString error", err)
+					Logger.log.Critical("OutMessageHandler Write error", err)
 					continue
 				}
 				err = rw.Writer.Flush()
@@ -417,6 +635,12 @@ func (peerConn *PeerConn) OutMessageHandler(rw *bufio.ReadWriter) {
 					Logger.log.Critical("OutMessageHandler Flush error", err)
 					continue
 				}
+				atomic.AddUint64(&peerConn.bytesSent, uint64(len(sendBytes)))
+				// remote peer reads binary frames after this verack
+				if verAck, ok := outMsg.message.(*wire.MessageVerAck); ok && verAck.BinaryFraming && !peerConn.binaryOut {
+					Logger.log.Infof("PEER %s switches to binary framing", peerConn.RemotePeerID.Pretty())
+					peerConn.binaryOut = true
+				}
 				continue
 			}
 		case <-peerConn.cWrite:
@@ -435,7 +659,16 @@ func (peerConn *PeerConn) OutMessageHandler(rw *bufio.ReadWriter) {
 	}
 }
 
-func (peerConn *PeerConn) checkMessageHashBeforeSend(hash string) bool {
+/*
+checkMessageHashBeforeSend - ask remote peer whether it needs the heavy message
+of hash before sending it. Hashes which remote peer is known to have are not
+asked again
+*/
+func (peerConn *PeerConn) checkMessageHashBeforeSend(cmdType string, hash string) bool {
+	if peerConn.knownMessageHashPool().contains(hash) {
+		Logger.log.Infof("checkMessageHashBeforeSend remote peer has hash %s", hash)
+		return false
+	}
 	numRetries := 0
 BeginCheckHashMessage:
 	numRetries++
@@ -452,6 +685,7 @@ BeginCheckHashMessage:
 			return
 		}
 		msgCheck.(*wire.MessageMsgCheck).HashStr = hash
+		msgCheck.(*wire.MessageMsgCheck).CmdType = cmdType
 		peerConn.QueueMessageWithEncoding(msgCheck, nil, MESSAGE_TO_PEER, nil)
 	}()
 	// set time out for check message
@@ -485,6 +719,10 @@ BeginCheckHashMessage:
 	if bTimeOut && numRetries < MAX_RETRIES_CHECK_HASH_MESSAGE {
 		goto BeginCheckHashMessage
 	}
+	if !bTimeOut {
+		// remote peer has the message or gets it now
+		peerConn.knownMessageHashPool().add(hash)
+	}
 	return bCheck
 }
 
@@ -503,7 +741,7 @@ func (peerConn *PeerConn) QueueMessageWithEncoding(msg wire.Message, doneChan ch
 				hash := msg.Hash()
 				Logger.log.Infof("QueueMessageWithEncoding HEAVY_MESSAGE_SIZE %s %s", hash, msg.MessageType())
 
-				if peerConn.checkMessageHashBeforeSend(hash) {
+				if peerConn.checkMessageHashBeforeSend(msg.MessageType(), hash) {
 					peerConn.sendMessageQueue <- outMsg{
 						message:      msg,
 						doneChan:     doneChan,
@@ -534,7 +772,8 @@ func (peerConn *PeerConn) QueueMessageWithBytes(msgBytes *[]byte, doneChan chan<
 				hash := common.HashH(*msgBytes).String()
 				Logger.log.Infof("QueueMessageWithBytes HEAVY_MESSAGE_SIZE %s", hash)
 
-				if peerConn.checkMessageHashBeforeSend(hash) {
+				cmdType := cmdTypeOfHeader((*msgBytes)[len(*msgBytes)-wire.MessageHeaderSize:])
+				if peerConn.checkMessageHashBeforeSend(cmdType, hash) {
 					peerConn.sendMessageQueue <- outMsg{
 						rawBytes: msgBytes,
 						doneChan: doneChan,
@@ -557,7 +796,7 @@ func (p *PeerConn) handleMsgCheck(msg *wire.MessageMsgCheck) {
 		Logger.log.Error("handleMsgCheck error", err)
 		return
 	}
-	if p.ListenerPeer.CheckHashPool(msg.HashStr) {
+	if p.ListenerPeer.CheckHashPool(msg.CmdType, msg.HashStr) {
 		msgResp.(*wire.MessageMsgCheckResp).HashStr = msg.HashStr
 		msgResp.(*wire.MessageMsgCheckResp).Accept = false
 	} else {
@@ -599,6 +838,12 @@ func (p *PeerConn) Close() {
 }
 
 func (p *PeerConn) ForceClose() {
-	p.SetIsForceClose(true)
+	p.isForceCloseMtx.Lock()
+	defer p.isForceCloseMtx.Unlock()
+	// banning a peer may close it twice
+	if p.isForceClose {
+		return
+	}
+	p.isForceClose = true
 	close(p.cClose)
 }
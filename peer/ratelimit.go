@@ -0,0 +1,162 @@
+package peer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ninjadotorg/constant/wire"
+)
+
+// RateLimitConfig - token bucket refilled with Rate tokens per second up to
+// Burst tokens
+type RateLimitConfig struct {
+	Rate  float64
+	Burst float64
+}
+
+// DefaultPeerRateLimit - messages per second of a peer connection, whatever
+// their type
+var DefaultPeerRateLimit = RateLimitConfig{
+	Rate:  200,
+	Burst: 1000,
+}
+
+/*
+DefaultMessageRateLimits - tokens per second of a peer connection per message
+type. A getblkshard, getblkbeacon or getshdtobcns message costs one token per
+block of its range, up to wire.MaxBlocksPerRange, others cost one token.
+Message types which are not listed are only limited by DefaultPeerRateLimit
+*/
+var DefaultMessageRateLimits = map[string]RateLimitConfig{
+	wire.CmdTx:                 {Rate: 50, Burst: 200},
+	wire.CmdCustomToken:        {Rate: 20, Burst: 100},
+	wire.CmdPrivacyCustomToken: {Rate: 20, Burst: 100},
+	wire.CmdGetBlockShard:      {Rate: 100, Burst: 1000},
+	wire.CmdGetBlockBeacon:     {Rate: 100, Burst: 1000},
+	wire.CmdGetShardToBeacons:  {Rate: 100, Burst: 1000},
+	wire.CmdGetAddr:            {Rate: 0.1, Burst: 5},
+	wire.CmdInv:                {Rate: 100, Burst: 500},
+	wire.CmdGetData:            {Rate: 50, Burst: 200},
+	wire.CmdMsgCheck:           {Rate: 10, Burst: 50},
+}
+
+type tokenBucket struct {
+	config RateLimitConfig
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(config RateLimitConfig) *tokenBucket {
+	return &tokenBucket{
+		config: config,
+		tokens: config.Burst,
+		last:   time.Now(),
+	}
+}
+
+// take refills bucket by time since last take, then takes cost tokens if there
+// are enough. A cost above burst takes a full bucket, so a burst below
+// wire.MaxBlocksPerRange does not refuse every large range
+func (bucket *tokenBucket) take(cost float64, now time.Time) bool {
+	if cost > bucket.config.Burst {
+		cost = bucket.config.Burst
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.config.Rate
+	if bucket.tokens > bucket.config.Burst {
+		bucket.tokens = bucket.config.Burst
+	}
+	bucket.last = now
+	if bucket.tokens < cost {
+		return false
+	}
+	bucket.tokens -= cost
+	return true
+}
+
+// rateLimiter - token buckets of a peer connection
+type rateLimiter struct {
+	mtx      sync.Mutex
+	peer     *tokenBucket
+	messages map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		peer:     newTokenBucket(DefaultPeerRateLimit),
+		messages: make(map[string]*tokenBucket),
+	}
+}
+
+// allowPeer takes a token of peer bucket for a message of any type
+func (limiter *rateLimiter) allowPeer() bool {
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+	return limiter.peer.take(1, time.Now())
+}
+
+// allowMessage takes cost tokens of bucket of message type
+func (limiter *rateLimiter) allowMessage(messageType string, cost float64) bool {
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+	bucket, ok := limiter.messages[messageType]
+	if !ok {
+		config, ok := DefaultMessageRateLimits[messageType]
+		if !ok {
+			return true
+		}
+		bucket = newTokenBucket(config)
+		limiter.messages[messageType] = bucket
+	}
+	return bucket.take(cost, time.Now())
+}
+
+// rangeMessageTypes - message types whose cost depends on the block range in
+// their body, other messages cost one token and are limited before decoding
+var rangeMessageTypes = map[string]bool{
+	wire.CmdGetBlockShard:     true,
+	wire.CmdGetBlockBeacon:    true,
+	wire.CmdGetShardToBeacons: true,
+}
+
+// clampBlockRange limits block range of msg to wire.MaxBlocksPerRange blocks,
+// the requesting peer asks the rest again
+func clampBlockRange(msg wire.Message) {
+	switch msg := msg.(type) {
+	case *wire.MessageGetBlockShard:
+		msg.To = wire.BlockRangeEnd(msg.From, msg.To)
+	case *wire.MessageGetBlockBeacon:
+		msg.To = wire.BlockRangeEnd(msg.From, msg.To)
+	case *wire.MessageGetShardToBeacons:
+		msg.To = wire.BlockRangeEnd(msg.From, msg.To)
+	}
+}
+
+// messageCost returns tokens taken by msg, one per block requested by a range
+func messageCost(msg wire.Message) float64 {
+	var from, to uint64
+	switch msg := msg.(type) {
+	case *wire.MessageGetBlockShard:
+		from, to = msg.From, msg.To
+	case *wire.MessageGetBlockBeacon:
+		from, to = msg.From, msg.To
+	case *wire.MessageGetShardToBeacons:
+		from, to = msg.From, msg.To
+	default:
+		return 1
+	}
+	if to < from {
+		return 1
+	}
+	return float64(wire.BlockRangeEnd(from, to)-from) + 1
+}
+
+// BytesReceived returns number of bytes read from remote peer
+func (peerConn *PeerConn) BytesReceived() uint64 {
+	return atomic.LoadUint64(&peerConn.bytesReceived)
+}
+
+// BytesSent returns number of bytes written to remote peer
+func (peerConn *PeerConn) BytesSent() uint64 {
+	return atomic.LoadUint64(&peerConn.bytesSent)
+}
@@ -0,0 +1,143 @@
+package peer
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ninjadotorg/constant/wire"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(RateLimitConfig{Rate: 10, Burst: 20})
+	now := bucket.last
+	for i := 0; i < 20; i++ {
+		if !bucket.take(1, now) {
+			t.Fatalf("take() %d within burst should pass", i)
+		}
+	}
+	if bucket.take(1, now) {
+		t.Fatalf("take() of an empty bucket should fail")
+	}
+	// refilled by rate
+	now = now.Add(500 * time.Millisecond)
+	if !bucket.take(5, now) || bucket.take(1, now) {
+		t.Fatalf("bucket should be refilled by 5 tokens in half a second")
+	}
+	// never refilled above burst
+	now = now.Add(time.Hour)
+	if !bucket.take(20, now) || bucket.take(1, now) {
+		t.Fatalf("bucket should be refilled up to burst")
+	}
+	// a cost above burst takes a full bucket
+	now = now.Add(time.Hour)
+	if !bucket.take(100, now) || bucket.take(1, now) {
+		t.Fatalf("cost above burst should take a full bucket")
+	}
+}
+
+func TestAllowMessage(t *testing.T) {
+	limiter := newRateLimiter()
+	for i := 0; i < 1000; i++ {
+		if !limiter.allowMessage(wire.CmdVersion, 1) {
+			t.Fatalf("message type without limit should pass")
+		}
+	}
+	burst := int(DefaultMessageRateLimits[wire.CmdGetAddr].Burst)
+	for i := 0; i < burst; i++ {
+		if !limiter.allowMessage(wire.CmdGetAddr, 1) {
+			t.Fatalf("getaddr %d within burst should pass", i)
+		}
+	}
+	if limiter.allowMessage(wire.CmdGetAddr, 1) {
+		t.Fatalf("getaddr above burst should be limited")
+	}
+	if !limiter.allowMessage(wire.CmdInv, 1) {
+		t.Fatalf("buckets of message types should be independent")
+	}
+}
+
+func TestMessageCost(t *testing.T) {
+	tests := []struct {
+		msg  wire.Message
+		cost float64
+	}{
+		{&wire.MessageTx{}, 1},
+		{&wire.MessageGetBlockShard{From: 10, To: 19}, 10},
+		{&wire.MessageGetBlockBeacon{From: 5, To: 5}, 1},
+		{&wire.MessageGetShardToBeacons{From: 9, To: 3}, 1},
+		{&wire.MessageGetBlockBeacon{From: 1, To: 1 << 62}, wire.MaxBlocksPerRange},
+		{&wire.MessageGetBlockShard{From: 0, To: ^uint64(0)}, wire.MaxBlocksPerRange},
+	}
+	for _, test := range tests {
+		if cost := messageCost(test.msg); cost != test.cost {
+			t.Errorf("messageCost(%+v) = %v, want %v", test.msg, cost, test.cost)
+		}
+	}
+}
+
+func TestClampBlockRange(t *testing.T) {
+	msg := &wire.MessageGetBlockShard{From: 100, To: 100000}
+	clampBlockRange(msg)
+	if msg.From != 100 || msg.To != 100+wire.MaxBlocksPerRange-1 {
+		t.Fatalf("clamped range %d..%d, want %d blocks", msg.From, msg.To, wire.MaxBlocksPerRange)
+	}
+	beaconMsg := &wire.MessageGetBlockBeacon{From: 1, To: 10}
+	clampBlockRange(beaconMsg)
+	if beaconMsg.To != 10 {
+		t.Fatalf("range within limit should not change, got To %d", beaconMsg.To)
+	}
+	shardToBeaconMsg := &wire.MessageGetShardToBeacons{From: 0, To: ^uint64(0)}
+	clampBlockRange(shardToBeaconMsg)
+	if shardToBeaconMsg.To != wire.MaxBlocksPerRange-1 {
+		t.Fatalf("clamped range ends at %d, want %d", shardToBeaconMsg.To, wire.MaxBlocksPerRange-1)
+	}
+}
+
+func TestHandlerPool(t *testing.T) {
+	pool := newHandlerPool(3)
+	release := make(chan struct{})
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	handler := func() {
+		defer wg.Done()
+		current := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&maxRunning)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxRunning, seen, current) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt32(&running, -1)
+	}
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		pool.run(handler)
+	}
+
+	// every handler is busy, the reader waits
+	started := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		pool.run(handler)
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatalf("run() should wait for a free handler")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatalf("run() should start once a handler is free")
+	}
+	wg.Wait()
+	if maxRunning > 3 {
+		t.Fatalf("%d handlers ran at the same time, want at most 3", maxRunning)
+	}
+}
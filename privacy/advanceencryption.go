@@ -41,6 +41,24 @@ func (ciphertext *Ciphertext) SetBytes(bytes []byte) error {
 	return nil
 }
 
+// MarshalBinary returns the same bytes as Bytes
+func (ciphertext *Ciphertext) MarshalBinary() ([]byte, error) {
+	return ciphertext.Bytes(), nil
+}
+
+// UnmarshalBinary sets the ciphertext from an encoding returned by MarshalBinary
+func (ciphertext *Ciphertext) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		ciphertext.symKeyEncrypted = nil
+		ciphertext.msgEncrypted = nil
+		return nil
+	}
+	if len(data) <= 66 {
+		return errors.New("UnmarshalBinary ciphertext encryption: invalid input")
+	}
+	return ciphertext.SetBytes(append([]byte{}, data...))
+}
+
 // AdvanceEncrypt encrypts message with any size, using Publickey to encrypt
 func AdvanceEncrypt(msg []byte, publicKey *EllipticPoint) (ciphertext *Ciphertext, err error) {
 	ciphertext = new(Ciphertext)
@@ -47,6 +47,30 @@ func (point EllipticPoint) MarshalJSON() ([]byte, error) {
 	return json.Marshal(temp)
 }
 
+// MarshalBinary returns the compressed point, or nothing for the point (0, 0)
+func (point *EllipticPoint) MarshalBinary() ([]byte, error) {
+	if point.X == nil || point.Y == nil || (point.X.Sign() == 0 && point.Y.Sign() == 0) {
+		return []byte{}, nil
+	}
+	data := point.Compress()
+	if data == nil {
+		return nil, errors.New("point is not on the elliptic curve")
+	}
+	return data, nil
+}
+
+// UnmarshalBinary sets the point from an encoding returned by MarshalBinary
+func (point *EllipticPoint) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		point.Zero()
+		return nil
+	}
+	if len(data) != CompressedPointSize {
+		return errors.New("invalid length of compressed point")
+	}
+	return point.Decompress(data)
+}
+
 // ComputeYCoord returns Y-coordinate from X-coordinate
 func (point *EllipticPoint) ComputeYCoord() error {
 	// Y = +-sqrt(x^3 - 3*x + B)
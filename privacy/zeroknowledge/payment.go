@@ -101,6 +101,20 @@ func (proof *PaymentProof) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (proof *PaymentProof) MarshalBinary() ([]byte, error) {
+	return proof.Bytes(), nil
+}
+
+func (proof *PaymentProof) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("empty payment proof")
+	}
+	if err := proof.SetBytes(data); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (proof *PaymentProof) Bytes() []byte {
 	var proofbytes []byte
 	hasPrivacy := len(proof.OneOfManyProof) > 0
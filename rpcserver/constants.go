@@ -5,6 +5,9 @@ const (
 	GetNetworkInfo     = "getnetworkinfo"
 	GetConnectionCount = "getconnectioncount"
 	GetAllPeers        = "getallpeers"
+	ListBanned         = "listbanned"
+	SetBan             = "setban"
+	ClearBanned        = "clearbanned"
 	GetRawMempool      = "getrawmempool"
 	GetMempoolEntry    = "getmempoolentry"
 	EstimateFee        = "estimatefee"
@@ -34,6 +37,7 @@ const (
 	GetBlockProducerList                       = "getblockproducer"
 	ListUnspentCustomToken                     = "listunspentcustomtoken"
 	GetTransactionByHash                       = "gettransactionbyhash"
+	GetTxMerkleProof                           = "gettxmerkleproof"
 	ListCustomToken                            = "listcustomtoken"
 	ListPrivacyCustomToken                     = "listprivacycustomtoken"
 	CustomToken                                = "customtoken"
@@ -25,6 +25,7 @@ const (
 	ErrCreateTxData
 	ErrSendTxData
 	ErrTxTypeInvalid
+	ErrDataPruned
 )
 
 // Standard JSON-RPC 2.0 errors.
@@ -56,6 +57,7 @@ var ErrCodeMessage = map[int]struct {
 	// processing -2xxx
 	ErrCreateTxData: {-2001, "Can not create tx"},
 	ErrSendTxData:   {-2002, "Can not send tx"},
+	ErrDataPruned:   {-2003, "Data is pruned on this node"},
 }
 
 // RPCError represents an error that is used as a part of a JSON-RPC Response
@@ -2,4 +2,15 @@ package jsonresult
 
 type GetAllPeersResult struct {
 	Peers []string `json:"Peers"`
+	// traffic of each connected peer
+	PeerStats []PeerStatResult `json:"PeerStats"`
+}
+
+type PeerStatResult struct {
+	PeerID        string `json:"PeerID"`
+	Address       string `json:"Address"`
+	Outbound      bool   `json:"Outbound"`
+	BytesSent     uint64 `json:"BytesSent"`
+	BytesReceived uint64 `json:"BytesReceived"`
+	BanScore      int32  `json:"BanScore"`
 }
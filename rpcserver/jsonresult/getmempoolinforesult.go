@@ -8,4 +8,12 @@ type GetMempoolInfo struct {
 	MempoolMinFee uint64   `json:"MempoolMinFee"`
 	MempoolMaxFee uint64   `json:"MempoolMaxFee"`
 	ListTxs       []string `json:"ListTxs"`
+
+	Shards map[byte]GetMempoolShardInfo `json:"Shards"`
+}
+
+type GetMempoolShardInfo struct {
+	Size          int    `json:"Size"`
+	Bytes         uint64 `json:"Bytes"`
+	MempoolMaxFee uint64 `json:"MempoolMaxFee"`
 }
@@ -10,4 +10,17 @@ type GetNetworkInfoResult struct {
 	LocalAddresses  []string                 `json:"LocalAddresses"`
 	IncrementalFee  uint64                   `json:"IncrementalFee"`
 	Warnings        string                   `json:"Warnings"`
+	// dedup of relayed messages per message type
+	MessageHashPools []MessageHashPoolResult `json:"MessageHashPools"`
+}
+
+type MessageHashPoolResult struct {
+	MessageType string  `json:"MessageType"`
+	Size        int     `json:"Size"`
+	Capacity    int     `json:"Capacity"`
+	Hits        uint64  `json:"Hits"`
+	Misses      uint64  `json:"Misses"`
+	Evicted     uint64  `json:"Evicted"`
+	Expired     uint64  `json:"Expired"`
+	HitRate     float64 `json:"HitRate"`
 }
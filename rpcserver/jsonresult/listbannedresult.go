@@ -0,0 +1,12 @@
+package jsonresult
+
+type ListBannedResult struct {
+	Banned []BannedPeerResult `json:"Banned"`
+}
+
+type BannedPeerResult struct {
+	PeerID      string `json:"PeerID"`
+	Address     string `json:"Address"`
+	BannedUntil int64  `json:"BannedUntil"`
+	Reason      string `json:"Reason"`
+}
@@ -0,0 +1,10 @@
+package jsonresult
+
+type MempoolEventResult struct {
+	Type       string `json:"Type"`
+	TxID       string `json:"TxID"`
+	Fee        uint64 `json:"Fee"`
+	Time       int64  `json:"Time"`
+	RejectCode int    `json:"RejectCode,omitempty"`
+	Reason     string `json:"Reason,omitempty"`
+}
@@ -0,0 +1,77 @@
+package rpcserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/ninjadotorg/constant/mempool"
+	"github.com/ninjadotorg/constant/rpcserver/jsonresult"
+)
+
+/*
+MempoolEventsHandleRequest - stream events of mempool to the client, one json
+object per line, until it disconnects. Optional query "types" filters events
+by a comma separated list of added, mined, evicted and rejected
+*/
+func (rpcServer *RpcServer) MempoolEventsHandleRequest(w http.ResponseWriter, r *http.Request) {
+	// Limit the number of connections to max allowed.
+	if rpcServer.limitConnections(w, r.RemoteAddr) {
+		return
+	}
+	rpcServer.IncrementClients()
+	defer rpcServer.DecrementClients()
+	ok, _, err := rpcServer.checkAuth(r, true)
+	if err != nil || !ok {
+		Logger.log.Error(err)
+		rpcServer.AuthFail(w)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "500 Streaming is not supported.", http.StatusInternalServerError)
+		return
+	}
+	types := make(map[string]bool)
+	if s := r.URL.Query().Get("types"); s != "" {
+		for _, t := range strings.Split(s, ",") {
+			types[strings.TrimSpace(t)] = true
+		}
+	}
+
+	sub := rpcServer.config.TxMemPool.Subscribe(mempool.DefaultEventBufferSize)
+	defer sub.Unsubscribe()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if len(types) > 0 && !types[event.Type.String()] {
+				continue
+			}
+			result := jsonresult.MempoolEventResult{
+				Type:       event.Type.String(),
+				TxID:       event.TxHash.String(),
+				Fee:        event.Fee,
+				Time:       event.Time.Unix(),
+				RejectCode: event.RejectCode,
+			}
+			if event.Err != nil {
+				result.Reason = event.Err.Error()
+			}
+			if err := encoder.Encode(result); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/common/base58"
+	"github.com/ninjadotorg/constant/mempool"
 	"github.com/ninjadotorg/constant/privacy"
 	"github.com/ninjadotorg/constant/rpcserver/jsonresult"
 	"github.com/ninjadotorg/constant/wallet"
@@ -20,6 +21,7 @@ var RpcHandler = map[string]commandHandler{
 	GetNetworkInfo:     RpcServer.handleGetNetWorkInfo,
 	GetConnectionCount: RpcServer.handleGetConnectionCount,
 	GetAllPeers:        RpcServer.handleGetAllPeers,
+	ListBanned:         RpcServer.handleListBanned,
 	GetRawMempool:      RpcServer.handleGetRawMempool,
 	GetMempoolEntry:    RpcServer.handleMempoolEntry,
 	EstimateFee:        RpcServer.handleEstimateFee,
@@ -44,6 +46,7 @@ var RpcHandler = map[string]commandHandler{
 	CreateAndSendTransaction: RpcServer.handleCreateAndSendTx,
 	GetMempoolInfo:           RpcServer.handleGetMempoolInfo,
 	GetTransactionByHash:     RpcServer.handleGetTransactionByHash,
+	GetTxMerkleProof:         RpcServer.handleGetTxMerkleProof,
 
 	GetCommitteeCandidateList: RpcServer.handleGetCommitteeCandidateList,
 	GetBlockProducerList:      RpcServer.handleGetBlockProducerList,
@@ -162,6 +165,10 @@ var RpcHandler = map[string]commandHandler{
 
 // Commands that are available to a limited user
 var RpcLimited = map[string]commandHandler{
+	// node
+	SetBan:      RpcServer.handleSetBan,
+	ClearBanned: RpcServer.handleClearBanned,
+
 	// local WALLET
 	ListAccounts:               RpcServer.handleListAccounts,
 	GetAccount:                 RpcServer.handleGetAccount,
@@ -228,6 +235,19 @@ func (rpcServer RpcServer) handleGetNetWorkInfo(params interface{}, closeChan <-
 		result.IncrementalFee = rpcServer.config.Wallet.Config.IncrementalFee
 	}
 	result.Warnings = ""
+	result.MessageHashPools = []jsonresult.MessageHashPoolResult{}
+	for _, stats := range listener.MessageHashPoolStats() {
+		result.MessageHashPools = append(result.MessageHashPools, jsonresult.MessageHashPoolResult{
+			MessageType: stats.MessageType,
+			Size:        stats.Size,
+			Capacity:    stats.Capacity,
+			Hits:        stats.Hits,
+			Misses:      stats.Misses,
+			Evicted:     stats.Evicted,
+			Expired:     stats.Expired,
+			HitRate:     stats.HitRate(),
+		})
+	}
 
 	return result, nil
 }
@@ -453,6 +473,19 @@ func (rpcServer RpcServer) handleEstimateFee(params interface{}, closeChan <-cha
 	if err != nil {
 		return nil, NewRPCError(ErrGetOutputCoin, err)
 	}
+	// param #3: optional class of tx to estimate fee for, normal by default
+	txClass := mempool.TxClassNormal
+	if len(arrayParams) > 2 && arrayParams[2] != nil {
+		txClassParam, ok := arrayParams[2].(string)
+		if !ok {
+			return nil, NewRPCError(ErrRPCInvalidParams, errors.New("tx class is invalid"))
+		}
+		txClass, err = mempool.ParseTxClass(txClassParam)
+		if err != nil {
+			return nil, NewRPCError(ErrRPCInvalidParams, err)
+		}
+	}
+
 	govFeePerKbTx := rpcServer.config.BlockChain.BestState.Beacon.StabilityInfo.GOVConstitution.GOVParams.FeePerKbTx
 	estimateFeeCoinPerKb := uint64(0)
 	estimateTxSizeInKb := uint64(0)
@@ -475,7 +508,7 @@ func (rpcServer RpcServer) handleEstimateFee(params interface{}, closeChan <-cha
 			paymentInfos = append(paymentInfos, paymentInfo)
 		}
 		// check real fee(nano constant) per tx
-		_, estimateFeeCoinPerKb, estimateTxSizeInKb = rpcServer.estimateFee(-1, outCoins, paymentInfos, shardIDSender, 8)
+		_, estimateFeeCoinPerKb, estimateTxSizeInKb = rpcServer.estimateFee(-1, outCoins, paymentInfos, shardIDSender, 8, txClass)
 	}
 	result := jsonresult.EstimateFeeResult{
 		EstimateFeeCoinPerKb: estimateFeeCoinPerKb,
@@ -0,0 +1,104 @@
+package rpcserver
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/rpcserver/jsonresult"
+)
+
+/*
+handleListBanned - RPC returns peers which are banned for misbehaving or by
+setban
+*/
+func (rpcServer RpcServer) handleListBanned(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
+	if rpcServer.config.ConnMgr == nil {
+		return nil, NewRPCError(ErrUnexpected, errors.New("Connection manager not init"))
+	}
+	result := jsonresult.ListBannedResult{
+		Banned: []jsonresult.BannedPeerResult{},
+	}
+	for _, bannedPeer := range rpcServer.config.ConnMgr.ListBanned() {
+		result.Banned = append(result.Banned, jsonresult.BannedPeerResult{
+			PeerID:      bannedPeer.PeerID,
+			Address:     bannedPeer.RawAddress,
+			BannedUntil: bannedPeer.Until.Unix(),
+			Reason:      bannedPeer.Reason,
+		})
+	}
+	return result, nil
+}
+
+/*
+handleSetBan - RPC bans or unbans a peer
+Parameter #1—peer id or full address of peer (/ip4/<ip>/tcp/<port>/ipfs/<peer id>)
+Parameter #2—"add" to ban, "remove" to unban
+Parameter #3—ban time in seconds, default is ban duration of node config
+Parameter #4—reason of ban
+*/
+func (rpcServer RpcServer) handleSetBan(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
+	if rpcServer.config.ConnMgr == nil {
+		return nil, NewRPCError(ErrUnexpected, errors.New("Connection manager not init"))
+	}
+	arrayParams := common.InterfaceSlice(params)
+	if len(arrayParams) < 2 {
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Expected peer and command params"))
+	}
+	target, ok := arrayParams[0].(string)
+	if !ok || target == common.EmptyString {
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Peer param invalid"))
+	}
+	command, ok := arrayParams[1].(string)
+	if !ok {
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Command param invalid"))
+	}
+	switch command {
+	case "add":
+		peerID := target
+		rawAddress := common.EmptyString
+		if strings.HasPrefix(target, "/") {
+			peerID = rpcServer.config.ConnMgr.GetPeerId(target)
+			if peerID == common.EmptyString {
+				return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Peer address invalid"))
+			}
+			rawAddress = target
+		}
+		duration := rpcServer.config.ConnMgr.Config.BanDuration
+		if len(arrayParams) > 2 {
+			banTime, ok := arrayParams[2].(float64)
+			if !ok || banTime <= 0 {
+				return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Ban time param invalid"))
+			}
+			duration = time.Duration(banTime) * time.Second
+		}
+		reason := "manually banned"
+		if len(arrayParams) > 3 {
+			reason, ok = arrayParams[3].(string)
+			if !ok {
+				return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Reason param invalid"))
+			}
+		}
+		rpcServer.config.ConnMgr.BanPeer(peerID, rawAddress, duration, reason)
+		return true, nil
+	case "remove":
+		if !rpcServer.config.ConnMgr.UnbanPeer(target) {
+			return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Peer is not banned"))
+		}
+		return true, nil
+	default:
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Command param must be add or remove"))
+	}
+}
+
+/*
+handleClearBanned - RPC lifts all bans
+*/
+func (rpcServer RpcServer) handleClearBanned(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
+	if rpcServer.config.ConnMgr == nil {
+		return nil, NewRPCError(ErrUnexpected, errors.New("Connection manager not init"))
+	}
+	rpcServer.config.ConnMgr.ClearBanned()
+	return true, nil
+}
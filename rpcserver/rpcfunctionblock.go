@@ -71,6 +71,9 @@ func (rpcServer RpcServer) handleRetrieveBlock(params interface{}, closeChan <-c
 		// block, errD := rpcServer.config.BlockChain.GetBlockByHash(hash)
 		block, errD := rpcServer.config.BlockChain.GetShardBlockByHash(hash)
 		if errD != nil {
+			if blockchain.IsBlockPrunedError(errD) {
+				return nil, NewRPCError(ErrDataPruned, errD)
+			}
 			return nil, NewRPCError(ErrUnexpected, errD)
 		}
 		result := jsonresult.GetBlockResult{}
@@ -2,8 +2,6 @@ package rpcserver
 
 import (
 	"errors"
-
-	"github.com/ninjadotorg/constant/mempool"
 )
 
 /*
@@ -13,7 +11,7 @@ func (rpcServer RpcServer) handleGetShardToBeaconPoolState(params interface{}, c
 	// if rpcServer.config.BlockChain.BestState.Beacon == nil {
 	// 	return nil, NewRPCError(ErrUnexpected, errors.New("Best State beacon not existed"))
 	// }
-	shardToBeaconPool := mempool.GetShardToBeaconPoolInstance()
+	shardToBeaconPool := rpcServer.config.ShardToBeaconPool
 	if shardToBeaconPool == nil {
 		return nil, NewRPCError(ErrUnexpected, errors.New("Shard to Beacon Pool not init"))
 	}
@@ -26,8 +24,11 @@ func (rpcServer RpcServer) handleGetShardToBeaconPoolState(params interface{}, c
 handleGetCrossShardPoolState - RPC get cross shard pool state
 */
 func (rpcServer RpcServer) handleGetCrossShardPoolState(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
-
-	result := mempool.GetCrossShardPoolState()
+	crossShardPool := rpcServer.config.CrossShardPool
+	if crossShardPool == nil {
+		return nil, NewRPCError(ErrUnexpected, errors.New("Cross Shard Pool not init"))
+	}
+	result := crossShardPool.GetCrossShardPoolState()
 	// if !ok || result == nil {
 	// 	return nil, NewRPCError(ErrUnexpected, errors.New("Best State shard given by ID not existed"))
 	// }
@@ -9,6 +9,7 @@ import (
 	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/privacy"
 
+	"github.com/ninjadotorg/constant/blockchain"
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/common/base58"
@@ -199,6 +200,14 @@ func (rpcServer RpcServer) handleGetMempoolInfo(params interface{}, closeChan <-
 	result.Bytes = rpcServer.config.TxMemPool.Size()
 	result.MempoolMaxFee = rpcServer.config.TxMemPool.MaxFee()
 	result.ListTxs = rpcServer.config.TxMemPool.ListTxs()
+	result.Shards = make(map[byte]jsonresult.GetMempoolShardInfo)
+	for shardID, stats := range rpcServer.config.TxMemPool.ShardStats() {
+		result.Shards[shardID] = jsonresult.GetMempoolShardInfo{
+			Size:          stats.Count,
+			Bytes:         stats.Size,
+			MempoolMaxFee: stats.MaxFee,
+		}
+	}
 	return result, nil
 }
 
@@ -214,6 +223,9 @@ func (rpcServer RpcServer) handleGetTransactionByHash(params interface{}, closeC
 	Logger.log.Infof("Get Transaction By Hash %+v", txHash)
 	shardID, blockHash, index, tx, err := rpcServer.config.BlockChain.GetTransactionByHash(txHash)
 	if err != nil {
+		if blockchain.IsBlockPrunedError(err) {
+			return nil, NewRPCError(ErrDataPruned, err)
+		}
 		return nil, NewRPCError(ErrUnexpected, err)
 	}
 	result := jsonresult.TransactionDetail{}
@@ -291,6 +303,35 @@ func (rpcServer RpcServer) handleGetTransactionByHash(params interface{}, closeC
 	return result, nil
 }
 
+/*
+handleGetTxMerkleProof - return block hash, header and merkle path proving a
+transaction is included under TxRoot of its shard block, the proof can be
+checked offline with blockchain.VerifyTxMerkleProof
+*/
+func (rpcServer RpcServer) handleGetTxMerkleProof(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
+	arrayParams := common.InterfaceSlice(params)
+	// param #1: transaction Hash
+	if len(arrayParams) < 1 {
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Tx hash is empty"))
+	}
+	txHashStr, ok := arrayParams[0].(string)
+	if !ok {
+		return nil, NewRPCError(ErrRPCInvalidParams, errors.New("Tx hash is invalid"))
+	}
+	txHash, err := common.Hash{}.NewHashFromStr(txHashStr)
+	if err != nil {
+		return nil, NewRPCError(ErrRPCInvalidParams, err)
+	}
+	proof, err := rpcServer.config.BlockChain.GetTxMerkleProof(txHash)
+	if err != nil {
+		if blockchain.IsBlockPrunedError(err) {
+			return nil, NewRPCError(ErrDataPruned, err)
+		}
+		return nil, NewRPCError(ErrUnexpected, err)
+	}
+	return proof, nil
+}
+
 func (rpcServer RpcServer) handleGetCommitteeCandidateList(params interface{}, closeChan <-chan struct{}) (interface{}, *RPCError) {
 	// param #1: private key of sender
 	// cndList := self.config.BlockChain.GetCommitteeCandidateList()
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ninjadotorg/constant/mempool"
 	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/privacy"
 	"github.com/ninjadotorg/constant/transaction"
@@ -145,6 +146,19 @@ func (rpcServer RpcServer) handleGetAllPeers(params interface{}, closeChan <-cha
 		}
 	}
 	result.Peers = peersMap
+	result.PeerStats = []jsonresult.PeerStatResult{}
+	if listener := rpcServer.config.ConnMgr.ListeningPeer; listener != nil {
+		for _, peerConn := range listener.GetPeerConnOfAll() {
+			result.PeerStats = append(result.PeerStats, jsonresult.PeerStatResult{
+				PeerID:        peerConn.RemotePeerID.Pretty(),
+				Address:       peerConn.RemoteRawAddress,
+				Outbound:      peerConn.GetIsOutbound(),
+				BytesSent:     peerConn.BytesSent(),
+				BytesReceived: peerConn.BytesReceived(),
+				BanScore:      peerConn.BanScore(),
+			})
+		}
+	}
 	return result, nil
 }
 
@@ -518,7 +532,7 @@ func (rpcServer RpcServer) handleBuildRawDefragmentAccountTransaction(params int
 	}
 	paymentInfos := []*privacy.PaymentInfo{paymentInfo}
 	// check real fee(nano constant) per tx
-	realFee, _, _ := rpcServer.estimateFee(estimateFeeCoinPerKb, outCoins, paymentInfos, shardIDSender, 8)
+	realFee, _, _ := rpcServer.estimateFee(estimateFeeCoinPerKb, outCoins, paymentInfos, shardIDSender, 8, mempool.TxClassNormal)
 	if len(outCoins) == 0 {
 		realFee = 0
 	}
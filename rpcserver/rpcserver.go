@@ -75,9 +75,11 @@ type RpcServerConfig struct {
 		PushMessageToPeer(message wire.Message, id peer2.ID) error
 	}
 
-	TxMemPool     *mempool.TxPool
-	RPCMaxClients int
-	RPCQuirks     bool
+	TxMemPool         *mempool.TxPool
+	ShardToBeaconPool *mempool.ShardToBeaconPool
+	CrossShardPool    *mempool.CrossShardPool
+	RPCMaxClients     int
+	RPCQuirks         bool
 
 	// Authentication
 	RPCUser      string
@@ -146,6 +148,9 @@ func (rpcServer *RpcServer) Start() error {
 	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		rpcServer.RpcHandleRequest(w, r)
 	})
+	rpcServeMux.HandleFunc("/mempoolevents", func(w http.ResponseWriter, r *http.Request) {
+		rpcServer.MempoolEventsHandleRequest(w, r)
+	})
 	for _, listen := range rpcServer.config.Listenters {
 		go func(listen net.Listener) {
 			Logger.log.Infof("RPC server listening on %s", listen.Addr())
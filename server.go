@@ -22,6 +22,7 @@ import (
 	"github.com/ninjadotorg/constant/consensus/constantpos"
 	"github.com/ninjadotorg/constant/database"
 	"github.com/ninjadotorg/constant/mempool"
+	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/netsync"
 	"github.com/ninjadotorg/constant/peer"
 	"github.com/ninjadotorg/constant/rewardagent"
@@ -46,6 +47,7 @@ type Server struct {
 	shardPool         *mempool.NodeShardPool
 	shardToBeaconPool *mempool.ShardToBeaconPool
 	crossShardPool    *mempool.CrossShardPool
+	orphanBlockPool   *mempool.OrphanBlockPool
 
 	waitGroup       sync.WaitGroup
 	netSync         *netsync.NetSync
@@ -58,6 +60,9 @@ type Server struct {
 	// the mempool before they are mined into blocks.
 	feeEstimator map[byte]*mempool.FeeEstimator
 
+	// txs and blocks requested by getdata, see inventory.go
+	requestedInventory inventoryRequests
+
 	cQuit     chan struct{}
 	cNewPeers chan *peer.Peer
 }
@@ -145,10 +150,11 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 			Logger.log.Error(err)
 		}
 	}
-	serverObj.beaconPool = &mempool.NodeBeaconPool{}
-	serverObj.shardPool = &mempool.NodeShardPool{}
-	serverObj.shardToBeaconPool = mempool.InitShardToBeaconPool(mempool.DefaultShardToBeaconPoolConfig)
-	serverObj.crossShardPool = &mempool.CrossShardPool{}
+	serverObj.beaconPool = mempool.NewNodeBeaconPool(mempool.DefaultNodeBlockPoolConfig)
+	serverObj.shardPool = mempool.NewNodeShardPool(mempool.DefaultNodeBlockPoolConfig)
+	serverObj.shardToBeaconPool = mempool.NewShardToBeaconPool(mempool.DefaultShardToBeaconPoolConfig)
+	serverObj.crossShardPool = mempool.NewCrossShardPool(mempool.DefaultCrossShardPoolConfig)
+	serverObj.orphanBlockPool = mempool.NewOrphanBlockPool(mempool.DefaultOrphanBlockPoolConfig)
 
 	serverObj.blockChain = &blockchain.BlockChain{}
 	relayShards := []byte{}
@@ -156,6 +162,10 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 		s, _ := strconv.Atoi(fmt.Sprintf("%c", byte(cfg.RelayShards[index])))
 		relayShards = append(relayShards, byte(s))
 	}
+	pruneDepth := uint64(0)
+	if cfg.Prune {
+		pruneDepth = cfg.PruneDepth
+	}
 	err = serverObj.blockChain.Init(&blockchain.Config{
 		ChainParams:       serverObj.chainParams,
 		DataBase:          serverObj.dataBase,
@@ -166,9 +176,12 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 		NodeShardPool:     serverObj.shardPool,
 		ShardToBeaconPool: serverObj.shardToBeaconPool,
 		CrossShardPool:    serverObj.crossShardPool,
+		OrphanBlockPool:   serverObj.orphanBlockPool,
 		Server:            serverObj,
 		UserKeySet:        userKeySet,
 		NodeMode:          cfg.NodeMode,
+		PruneDepth:        pruneDepth,
+		UndoDepth:         cfg.UndoDepth,
 		// Light:       cfg.Light,
 	})
 	serverObj.blockChain.SetShardToBeaconPool(db)
@@ -216,13 +229,40 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 		serverObj.feeEstimator = make(map[byte]*mempool.FeeEstimator)
 	}
 	// create mempool tx
+	metadataPriority := mempool.DefaultMetadataPriority()
+	if len(cfg.TxPriority) > 0 {
+		metadataPriority, err = mempool.ParseMetadataPriority(cfg.TxPriority)
+		if err != nil {
+			Logger.log.Error(err)
+			return err
+		}
+	}
+	mempoolFile := filepath.Join(cfg.DataDir, defaultMempoolFilename)
+	if cfg.NoMempoolPersist {
+		mempoolFile = common.EmptyString
+	}
 	serverObj.memPool = &mempool.TxPool{}
 	serverObj.memPool.Init(&mempool.Config{
 		BlockChain:   serverObj.blockChain,
 		DataBase:     serverObj.dataBase,
 		ChainParams:  chainParams,
 		FeeEstimator: serverObj.feeEstimator,
+		RelayShards:  relayShards,
+		NodeMode:     cfg.NodeMode,
+		UserKeySet:   userKeySet,
+		MaxTxs:       cfg.MempoolMaxTxs,
+		MaxSize:      cfg.MempoolMaxSize,
+		MaxAge:       cfg.MempoolExpiry,
+		MaxAgeBlocks: cfg.MempoolExpiryBlocks,
+		MaxOrphanTxs: cfg.MaxOrphanTxs,
+		OrphanTxTTL:  cfg.OrphanTxTTL,
+
+		MinReplaceFeeBump: cfg.ReplaceFeeBump,
+
+		MetadataPriority: metadataPriority,
+		PersistFile:      mempoolFile,
 	})
+	serverObj.blockChain.SetTxPool(serverObj.memPool)
 
 	serverObj.addrManager = addrmanager.New(cfg.DataDir)
 
@@ -281,6 +321,9 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 		MaxPeersOther:      cfg.MaxPeersOther,
 		MaxPeersNoShard:    cfg.MaxPeersNoShard,
 		MaxPeersBeacon:     cfg.MaxPeersBeacon,
+		// ban misbehaving peers
+		AddrManager: serverObj.addrManager,
+		BanDuration: cfg.BanDuration,
 	})
 	serverObj.connManager = connManager
 
@@ -308,21 +351,23 @@ func (serverObj *Server) NewServer(listenAddrs string, db database.DatabaseInter
 		}
 
 		rpcConfig := rpcserver.RpcServerConfig{
-			Listenters:    rpcListeners,
-			RPCQuirks:     cfg.RPCQuirks,
-			RPCMaxClients: cfg.RPCMaxClients,
-			ChainParams:   chainParams,
-			BlockChain:    serverObj.blockChain,
-			TxMemPool:     serverObj.memPool,
-			Server:        serverObj,
-			Wallet:        serverObj.wallet,
-			ConnMgr:       serverObj.connManager,
-			AddrMgr:       serverObj.addrManager,
-			RPCUser:       cfg.RPCUser,
-			RPCPass:       cfg.RPCPass,
-			RPCLimitUser:  cfg.RPCLimitUser,
-			RPCLimitPass:  cfg.RPCLimitPass,
-			DisableAuth:   cfg.RPCDisableAuth,
+			Listenters:        rpcListeners,
+			RPCQuirks:         cfg.RPCQuirks,
+			RPCMaxClients:     cfg.RPCMaxClients,
+			ChainParams:       chainParams,
+			BlockChain:        serverObj.blockChain,
+			TxMemPool:         serverObj.memPool,
+			Server:            serverObj,
+			ShardToBeaconPool: serverObj.shardToBeaconPool,
+			CrossShardPool:    serverObj.crossShardPool,
+			Wallet:            serverObj.wallet,
+			ConnMgr:           serverObj.connManager,
+			AddrMgr:           serverObj.addrManager,
+			RPCUser:           cfg.RPCUser,
+			RPCPass:           cfg.RPCPass,
+			RPCLimitUser:      cfg.RPCLimitUser,
+			RPCLimitPass:      cfg.RPCLimitPass,
+			DisableAuth:       cfg.RPCDisableAuth,
 			// IsGenerateNode:  cfg.Generate,
 			NodeMode:        cfg.NodeMode,
 			FeeEstimator:    serverObj.feeEstimator,
@@ -384,6 +429,9 @@ func (serverObj *Server) Stop() error {
 		serverObj.rpcServer.Stop()
 	}
 
+	// Save txs of mempool to file
+	serverObj.memPool.Stop()
+
 	// Save fee estimator in the db
 	for shardID, feeEstimator := range serverObj.feeEstimator {
 		feeEstimatorData := feeEstimator.Save()
@@ -466,6 +514,9 @@ func (serverObj Server) Start() {
 	// managers.
 	serverObj.waitGroup.Add(1)
 
+	// Restore txs saved by the previous run before accepting new ones
+	serverObj.memPool.Start()
+
 	go serverObj.peerHandler()
 	if !cfg.DisableRPC && serverObj.rpcServer != nil {
 		serverObj.waitGroup.Add(1)
@@ -528,6 +579,9 @@ func (serverObj *Server) InitListenerPeer(amgr *addrmanager.AddrManager, listenA
 	peer.Config.MaxInPeers = maxInPeers
 	peer.Config.MaxOutPeers = maxOutPeers
 	peer.Config.MaxPeers = maxPeers
+	peer.Config.BanThreshold = cfg.BanThreshold
+	peer.Config.DisableRateLimit = cfg.DisableRateLimit
+	peer.Config.NetID = serverObj.chainParams.Net
 	if err != nil {
 		return nil, err
 	}
@@ -560,6 +614,8 @@ func (serverObj *Server) NewPeerConfig() *peer.Config {
 			OnVerAck:            serverObj.OnVerAck,
 			OnGetAddr:           serverObj.OnGetAddr,
 			OnAddr:              serverObj.OnAddr,
+			OnInv:               serverObj.OnInv,
+			OnGetData:           serverObj.OnGetData,
 
 			//constantpos
 			OnBFTMsg: serverObj.OnBFTMsg,
@@ -589,9 +645,10 @@ func (serverObj *Server) NewPeerConfig() *peer.Config {
 func (serverObj *Server) OnBlockShard(p *peer.PeerConn,
 	msg *wire.MessageBlockShard) {
 	Logger.log.Info("Receive a new blockshard START")
+	serverObj.receivedInventory(p, *msg.Block.Hash())
 
 	var txProcessed chan struct{}
-	serverObj.netSync.QueueBlock(nil, msg, txProcessed)
+	serverObj.netSync.QueueBlock(p, msg, txProcessed)
 	//<-txProcessed
 
 	Logger.log.Info("Receive a new blockshard END")
@@ -600,9 +657,10 @@ func (serverObj *Server) OnBlockShard(p *peer.PeerConn,
 func (serverObj *Server) OnBlockBeacon(p *peer.PeerConn,
 	msg *wire.MessageBlockBeacon) {
 	Logger.log.Info("Receive a new blockbeacon START")
+	serverObj.receivedInventory(p, *msg.Block.Hash())
 
 	var txProcessed chan struct{}
-	serverObj.netSync.QueueBlock(nil, msg, txProcessed)
+	serverObj.netSync.QueueBlock(p, msg, txProcessed)
 	//<-txProcessed
 
 	Logger.log.Info("Receive a new blockbeacon END")
@@ -613,7 +671,7 @@ func (serverObj *Server) OnCrossShard(p *peer.PeerConn,
 	Logger.log.Info("Receive a new crossshard START")
 
 	var txProcessed chan struct{}
-	serverObj.netSync.QueueBlock(nil, msg, txProcessed)
+	serverObj.netSync.QueueBlock(p, msg, txProcessed)
 	//<-txProcessed
 
 	Logger.log.Info("Receive a new crossshard END")
@@ -624,7 +682,7 @@ func (serverObj *Server) OnShardToBeacon(p *peer.PeerConn,
 	Logger.log.Info("Receive a new shardToBeacon START")
 
 	var txProcessed chan struct{}
-	serverObj.netSync.QueueBlock(nil, msg, txProcessed)
+	serverObj.netSync.QueueBlock(p, msg, txProcessed)
 	//<-txProcessed
 
 	Logger.log.Info("Receive a new shardToBeacon END")
@@ -674,6 +732,9 @@ func (serverObj *Server) OnGetShardToBeacons(_ *peer.PeerConn, msg *wire.Message
 // transactions don't rely on the previous one in a linear fashion like blocks.
 func (serverObj *Server) OnTx(peer *peer.PeerConn, msg *wire.MessageTx) {
 	Logger.log.Info("Receive a new transaction START")
+	if msg.Transaction != nil {
+		peer.AddKnownInventory(*msg.Transaction.Hash())
+	}
 	var txProcessed chan struct{}
 	serverObj.netSync.QueueTx(nil, msg, txProcessed)
 	//<-txProcessed
@@ -754,6 +815,10 @@ func (serverObj *Server) OnVersion(peerConn *peer.PeerConn, msg *wire.MessageVer
 
 	msgV.(*wire.MessageVerAck).Valid = valid
 	msgV.(*wire.MessageVerAck).Timestamp = time.Now()
+	// switch to binary framing only if remote peer can read it
+	msgV.(*wire.MessageVerAck).BinaryFraming = msg.BinaryFraming
+	peerConn.SetInvRelay(msg.InvRelay)
+	peerConn.SetBinaryCodec(msg.BinaryCodec)
 
 	peerConn.QueueMessageWithEncoding(msgV, nil, peer.MESSAGE_TO_PEER, nil)
 
@@ -919,12 +984,16 @@ func (serverObj *Server) GetPeerIDsFromPublicKey(pubKey string) []libp2p.ID {
 }
 
 /*
-PushMessageToAll broadcast msg
+PushMessageToAll broadcast msg, txs and blocks are announced by inv to peers
+which support it
 */
 func (serverObj *Server) PushMessageToAll(msg wire.Message) error {
 	Logger.log.Info("Push msg to all peers")
 	var dc chan<- struct{}
 	msg.SetSenderID(serverObj.connManager.Config.ListenerPeer.PeerID)
+	if serverObj.relayInventory(msg) {
+		return nil
+	}
 	serverObj.connManager.Config.ListenerPeer.QueueMessageWithEncoding(msg, dc, peer.MESSAGE_TO_ALL, nil)
 	return nil
 }
@@ -1147,6 +1216,46 @@ func (serverObj *Server) PushMessageGetShardToBeacons(shardID byte, from uint64,
 	return nil
 }
 
+// newMessageTx - message of tx, the message type depends on the tx type so
+// that peers decode the tx type
+func newMessageTx(tx metadata.Transaction) (wire.Message, error) {
+	cmd := wire.CmdTx
+	switch tx.GetType() {
+	case common.TxCustomTokenType:
+		cmd = wire.CmdCustomToken
+	case common.TxCustomTokenPrivacyType:
+		cmd = wire.CmdPrivacyCustomToken
+	}
+	msg, err := wire.MakeEmptyMessage(cmd)
+	if err != nil {
+		return nil, err
+	}
+	msg.(*wire.MessageTx).Transaction = tx
+	return msg, nil
+}
+
+/*
+PushMessageTx relay a tx accepted by mempool
+*/
+func (serverObj *Server) PushMessageTx(tx metadata.Transaction) error {
+	msg, err := newMessageTx(tx)
+	if err != nil {
+		return err
+	}
+	return serverObj.PushMessageToAll(msg)
+}
+
+/*
+OnBlockRejected - add ban score to peer which sent a block failing validation
+*/
+func (serverObj *Server) OnBlockRejected(peerID libp2p.ID, reason string) {
+	peerConn := serverObj.connManager.Config.ListenerPeer.GetPeerConnByPeerID(peerID.Pretty())
+	if peerConn == nil {
+		return
+	}
+	peerConn.AddBanScore(peer.MisbehaviorRejectedBlock, reason)
+}
+
 func (serverObj *Server) PushVersionMessage(peerConn *peer.PeerConn) error {
 	// push message version
 	msg, err := wire.MakeEmptyMessage(wire.CmdVersion)
@@ -1158,6 +1267,9 @@ func (serverObj *Server) PushVersionMessage(peerConn *peer.PeerConn) error {
 	msg.(*wire.MessageVersion).RawRemoteAddress = peerConn.ListenerPeer.RawAddress
 	msg.(*wire.MessageVersion).RemotePeerId = peerConn.ListenerPeer.PeerID
 	msg.(*wire.MessageVersion).ProtocolVersion = serverObj.protocolVersion
+	msg.(*wire.MessageVersion).BinaryFraming = true
+	msg.(*wire.MessageVersion).InvRelay = true
+	msg.(*wire.MessageVersion).BinaryCodec = true
 	msg.(*wire.MessageVersion).PublicKey = peerConn.ListenerPeer.Config.UserKeySet.GetPublicKeyB58()
 	// Validate Public Key from UserPrvKey
 	// if peerConn.ListenerPeer.Config.UserKeySet != "" {
@@ -0,0 +1,41 @@
+package transaction
+
+import (
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
+	"github.com/ninjadotorg/constant/metadata"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	// transactions are tagged with their type, as ShardBody.UnmarshalJSON does for json
+	codec.RegisterInterface((*metadata.Transaction)(nil), func(value interface{}) (string, error) {
+		switch tx := value.(type) {
+		case *Tx:
+			if tx.Type == common.TxNormalType || tx.Type == common.TxSalaryType {
+				return tx.Type, nil
+			}
+			return "", errors.Errorf("invalid type %s of normal transaction", tx.Type)
+		case *TxCustomToken:
+			return common.TxCustomTokenType, nil
+		case *TxCustomTokenPrivacy:
+			return common.TxCustomTokenPrivacyType, nil
+		}
+		return "", errors.Errorf("can not encode transaction %T", value)
+	}, func(tag string) (interface{}, error) {
+		return NewTransaction(tag)
+	})
+}
+
+// NewTransaction returns an empty transaction of the concrete type used for txType
+func NewTransaction(txType string) (metadata.Transaction, error) {
+	switch txType {
+	case common.TxNormalType, common.TxSalaryType:
+		return &Tx{}, nil
+	case common.TxCustomTokenType:
+		return &TxCustomToken{}, nil
+	case common.TxCustomTokenPrivacyType:
+		return &TxCustomTokenPrivacy{}, nil
+	}
+	return nil, errors.Errorf("unknown transaction type %s", txType)
+}
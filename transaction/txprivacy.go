@@ -321,8 +321,8 @@ func (tx *Tx) signTx() error {
 	return nil
 }
 
-// verifySigTx - verify signature on tx
-func (tx *Tx) verifySigTx() (bool, error) {
+// VerifySigTx - verify signature on tx, it does not need data of chain
+func (tx *Tx) VerifySigTx() (bool, error) {
 	// check input transaction
 	if tx.Sig == nil || tx.SigPubKey == nil {
 		return false, errors.New("input transaction must be an signed one")
@@ -384,7 +384,7 @@ func (tx *Tx) ValidateTransaction(hasPrivacy bool, db database.DatabaseInterface
 	var valid bool
 	var err error
 
-	valid, err = tx.verifySigTx()
+	valid, err = tx.VerifySigTx()
 	if !valid {
 		if err != nil {
 			Logger.log.Infof("[PRIVACY LOG] - Error verifying signature of tx: %+v", err)
@@ -883,7 +883,7 @@ func (tx Tx) ValidateTxSalary(
 	db database.DatabaseInterface,
 ) bool {
 	// verify signature
-	valid, err := tx.verifySigTx()
+	valid, err := tx.VerifySigTx()
 	if !valid {
 		if err != nil {
 			Logger.log.Infof("Error verifying signature of tx: %+v", err)
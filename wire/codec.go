@@ -0,0 +1,35 @@
+package wire
+
+import (
+	"encoding/json"
+
+	"github.com/ninjadotorg/constant/common/codec"
+)
+
+/*
+BinaryMessage - message which can also be encoded with common/codec. The
+binary payload is only sent to peers which announce BinaryCodec in their
+version message, others get JsonSerialize
+*/
+type BinaryMessage interface {
+	Message
+	BinarySerialize() ([]byte, error)
+}
+
+// SerializeMessage returns payload of msg for a peer, binary is true if the
+// peer reads the binary codec
+func SerializeMessage(msg Message, binary bool) ([]byte, error) {
+	if binaryMsg, ok := msg.(BinaryMessage); ok && binary {
+		return binaryMsg.BinarySerialize()
+	}
+	return msg.JsonSerialize()
+}
+
+// unmarshalMessage decodes payload of either encoding, a codec payload starts
+// with codec.Version and a json one with '{'
+func unmarshalMessage(data []byte, msg interface{}) error {
+	if codec.IsEncoded(data) {
+		return codec.Unmarshal(data, msg)
+	}
+	return json.Unmarshal(data, msg)
+}
@@ -0,0 +1,76 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ninjadotorg/constant/blockchain"
+	"github.com/ninjadotorg/constant/common/codec"
+)
+
+func TestSerializeMessage(t *testing.T) {
+	msg := &MessageBlockBeacon{}
+	msg.Block.Header.Height = 7
+	msg.Block.ProducerSig = "sig"
+
+	jsonBytes, err := SerializeMessage(msg, false)
+	if err != nil {
+		t.Fatalf("SerializeMessage json %+v", err)
+	}
+	if codec.IsEncoded(jsonBytes) || jsonBytes[0] != '{' {
+		t.Fatalf("peer without binary codec should get json, got %x", jsonBytes[:1])
+	}
+	binaryBytes, err := SerializeMessage(msg, true)
+	if err != nil {
+		t.Fatalf("SerializeMessage binary %+v", err)
+	}
+	if !codec.IsEncoded(binaryBytes) {
+		t.Fatalf("peer with binary codec should get the codec payload")
+	}
+
+	for _, payload := range [][]byte{jsonBytes, binaryBytes} {
+		decoded := &MessageBlockBeacon{}
+		if err := decoded.JsonDeserialize(string(payload)); err != nil {
+			t.Fatalf("JsonDeserialize %+v", err)
+		}
+		if decoded.Block.Header.Height != 7 || decoded.Block.ProducerSig != "sig" {
+			t.Fatalf("decoded block differs %+v", decoded.Block)
+		}
+	}
+}
+
+// messages without a binary encoding are always sent in json
+func TestSerializeMessageJsonOnly(t *testing.T) {
+	msg := &MessageGetBlockBeacon{From: 1, To: 2}
+	binaryBytes, err := SerializeMessage(msg, true)
+	if err != nil {
+		t.Fatalf("SerializeMessage %+v", err)
+	}
+	jsonBytes, _ := msg.JsonSerialize()
+	if !bytes.Equal(binaryBytes, jsonBytes) {
+		t.Fatalf("message without BinarySerialize should be sent in json")
+	}
+}
+
+func TestBFTProposeKeepsJsonBlock(t *testing.T) {
+	block := blockchain.ShardBlock{}
+	block.Header.Height = 3
+	jsonBlock, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("json.Marshal %+v", err)
+	}
+	msg := &MessageBFTPropose{Block: jsonBlock, MsgSig: "sig"}
+	binaryBytes, err := SerializeMessage(msg, true)
+	if err != nil {
+		t.Fatalf("SerializeMessage %+v", err)
+	}
+	decoded := &MessageBFTPropose{}
+	if err := decoded.JsonDeserialize(string(binaryBytes)); err != nil {
+		t.Fatalf("JsonDeserialize %+v", err)
+	}
+	pendingBlock := blockchain.ShardBlock{}
+	if err := pendingBlock.UnmarshalJSON(decoded.Block); err != nil || pendingBlock.Header.Height != 3 {
+		t.Fatalf("block of propose should stay json, %+v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+/*
+Binary framing of messages, used instead of hex encoded gzip lines once both
+peers announce it in version/verack handshake. A frame is
+
+	magic    4 bytes   network id (Params.Net), big endian
+	command  12 bytes  cmd type of message, zero padded
+	forward  2 bytes   forward type and forward value of message header
+	length   4 bytes   length of payload, big endian
+	checksum 4 bytes   first 4 bytes of hash of payload
+	payload  message serialized by SerializeMessage
+*/
+const (
+	FrameMagicSize    = 4
+	FrameForwardSize  = 2
+	FrameLengthSize   = 4
+	FrameChecksumSize = 4
+	FrameHeaderSize   = FrameMagicSize + MessageCmdTypeSize + FrameForwardSize + FrameLengthSize + FrameChecksumSize
+)
+
+var (
+	ErrFrameMagic    = errors.New("frame magic does not match network")
+	ErrFrameTooLarge = errors.New("frame payload is larger than limit")
+	ErrFrameChecksum = errors.New("frame checksum does not match payload")
+)
+
+func frameChecksum(payload []byte) []byte {
+	hash := common.HashH(payload)
+	return hash[:FrameChecksumSize]
+}
+
+/*
+EncodeFrame - frame payload of a message, messageHeader is the
+MessageHeaderSize header of the message which carries its cmd type and forward
+info
+*/
+func EncodeFrame(magic uint32, messageHeader []byte, payload []byte) []byte {
+	frame := make([]byte, FrameHeaderSize, FrameHeaderSize+len(payload))
+	offset := 0
+	binary.BigEndian.PutUint32(frame[offset:], magic)
+	offset += FrameMagicSize
+	copy(frame[offset:offset+MessageCmdTypeSize+FrameForwardSize], messageHeader)
+	offset += MessageCmdTypeSize + FrameForwardSize
+	binary.BigEndian.PutUint32(frame[offset:], uint32(len(payload)))
+	offset += FrameLengthSize
+	copy(frame[offset:], frameChecksum(payload))
+	return append(frame, payload...)
+}
+
+/*
+ReadFrame - read a frame from r, returns the MessageHeaderSize header of the
+message and its payload. After ErrFrameMagic or ErrFrameTooLarge the stream is
+not readable anymore, after ErrFrameChecksum the frame is skipped and the next
+one can be read
+*/
+func ReadFrame(r io.Reader, magic uint32, maxPayload int) ([]byte, []byte, error) {
+	frameHeader := make([]byte, FrameHeaderSize)
+	if _, err := io.ReadFull(r, frameHeader); err != nil {
+		return nil, nil, err
+	}
+	offset := 0
+	if binary.BigEndian.Uint32(frameHeader[offset:]) != magic {
+		return nil, nil, ErrFrameMagic
+	}
+	offset += FrameMagicSize
+	messageHeader := make([]byte, MessageHeaderSize)
+	copy(messageHeader, frameHeader[offset:offset+MessageCmdTypeSize+FrameForwardSize])
+	offset += MessageCmdTypeSize + FrameForwardSize
+	length := binary.BigEndian.Uint32(frameHeader[offset:])
+	if uint64(length) > uint64(maxPayload) {
+		return nil, nil, ErrFrameTooLarge
+	}
+	offset += FrameLengthSize
+	checksum := frameHeader[offset : offset+FrameChecksumSize]
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(checksum, frameChecksum(payload)) {
+		return nil, nil, ErrFrameChecksum
+	}
+	return messageHeader, payload, nil
+}
@@ -0,0 +1,90 @@
+package wire
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+const testFrameMagic = 0x0ddba11
+
+func testMessageHeader(cmdType string, forwardType byte, forwardValue byte) []byte {
+	header := make([]byte, MessageHeaderSize)
+	copy(header, cmdType)
+	header[MessageCmdTypeSize] = forwardType
+	header[MessageCmdTypeSize+1] = forwardValue
+	return header
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	stream := &bytes.Buffer{}
+	payloads := [][]byte{[]byte("payload of tx"), {}, bytes.Repeat([]byte{7}, 1000)}
+	for _, payload := range payloads {
+		stream.Write(EncodeFrame(testFrameMagic, testMessageHeader(CmdTx, 's', 2), payload))
+	}
+	for i, payload := range payloads {
+		messageHeader, readPayload, err := ReadFrame(stream, testFrameMagic, 1000)
+		if err != nil {
+			t.Fatalf("ReadFrame %d error = %+v", i, err)
+		}
+		if !bytes.Equal(messageHeader, testMessageHeader(CmdTx, 's', 2)) {
+			t.Fatalf("ReadFrame %d header = %x", i, messageHeader)
+		}
+		if !bytes.Equal(readPayload, payload) {
+			t.Fatalf("ReadFrame %d payload = %x, want %x", i, readPayload, payload)
+		}
+	}
+	if _, _, err := ReadFrame(stream, testFrameMagic, 1000); err != io.EOF {
+		t.Fatalf("ReadFrame of drained stream error = %+v, want EOF", err)
+	}
+}
+
+func TestReadFrameWrongMagic(t *testing.T) {
+	frame := EncodeFrame(testFrameMagic+1, testMessageHeader(CmdTx, 0, 0), []byte("payload"))
+	if _, _, err := ReadFrame(bytes.NewReader(frame), testFrameMagic, 1000); err != ErrFrameMagic {
+		t.Fatalf("ReadFrame error = %+v, want ErrFrameMagic", err)
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	frame := EncodeFrame(testFrameMagic, testMessageHeader(CmdTx, 0, 0), bytes.Repeat([]byte{1}, 11))
+	if _, _, err := ReadFrame(bytes.NewReader(frame), testFrameMagic, 10); err != ErrFrameTooLarge {
+		t.Fatalf("ReadFrame error = %+v, want ErrFrameTooLarge", err)
+	}
+
+	// length is checked before the payload is allocated
+	frame = EncodeFrame(testFrameMagic, testMessageHeader(CmdTx, 0, 0), nil)
+	copy(frame[FrameMagicSize+MessageCmdTypeSize+FrameForwardSize:], []byte{0xff, 0xff, 0xff, 0xff})
+	if _, _, err := ReadFrame(bytes.NewReader(frame), testFrameMagic, 1000); err != ErrFrameTooLarge {
+		t.Fatalf("ReadFrame of forged length error = %+v, want ErrFrameTooLarge", err)
+	}
+}
+
+func TestReadFrameBadChecksum(t *testing.T) {
+	badFrame := EncodeFrame(testFrameMagic, testMessageHeader(CmdTx, 0, 0), []byte("payload"))
+	badFrame[len(badFrame)-1] ^= 1
+	stream := bytes.NewBuffer(badFrame)
+	stream.Write(EncodeFrame(testFrameMagic, testMessageHeader(CmdBlockShard, 0, 0), []byte("next")))
+
+	if _, _, err := ReadFrame(stream, testFrameMagic, 1000); err != ErrFrameChecksum {
+		t.Fatalf("ReadFrame error = %+v, want ErrFrameChecksum", err)
+	}
+	// the bad frame is consumed, the next frame is readable
+	messageHeader, payload, err := ReadFrame(stream, testFrameMagic, 1000)
+	if err != nil {
+		t.Fatalf("ReadFrame after bad checksum error = %+v", err)
+	}
+	if !bytes.Equal(messageHeader, testMessageHeader(CmdBlockShard, 0, 0)) || string(payload) != "next" {
+		t.Fatalf("ReadFrame after bad checksum = %x %q", messageHeader, payload)
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	frame := EncodeFrame(testFrameMagic, testMessageHeader(CmdTx, 0, 0), []byte("payload"))
+	if _, _, err := ReadFrame(bytes.NewReader(frame[:len(frame)-3]), testFrameMagic, 1000); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadFrame of truncated payload error = %+v, want ErrUnexpectedEOF", err)
+	}
+	if _, _, err := ReadFrame(bytes.NewReader(frame[:FrameHeaderSize-1]), testFrameMagic, 1000); err != io.ErrUnexpectedEOF {
+		t.Fatalf("ReadFrame of truncated header error = %+v, want ErrUnexpectedEOF", err)
+	}
+}
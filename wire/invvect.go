@@ -0,0 +1,66 @@
+package wire
+
+import (
+	"fmt"
+
+	"github.com/ninjadotorg/constant/common"
+)
+
+const (
+	// MaxInvPerMsg is the maximum number of inventory vectors in a single
+	// inv or getdata message
+	MaxInvPerMsg = 1000
+)
+
+// InvType - type of data announced by an inventory vector
+type InvType byte
+
+const (
+	InvTypeTx InvType = iota + 1
+	InvTypeBlockShard
+	InvTypeBlockBeacon
+)
+
+// InvVect - announcement of a tx or block by its hash
+type InvVect struct {
+	Type InvType
+	Hash common.Hash
+}
+
+func NewInvVect(invType InvType, hash *common.Hash) *InvVect {
+	return &InvVect{
+		Type: invType,
+		Hash: *hash,
+	}
+}
+
+/*
+InvVectOfMessage - inventory vector of a message which is relayed by
+inv/getdata, false if the message is always pushed in full
+*/
+func InvVectOfMessage(msg Message) (*InvVect, bool) {
+	switch msg := msg.(type) {
+	case *MessageTx:
+		if msg.Transaction == nil {
+			return nil, false
+		}
+		return NewInvVect(InvTypeTx, msg.Transaction.Hash()), true
+	case *MessageBlockShard:
+		return NewInvVect(InvTypeBlockShard, msg.Block.Hash()), true
+	case *MessageBlockBeacon:
+		return NewInvVect(InvTypeBlockBeacon, msg.Block.Hash()), true
+	}
+	return nil, false
+}
+
+func verifyInvList(invList []InvVect) error {
+	if len(invList) > MaxInvPerMsg {
+		return fmt.Errorf("too many inventory vectors %d, max %d", len(invList), MaxInvPerMsg)
+	}
+	for _, invVect := range invList {
+		if invVect.Type < InvTypeTx || invVect.Type > InvTypeBlockBeacon {
+			return fmt.Errorf("unknown inventory type %d", invVect.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package wire
+
+import (
+	"testing"
+
+	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/transaction"
+)
+
+// a tx is announced by inv, requested back by getdata with the same vectors,
+// then sent in full
+func TestInvGetDataRoundTrip(t *testing.T) {
+	tx := &transaction.Tx{Type: common.TxNormalType, LockTime: 1, Fee: 10}
+	msgTx, err := MakeEmptyMessage(CmdTx)
+	if err != nil {
+		t.Fatalf("MakeEmptyMessage %+v", err)
+	}
+	msgTx.(*MessageTx).Transaction = tx
+	invVect, ok := InvVectOfMessage(msgTx)
+	if !ok || invVect.Type != InvTypeTx || invVect.Hash != *tx.Hash() {
+		t.Fatalf("InvVectOfMessage() = %+v, %v", invVect, ok)
+	}
+
+	msgInv, _ := MakeEmptyMessage(CmdInv)
+	msgInv.(*MessageInv).InvList = []InvVect{*invVect}
+	invBytes, err := msgInv.JsonSerialize()
+	if err != nil {
+		t.Fatalf("JsonSerialize inv %+v", err)
+	}
+	receivedInv, _ := MakeEmptyMessage(CmdInv)
+	if err := receivedInv.JsonDeserialize(string(invBytes)); err != nil {
+		t.Fatalf("JsonDeserialize inv %+v", err)
+	}
+	if err := receivedInv.VerifyMsgSanity(); err != nil {
+		t.Fatalf("VerifyMsgSanity inv %+v", err)
+	}
+
+	msgGetData, _ := MakeEmptyMessage(CmdGetData)
+	msgGetData.(*MessageGetData).InvList = receivedInv.(*MessageInv).InvList
+	getDataBytes, err := msgGetData.JsonSerialize()
+	if err != nil {
+		t.Fatalf("JsonSerialize getdata %+v", err)
+	}
+	receivedGetData, _ := MakeEmptyMessage(CmdGetData)
+	if err := receivedGetData.JsonDeserialize(string(getDataBytes)); err != nil {
+		t.Fatalf("JsonDeserialize getdata %+v", err)
+	}
+	requested := receivedGetData.(*MessageGetData).InvList
+	if len(requested) != 1 || requested[0] != *invVect {
+		t.Fatalf("getdata requests %+v, want %+v", requested, *invVect)
+	}
+
+	txBytes, err := SerializeMessage(msgTx, true)
+	if err != nil {
+		t.Fatalf("SerializeMessage tx %+v", err)
+	}
+	receivedTx, _ := MakeEmptyMessage(CmdTx)
+	if err := receivedTx.JsonDeserialize(string(txBytes)); err != nil {
+		t.Fatalf("JsonDeserialize tx %+v", err)
+	}
+	if *receivedTx.(*MessageTx).Transaction.Hash() != requested[0].Hash {
+		t.Fatalf("delivered tx does not match the requested hash")
+	}
+}
+
+// tx hash does not commit to the signature, a copy with another signature is
+// announced under the same vector, see OnTxRejected
+func TestInvVectOfTxIgnoresSignature(t *testing.T) {
+	tx := &transaction.Tx{Type: common.TxNormalType, LockTime: 1, Fee: 10}
+	msgTx, _ := MakeEmptyMessage(CmdTx)
+	msgTx.(*MessageTx).Transaction = tx
+	invVect, _ := InvVectOfMessage(msgTx)
+
+	forged := *tx
+	forged.Sig = []byte("forged")
+	msgForged, _ := MakeEmptyMessage(CmdTx)
+	msgForged.(*MessageTx).Transaction = &forged
+	forgedInvVect, _ := InvVectOfMessage(msgForged)
+	if *forgedInvVect != *invVect {
+		t.Fatalf("copy with another signature should have the same vector")
+	}
+}
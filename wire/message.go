@@ -8,6 +8,7 @@ import (
 
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/ninjadotorg/constant/cashec"
+	"github.com/ninjadotorg/constant/common"
 	"github.com/ninjadotorg/constant/metadata"
 	"github.com/ninjadotorg/constant/transaction"
 )
@@ -67,6 +68,8 @@ type Message interface {
 	Hash() string
 	MessageType() string
 	MaxPayloadLength(int) int
+	// messages carrying blocks or transactions are encoded with
+	// common/codec, the others in json
 	JsonSerialize() ([]byte, error)
 	JsonDeserialize(string) error
 	SetSenderID(peer.ID) error
@@ -153,6 +156,12 @@ func MakeEmptyMessage(messageType string) (Message, error) {
 			Transaction: &transaction.Tx{},
 		}
 		break
+	case CmdInv:
+		msg = &MessageInv{}
+		break
+	case CmdGetData:
+		msg = &MessageGetData{}
+		break
 	case CmdVersion:
 		msg = &MessageVersion{}
 		break
@@ -251,6 +260,10 @@ func GetCmdType(msgType reflect.Type) (string, error) {
 		return CmdTx, nil
 		/*case reflect.TypeOf(&MessageRegistration{}):
 		  return CmdRegisteration, nil*/
+	case reflect.TypeOf(&MessageInv{}):
+		return CmdInv, nil
+	case reflect.TypeOf(&MessageGetData{}):
+		return CmdGetData, nil
 	case reflect.TypeOf(&MessageVersion{}):
 		return CmdVersion, nil
 	case reflect.TypeOf(&MessageVerAck{}):
@@ -293,3 +306,16 @@ func GetCmdType(msgType reflect.Type) (string, error) {
 		return "", fmt.Errorf("unhandled this message type [%s]", msgType)
 	}
 }
+
+// verifyProducerSig - block in a message must be signed by its producer,
+// genesis blocks are not signed
+func verifyProducerSig(producer string, producerSig string, blockHash common.Hash, height uint64) error {
+	if height <= 1 {
+		return nil
+	}
+	err := cashec.ValidateDataB58(producer, producerSig, blockHash.GetBytes())
+	if err != nil {
+		return fmt.Errorf("invalid producer signature of block %s at height %d: %+v", blockHash.String(), height, err)
+	}
+	return nil
+}
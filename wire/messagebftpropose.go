@@ -6,6 +6,7 @@ import (
 	"github.com/libp2p/go-libp2p-peer"
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 const (
@@ -13,6 +14,8 @@ const (
 )
 
 type MessageBFTPropose struct {
+	// Block is json encoded, peers which do not read the binary codec
+	// decode it too
 	Block  json.RawMessage
 	MsgSig string
 }
@@ -38,9 +41,13 @@ func (msg *MessageBFTPropose) JsonSerialize() ([]byte, error) {
 	return jsonBytes, err
 }
 
+func (msg *MessageBFTPropose) BinarySerialize() ([]byte, error) {
+	return codec.Marshal(msg)
+}
+
+// JsonDeserialize decodes a json or a binary payload
 func (msg *MessageBFTPropose) JsonDeserialize(jsonStr string) error {
-	err := json.Unmarshal([]byte(jsonStr), msg)
-	return err
+	return unmarshalMessage([]byte(jsonStr), msg)
 }
 
 func (msg *MessageBFTPropose) SetSenderID(senderID peer.ID) error {
@@ -7,6 +7,7 @@ import (
 	"github.com/ninjadotorg/constant/blockchain"
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 // const (
@@ -38,9 +39,13 @@ func (msg *MessageBlockBeacon) JsonSerialize() ([]byte, error) {
 	return jsonBytes, err
 }
 
+func (msg *MessageBlockBeacon) BinarySerialize() ([]byte, error) {
+	return codec.Marshal(msg)
+}
+
+// JsonDeserialize decodes a json or a binary payload
 func (msg *MessageBlockBeacon) JsonDeserialize(jsonStr string) error {
-	err := json.Unmarshal([]byte(jsonStr), msg)
-	return err
+	return unmarshalMessage([]byte(jsonStr), msg)
 }
 
 func (msg *MessageBlockBeacon) SetSenderID(senderID peer.ID) error {
@@ -52,5 +57,5 @@ func (msg *MessageBlockBeacon) SignMsg(_ *cashec.KeySet) error {
 }
 
 func (msg *MessageBlockBeacon) VerifyMsgSanity() error {
-	return nil
+	return verifyProducerSig(msg.Block.Header.Producer, msg.Block.ProducerSig, msg.Block.Header.Hash(), msg.Block.Header.Height)
 }
@@ -7,12 +7,25 @@ import (
 	"github.com/ninjadotorg/constant/blockchain"
 	"github.com/ninjadotorg/constant/cashec"
 	"github.com/ninjadotorg/constant/common"
+	"github.com/ninjadotorg/constant/common/codec"
 )
 
 const (
 	MaxBlockPayload = 1000000 // 1 Mb
+	// MaxBlocksPerRange is the maximum number of blocks served for a
+	// getblkshard, getblkbeacon or getshdtobcns message, a larger range is
+	// served up to this number of blocks
+	MaxBlocksPerRange = 500
 )
 
+// BlockRangeEnd returns the last height served for a range from..to
+func BlockRangeEnd(from uint64, to uint64) uint64 {
+	if to >= from && to-from >= MaxBlocksPerRange {
+		return from + MaxBlocksPerRange - 1
+	}
+	return to
+}
+
 type MessageBlockShard struct {
 	Block blockchain.ShardBlock
 }
@@ -38,9 +51,13 @@ func (msg *MessageBlockShard) JsonSerialize() ([]byte, error) {
 	return jsonBytes, err
 }
 
+func (msg *MessageBlockShard) BinarySerialize() ([]byte, error) {
+	return codec.Marshal(msg)
+}
+
+// JsonDeserialize decodes a json or a binary payload
 func (msg *MessageBlockShard) JsonDeserialize(jsonStr string) error {
-	err := json.Unmarshal([]byte(jsonStr), msg)
-	return err
+	return unmarshalMessage([]byte(jsonStr), msg)
 }
 
 func (msg *MessageBlockShard) SetSenderID(senderID peer.ID) error {
@@ -52,5 +69,5 @@ func (msg *MessageBlockShard) SignMsg(_ *cashec.KeySet) error {
 }
 
 func (msg *MessageBlockShard) VerifyMsgSanity() error {
-	return nil
+	return verifyProducerSig(msg.Block.Header.Producer, msg.Block.ProducerSig, msg.Block.Header.Hash(), msg.Block.Header.Height)
 }
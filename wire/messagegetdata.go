@@ -0,0 +1,57 @@
+package wire
+
+import (
+	"encoding/json"
+
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/ninjadotorg/constant/cashec"
+	"github.com/ninjadotorg/constant/common"
+)
+
+const (
+	MaxGetDataPayload = 50000 // 50 Kb
+)
+
+// MessageGetData - request txs and blocks announced by an inv message
+type MessageGetData struct {
+	InvList   []InvVect
+	Timestamp int64
+}
+
+func (msg *MessageGetData) Hash() string {
+	rawBytes, err := msg.JsonSerialize()
+	if err != nil {
+		return ""
+	}
+	return common.HashH(rawBytes).String()
+}
+
+func (msg *MessageGetData) MessageType() string {
+	return CmdGetData
+}
+
+func (msg *MessageGetData) MaxPayloadLength(pver int) int {
+	return MaxGetDataPayload
+}
+
+func (msg *MessageGetData) JsonSerialize() ([]byte, error) {
+	jsonBytes, err := json.Marshal(msg)
+	return jsonBytes, err
+}
+
+func (msg *MessageGetData) JsonDeserialize(jsonStr string) error {
+	err := json.Unmarshal([]byte(jsonStr), msg)
+	return err
+}
+
+func (msg *MessageGetData) SetSenderID(senderID peer.ID) error {
+	return nil
+}
+
+func (msg *MessageGetData) SignMsg(_ *cashec.KeySet) error {
+	return nil
+}
+
+func (msg *MessageGetData) VerifyMsgSanity() error {
+	return verifyInvList(msg.InvList)
+}
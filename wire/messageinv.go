@@ -0,0 +1,57 @@
+package wire
+
+import (
+	"encoding/json"
+
+	"github.com/libp2p/go-libp2p-peer"
+	"github.com/ninjadotorg/constant/cashec"
+	"github.com/ninjadotorg/constant/common"
+)
+
+const (
+	MaxInvPayload = 50000 // 50 Kb
+)
+
+// MessageInv - announce txs and blocks which the sender has
+type MessageInv struct {
+	InvList   []InvVect
+	Timestamp int64
+}
+
+func (msg *MessageInv) Hash() string {
+	rawBytes, err := msg.JsonSerialize()
+	if err != nil {
+		return ""
+	}
+	return common.HashH(rawBytes).String()
+}
+
+func (msg *MessageInv) MessageType() string {
+	return CmdInv
+}
+
+func (msg *MessageInv) MaxPayloadLength(pver int) int {
+	return MaxInvPayload
+}
+
+func (msg *MessageInv) JsonSerialize() ([]byte, error) {
+	jsonBytes, err := json.Marshal(msg)
+	return jsonBytes, err
+}
+
+func (msg *MessageInv) JsonDeserialize(jsonStr string) error {
+	err := json.Unmarshal([]byte(jsonStr), msg)
+	return err
+}
+
+func (msg *MessageInv) SetSenderID(senderID peer.ID) error {
+	return nil
+}
+
+func (msg *MessageInv) SignMsg(_ *cashec.KeySet) error {
+	return nil
+}
+
+func (msg *MessageInv) VerifyMsgSanity() error {
+	return verifyInvList(msg.InvList)
+}
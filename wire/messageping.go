@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"time"
@@ -41,8 +40,7 @@ func (msg *MessagePing) JsonSerialize() ([]byte, error) {
 }
 
 func (msg *MessagePing) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 func (msg *MessagePing) SetSenderID(senderID peer.ID) error {
@@ -1,7 +1,6 @@
 package wire
 
 /*import (
-	"encoding/hex"
 	"encoding/json"
 
 	"github.com/libp2p/go-libp2p-peer"
@@ -30,8 +29,7 @@ func (msg MessageRegistration) JsonSerialize() ([]byte, error) {
 }
 
 func (msg MessageRegistration) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 
@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"time"
@@ -14,6 +13,8 @@ import (
 type MessageVerAck struct {
 	Valid     bool
 	Timestamp time.Time
+	// messages after this verack are sent in binary frames, see EncodeFrame
+	BinaryFraming bool
 }
 
 func (msg *MessageVerAck) Hash() string {
@@ -38,8 +39,7 @@ func (msg *MessageVerAck) JsonSerialize() ([]byte, error) {
 }
 
 func (msg *MessageVerAck) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 
@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"github.com/libp2p/go-libp2p-peer"
@@ -24,6 +23,13 @@ type MessageVersion struct {
 	LocalPeerId      peer.ID
 	PublicKey        string
 	SignDataB58      string
+	// sender can read binary frames, see EncodeFrame
+	BinaryFraming bool
+	// sender relays txs and blocks by inv/getdata
+	InvRelay bool
+	// sender reads blocks and txs encoded with common/codec, see
+	// SerializeMessage
+	BinaryCodec bool
 }
 
 func (msg *MessageVersion) Hash() string {
@@ -48,8 +54,7 @@ func (msg *MessageVersion) JsonSerialize() ([]byte, error) {
 }
 
 func (msg *MessageVersion) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 
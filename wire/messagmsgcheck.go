@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"github.com/libp2p/go-libp2p-peer"
@@ -12,6 +11,8 @@ import (
 type MessageMsgCheck struct {
 	HashStr   string
 	Timestamp int64
+	// cmd type of the heavy message, empty from older peers
+	CmdType string
 }
 
 func (msg *MessageMsgCheck) Hash() string {
@@ -36,8 +37,7 @@ func (msg *MessageMsgCheck) JsonSerialize() ([]byte, error) {
 }
 
 func (msg *MessageMsgCheck) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 
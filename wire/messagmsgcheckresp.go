@@ -1,7 +1,6 @@
 package wire
 
 import (
-	"encoding/hex"
 	"encoding/json"
 
 	"github.com/libp2p/go-libp2p-peer"
@@ -38,8 +37,7 @@ func (msg *MessageMsgCheckResp) JsonSerialize() ([]byte, error) {
 }
 
 func (msg *MessageMsgCheckResp) JsonDeserialize(jsonStr string) error {
-	jsonDecodeString, _ := hex.DecodeString(jsonStr)
-	err := json.Unmarshal([]byte(jsonDecodeString), msg)
+	err := json.Unmarshal([]byte(jsonStr), msg)
 	return err
 }
 